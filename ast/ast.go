@@ -451,3 +451,105 @@ func (hl *HashLiteral) String() string {
 
 // TokenLiteral returns the token literal.
 func (hl *HashLiteral) TokenLiteral() string { return hl.Token.Literal }
+
+// Pos returns the position of the token of a node, which is
+// invalid for a node without a token (e.g. a Program).
+func Pos(node Node) token.Position {
+	switch n := node.(type) {
+	case *LetStatement:
+		return n.Token.Pos
+	case *Identifier:
+		return n.Token.Pos
+	case *LineComment:
+		return n.Token.Pos
+	case *ReturnStatement:
+		return n.Token.Pos
+	case *ExpressionStatement:
+		return n.Token.Pos
+	case *IntegerLiteral:
+		return n.Token.Pos
+	case *FloatLiteral:
+		return n.Token.Pos
+	case *StringLiteral:
+		return n.Token.Pos
+	case *PrefixExpression:
+		return n.Token.Pos
+	case *InfixExpression:
+		return n.Token.Pos
+	case *BooleanLiteral:
+		return n.Token.Pos
+	case *IfExpression:
+		return n.Token.Pos
+	case *BlockStatement:
+		return n.Token.Pos
+	case *FunctionLiteral:
+		return n.Token.Pos
+	case *CallExpression:
+		return n.Token.Pos
+	case *ArrayLiteral:
+		return n.Token.Pos
+	case *IndexExpression:
+		return n.Token.Pos
+	case *HashLiteral:
+		return n.Token.Pos
+	case *NamedArgument:
+		return n.Token.Pos
+	case *CirclePrimitive:
+		return n.Token.Pos
+	case *CubePrimitive:
+		return n.Token.Pos
+	case *CylinderPrimitive:
+		return n.Token.Pos
+	case *GroupPrimitive:
+		return n.Token.Pos
+	case *PolygonPrimitive:
+		return n.Token.Pos
+	case *PolyhedronPrimitive:
+		return n.Token.Pos
+	case *SpherePrimitive:
+		return n.Token.Pos
+	case *SquarePrimitive:
+		return n.Token.Pos
+	case *TextPrimitive:
+		return n.Token.Pos
+	case *UndefLiteral:
+		return n.Token.Pos
+	case *ColorBlockPrimitive:
+		return n.Token.Pos
+	case *DifferenceBlockPrimitive:
+		return n.Token.Pos
+	case *GroupBlockPrimitive:
+		return n.Token.Pos
+	case *HullBlockPrimitive:
+		return n.Token.Pos
+	case *IntersectionBlockPrimitive:
+		return n.Token.Pos
+	case *LinearExtrudeBlockPrimitive:
+		return n.Token.Pos
+	case *MinkowskiBlockPrimitive:
+		return n.Token.Pos
+	case *MirrorBlockPrimitive:
+		return n.Token.Pos
+	case *MultmatrixBlockPrimitive:
+		return n.Token.Pos
+	case *OffsetBlockPrimitive:
+		return n.Token.Pos
+	case *ProjectionBlockPrimitive:
+		return n.Token.Pos
+	case *ResizeBlockPrimitive:
+		return n.Token.Pos
+	case *RotateBlockPrimitive:
+		return n.Token.Pos
+	case *RotateExtrudeBlockPrimitive:
+		return n.Token.Pos
+	case *ScaleBlockPrimitive:
+		return n.Token.Pos
+	case *TranslateBlockPrimitive:
+		return n.Token.Pos
+	case *UnionBlockPrimitive:
+		return n.Token.Pos
+	case *ModifierExpression:
+		return n.Token.Pos
+	}
+	return token.Position{}
+}
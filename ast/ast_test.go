@@ -1,6 +1,7 @@
 package ast
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/gmlewis/go-csg/token"
@@ -27,3 +28,32 @@ func TestString(t *testing.T) {
 		t.Errorf("program.String() = %v, want %v", got, want)
 	}
 }
+
+func TestJSON(t *testing.T) {
+	program := &Program{
+		Statements: []Statement{
+			&ExpressionStatement{
+				Token: token.Token{Type: token.CUBE, Literal: "cube"},
+				Expression: &CubePrimitive{
+					Token: token.Token{Type: token.CUBE, Literal: "cube"},
+					Arguments: []Expression{
+						&NamedArgument{
+							Token: token.Token{Type: token.ASSIGN, Literal: "="},
+							Name:  &Identifier{Token: token.Token{Type: token.IDENT, Literal: "size"}, Value: "size"},
+							Value: &IntegerLiteral{Token: token.Token{Type: token.INT, Literal: "2"}, Value: 2},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	buf, err := json.Marshal(JSON(program))
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+	want := `{"statements":[{"expression":{"arguments":[{"name":{"type":"Identifier","value":"size"},"type":"NamedArgument","value":{"type":"IntegerLiteral","value":2}}],"type":"CubePrimitive"},"type":"ExpressionStatement"}],"type":"Program"}`
+	if got := string(buf); got != want {
+		t.Errorf("JSON =\n%v\nwant:\n%v", got, want)
+	}
+}
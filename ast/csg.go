@@ -262,6 +262,12 @@ func (gbp *GroupBlockPrimitive) String() string {
 // TokenLiteral returns the token literal.
 func (gbp *GroupBlockPrimitive) TokenLiteral() string { return gbp.Token.Literal }
 
+// NewGroup returns a group block with the body, for tools that
+// synthesize groups (e.g. to replace a node that has several children).
+func NewGroup(body *BlockStatement) *GroupBlockPrimitive {
+	return &GroupBlockPrimitive{Token: token.Token{Type: token.GROUP, Literal: "group"}, Body: body}
+}
+
 // HullBlockPrimitive represents a CSG block primitive.
 type HullBlockPrimitive struct {
 	Token token.Token
@@ -328,6 +334,23 @@ func (mbp *MinkowskiBlockPrimitive) String() string {
 // TokenLiteral returns the token literal.
 func (mbp *MinkowskiBlockPrimitive) TokenLiteral() string { return mbp.Token.Literal }
 
+// MirrorBlockPrimitive represents a CSG block primitive.
+type MirrorBlockPrimitive struct {
+	Token     token.Token
+	Arguments []Expression
+	Body      *BlockStatement
+}
+
+func (mbp *MirrorBlockPrimitive) expressionNode() {}
+
+// String returns the string representation of the Node.
+func (mbp *MirrorBlockPrimitive) String() string {
+	return blockPrimitiveString(mbp.TokenLiteral(), mbp.Arguments, mbp.Body)
+}
+
+// TokenLiteral returns the token literal.
+func (mbp *MirrorBlockPrimitive) TokenLiteral() string { return mbp.Token.Literal }
+
 // MultmatrixBlockPrimitive represents a CSG block primitive.
 type MultmatrixBlockPrimitive struct {
 	Token     token.Token
@@ -345,6 +368,23 @@ func (mbp *MultmatrixBlockPrimitive) String() string {
 // TokenLiteral returns the token literal.
 func (mbp *MultmatrixBlockPrimitive) TokenLiteral() string { return mbp.Token.Literal }
 
+// OffsetBlockPrimitive represents a CSG block primitive.
+type OffsetBlockPrimitive struct {
+	Token     token.Token
+	Arguments []Expression
+	Body      *BlockStatement
+}
+
+func (obp *OffsetBlockPrimitive) expressionNode() {}
+
+// String returns the string representation of the Node.
+func (obp *OffsetBlockPrimitive) String() string {
+	return blockPrimitiveString(obp.TokenLiteral(), obp.Arguments, obp.Body)
+}
+
+// TokenLiteral returns the token literal.
+func (obp *OffsetBlockPrimitive) TokenLiteral() string { return obp.Token.Literal }
+
 // ProjectionBlockPrimitive represents a CSG block primitive.
 type ProjectionBlockPrimitive struct {
 	Token     token.Token
@@ -362,6 +402,40 @@ func (pbp *ProjectionBlockPrimitive) String() string {
 // TokenLiteral returns the token literal.
 func (pbp *ProjectionBlockPrimitive) TokenLiteral() string { return pbp.Token.Literal }
 
+// ResizeBlockPrimitive represents a CSG block primitive.
+type ResizeBlockPrimitive struct {
+	Token     token.Token
+	Arguments []Expression
+	Body      *BlockStatement
+}
+
+func (rbp *ResizeBlockPrimitive) expressionNode() {}
+
+// String returns the string representation of the Node.
+func (rbp *ResizeBlockPrimitive) String() string {
+	return blockPrimitiveString(rbp.TokenLiteral(), rbp.Arguments, rbp.Body)
+}
+
+// TokenLiteral returns the token literal.
+func (rbp *ResizeBlockPrimitive) TokenLiteral() string { return rbp.Token.Literal }
+
+// RotateBlockPrimitive represents a CSG block primitive.
+type RotateBlockPrimitive struct {
+	Token     token.Token
+	Arguments []Expression
+	Body      *BlockStatement
+}
+
+func (rbp *RotateBlockPrimitive) expressionNode() {}
+
+// String returns the string representation of the Node.
+func (rbp *RotateBlockPrimitive) String() string {
+	return blockPrimitiveString(rbp.TokenLiteral(), rbp.Arguments, rbp.Body)
+}
+
+// TokenLiteral returns the token literal.
+func (rbp *RotateBlockPrimitive) TokenLiteral() string { return rbp.Token.Literal }
+
 // RotateExtrudeBlockPrimitive represents a CSG block primitive.
 type RotateExtrudeBlockPrimitive struct {
 	Token     token.Token
@@ -379,6 +453,40 @@ func (rebp *RotateExtrudeBlockPrimitive) String() string {
 // TokenLiteral returns the token literal.
 func (rebp *RotateExtrudeBlockPrimitive) TokenLiteral() string { return rebp.Token.Literal }
 
+// ScaleBlockPrimitive represents a CSG block primitive.
+type ScaleBlockPrimitive struct {
+	Token     token.Token
+	Arguments []Expression
+	Body      *BlockStatement
+}
+
+func (sbp *ScaleBlockPrimitive) expressionNode() {}
+
+// String returns the string representation of the Node.
+func (sbp *ScaleBlockPrimitive) String() string {
+	return blockPrimitiveString(sbp.TokenLiteral(), sbp.Arguments, sbp.Body)
+}
+
+// TokenLiteral returns the token literal.
+func (sbp *ScaleBlockPrimitive) TokenLiteral() string { return sbp.Token.Literal }
+
+// TranslateBlockPrimitive represents a CSG block primitive.
+type TranslateBlockPrimitive struct {
+	Token     token.Token
+	Arguments []Expression
+	Body      *BlockStatement
+}
+
+func (tbp *TranslateBlockPrimitive) expressionNode() {}
+
+// String returns the string representation of the Node.
+func (tbp *TranslateBlockPrimitive) String() string {
+	return blockPrimitiveString(tbp.TokenLiteral(), tbp.Arguments, tbp.Body)
+}
+
+// TokenLiteral returns the token literal.
+func (tbp *TranslateBlockPrimitive) TokenLiteral() string { return tbp.Token.Literal }
+
 // UnionBlockPrimitive represents a CSG block primitive.
 type UnionBlockPrimitive struct {
 	Token token.Token
@@ -394,3 +502,89 @@ func (ubp *UnionBlockPrimitive) String() string {
 
 // TokenLiteral returns the token literal.
 func (ubp *UnionBlockPrimitive) TokenLiteral() string { return ubp.Token.Literal }
+
+// ModifierExpression represents a CSG modifier character applied to
+// a primitive or block primitive:
+// '*' (disable), '#' (highlight) or '%' (background).
+type ModifierExpression struct {
+	Token    token.Token // The modifier token, e.g. *
+	Modifier string
+	Right    Expression
+}
+
+func (me *ModifierExpression) expressionNode() {}
+
+// String returns the string representation of the Node.
+func (me *ModifierExpression) String() string {
+	return me.Modifier + me.Right.String()
+}
+
+// TokenLiteral returns the token literal.
+func (me *ModifierExpression) TokenLiteral() string { return me.Token.Literal }
+
+// Disabled reports whether the modifier excludes its geometry from
+// the rendered model, which is the case for '*' (disable) and '%'
+// (background). A '#' (highlight) modifier is rendered as usual.
+func (me *ModifierExpression) Disabled() bool {
+	return me.Modifier == "*" || me.Modifier == "%"
+}
+
+// Parts returns the arguments and (for block primitives) body of a CSG
+// primitive, where ok reports whether exp is a CSG primitive at all.
+func Parts(exp Expression) (args []Expression, body *BlockStatement, ok bool) {
+	switch n := exp.(type) {
+	case *CirclePrimitive:
+		return n.Arguments, nil, true
+	case *CubePrimitive:
+		return n.Arguments, nil, true
+	case *CylinderPrimitive:
+		return n.Arguments, nil, true
+	case *GroupPrimitive:
+		return n.Arguments, nil, true
+	case *PolygonPrimitive:
+		return n.Arguments, nil, true
+	case *PolyhedronPrimitive:
+		return n.Arguments, nil, true
+	case *SpherePrimitive:
+		return n.Arguments, nil, true
+	case *SquarePrimitive:
+		return n.Arguments, nil, true
+	case *TextPrimitive:
+		return n.Arguments, nil, true
+	case *ColorBlockPrimitive:
+		return n.Arguments, n.Body, true
+	case *DifferenceBlockPrimitive:
+		return nil, n.Body, true
+	case *GroupBlockPrimitive:
+		return nil, n.Body, true
+	case *HullBlockPrimitive:
+		return nil, n.Body, true
+	case *IntersectionBlockPrimitive:
+		return nil, n.Body, true
+	case *LinearExtrudeBlockPrimitive:
+		return n.Arguments, n.Body, true
+	case *MinkowskiBlockPrimitive:
+		return n.Arguments, n.Body, true
+	case *MirrorBlockPrimitive:
+		return n.Arguments, n.Body, true
+	case *MultmatrixBlockPrimitive:
+		return n.Arguments, n.Body, true
+	case *OffsetBlockPrimitive:
+		return n.Arguments, n.Body, true
+	case *ProjectionBlockPrimitive:
+		return n.Arguments, n.Body, true
+	case *ResizeBlockPrimitive:
+		return n.Arguments, n.Body, true
+	case *RotateBlockPrimitive:
+		return n.Arguments, n.Body, true
+	case *RotateExtrudeBlockPrimitive:
+		return n.Arguments, n.Body, true
+	case *ScaleBlockPrimitive:
+		return n.Arguments, n.Body, true
+	case *TranslateBlockPrimitive:
+		return n.Arguments, n.Body, true
+	case *UnionBlockPrimitive:
+		return nil, n.Body, true
+	}
+	return nil, nil, false
+}
@@ -0,0 +1,76 @@
+package ast_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gmlewis/go-csg/ast"
+	"github.com/gmlewis/go-csg/token"
+)
+
+func TestParts(t *testing.T) {
+	tests := []struct {
+		src  string
+		args int
+		body int // -1 for no body
+		ok   bool
+	}{
+		{src: "cube(size = 1, center = true);", args: 2, body: -1, ok: true},
+		{src: "group();", args: 0, body: -1, ok: true},
+		{src: "difference() { cube(size = 1); sphere(r = 1); }", args: 0, body: 2, ok: true},
+		{src: "translate([1, 0, 0]) { }", args: 1, body: 0, ok: true},
+		{src: "// comment", body: -1},
+		{src: "1 + 2;", body: -1},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test #%v", i), func(t *testing.T) {
+			program := parse(t, tt.src)
+			exp := program.Statements[0].(*ast.ExpressionStatement).Expression
+			args, body, ok := ast.Parts(exp)
+			if ok != tt.ok {
+				t.Errorf("ok = %v, want %v", ok, tt.ok)
+			}
+			if len(args) != tt.args {
+				t.Errorf("got %v args, want %v", len(args), tt.args)
+			}
+			switch {
+			case body == nil && tt.body != -1:
+				t.Errorf("body = nil, want %v statements", tt.body)
+			case body != nil && len(body.Statements) != tt.body:
+				t.Errorf("got %v body statements, want %v", len(body.Statements), tt.body)
+			}
+		})
+	}
+}
+
+func TestPos(t *testing.T) {
+	program := parse(t, "cube(size = 1);\n  #sphere(r = 2);")
+	var got []string
+	ast.Inspect(program, func(node ast.Node) bool {
+		if node == nil {
+			return false
+		}
+		got = append(got, fmt.Sprintf("%v:%v", ast.Pos(node), node.TokenLiteral()))
+		return true
+	})
+	want := []string{
+		"-:cube",
+		"1:1:cube", "1:1:cube", "1:11:=", "1:6:size", "1:13:1",
+		"2:3:#", "2:3:#", "2:4:sphere", "2:13:=", "2:11:r", "2:15:2",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("positions =\n%v\nwant:\n%v", got, want)
+	}
+
+	if p := ast.Pos(&ast.Program{}); p != (token.Position{}) {
+		t.Errorf("Pos(Program) = %v, want the zero Position", p)
+	}
+}
+
+func TestNewGroup(t *testing.T) {
+	body := parse(t, "group() { cube(size = 1); }").Statements[0].(*ast.ExpressionStatement).Expression.(*ast.GroupBlockPrimitive).Body
+	if got, want := ast.NewGroup(body).String(), "group() { cube(size = 1) }"; got != want {
+		t.Errorf("NewGroup = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,66 @@
+package ast
+
+import (
+	"reflect"
+	"sort"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gmlewis/go-csg/token"
+)
+
+var tokenType = reflect.TypeOf(token.Token{})
+
+// JSON returns a representation of the node that encoding/json can
+// marshal. Each node becomes an object with its "type" (e.g.
+// "CubePrimitive") and its fields (except for its token) named in
+// lowerCamelCase. The pairs of a HashLiteral are sorted by key.
+func JSON(node Node) interface{} {
+	return jsonValue(reflect.ValueOf(node))
+}
+
+func jsonValue(v reflect.Value) interface{} {
+	switch v.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		return jsonValue(v.Elem())
+	case reflect.Struct:
+		t := v.Type()
+		result := map[string]interface{}{"type": t.Name()}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.Type == tokenType || f.PkgPath != "" {
+				continue
+			}
+			result[lowerFirst(f.Name)] = jsonValue(v.Field(i))
+		}
+		return result
+	case reflect.Slice:
+		result := make([]interface{}, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			result = append(result, jsonValue(v.Index(i)))
+		}
+		return result
+	case reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return keys[i].Interface().(Node).String() < keys[j].Interface().(Node).String()
+		})
+		result := make([]interface{}, 0, len(keys))
+		for _, k := range keys {
+			result = append(result, map[string]interface{}{
+				"key":   jsonValue(k),
+				"value": jsonValue(v.MapIndex(k)),
+			})
+		}
+		return result
+	}
+	return v.Interface()
+}
+
+func lowerFirst(s string) string {
+	r, n := utf8.DecodeRuneInString(s)
+	return string(unicode.ToLower(r)) + s[n:]
+}
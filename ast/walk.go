@@ -0,0 +1,413 @@
+package ast
+
+import (
+	"fmt"
+	"sort"
+)
+
+// A Visitor's Visit method is invoked for each node encountered by Walk.
+// If the result visitor w is not nil, Walk visits each of the children
+// of node with the visitor w, followed by a call of w.Visit(nil).
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// Walk traverses an AST in depth-first order: It starts by calling
+// v.Visit(node); node must not be nil. If the visitor w returned by
+// v.Visit(node) is not nil, Walk is invoked recursively with visitor
+// w for each of the non-nil children of node, followed by a call of
+// w.Visit(nil).
+func Walk(v Visitor, node Node) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+
+	walkList := func(exps []Expression) {
+		for _, exp := range exps {
+			if exp != nil {
+				Walk(v, exp)
+			}
+		}
+	}
+	walkBlock := func(block *BlockStatement) {
+		if block != nil {
+			Walk(v, block)
+		}
+	}
+	walkExp := func(exp Expression) {
+		if exp != nil {
+			Walk(v, exp)
+		}
+	}
+
+	switch n := node.(type) {
+	case *Program:
+		for _, stmt := range n.Statements {
+			if stmt != nil {
+				Walk(v, stmt)
+			}
+		}
+	case *BlockStatement:
+		for _, stmt := range n.Statements {
+			if stmt != nil {
+				Walk(v, stmt)
+			}
+		}
+
+	// Statements
+	case *LetStatement:
+		if n.Name != nil {
+			Walk(v, n.Name)
+		}
+		walkExp(n.Value)
+	case *ReturnStatement:
+		walkExp(n.ReturnValue)
+	case *ExpressionStatement:
+		walkExp(n.Expression)
+
+	// Expressions
+	case *Identifier, *LineComment, *IntegerLiteral, *FloatLiteral,
+		*StringLiteral, *BooleanLiteral, *UndefLiteral:
+		// nothing to do
+	case *PrefixExpression:
+		walkExp(n.Right)
+	case *ModifierExpression:
+		walkExp(n.Right)
+	case *InfixExpression:
+		walkExp(n.Left)
+		walkExp(n.Right)
+	case *IfExpression:
+		walkExp(n.Condition)
+		walkBlock(n.Consequence)
+		walkBlock(n.Alternative)
+	case *FunctionLiteral:
+		for _, param := range n.Parameters {
+			if param != nil {
+				Walk(v, param)
+			}
+		}
+		walkBlock(n.Body)
+	case *CallExpression:
+		walkExp(n.Function)
+		walkList(n.Arguments)
+	case *ArrayLiteral:
+		walkList(n.Elements)
+	case *IndexExpression:
+		walkExp(n.Left)
+		walkExp(n.Index)
+	case *HashLiteral:
+		for _, key := range sortedKeys(n.Pairs) {
+			walkExp(key)
+			walkExp(n.Pairs[key])
+		}
+	case *NamedArgument:
+		walkExp(n.Name)
+		walkExp(n.Value)
+
+	// CSG primitives
+	case *CirclePrimitive:
+		walkList(n.Arguments)
+	case *CubePrimitive:
+		walkList(n.Arguments)
+	case *CylinderPrimitive:
+		walkList(n.Arguments)
+	case *GroupPrimitive:
+		walkList(n.Arguments)
+	case *PolygonPrimitive:
+		walkList(n.Arguments)
+	case *PolyhedronPrimitive:
+		walkList(n.Arguments)
+	case *SpherePrimitive:
+		walkList(n.Arguments)
+	case *SquarePrimitive:
+		walkList(n.Arguments)
+	case *TextPrimitive:
+		walkList(n.Arguments)
+
+	// CSG block primitives
+	case *ColorBlockPrimitive:
+		walkList(n.Arguments)
+		walkBlock(n.Body)
+	case *DifferenceBlockPrimitive:
+		walkBlock(n.Body)
+	case *GroupBlockPrimitive:
+		walkBlock(n.Body)
+	case *HullBlockPrimitive:
+		walkBlock(n.Body)
+	case *IntersectionBlockPrimitive:
+		walkBlock(n.Body)
+	case *LinearExtrudeBlockPrimitive:
+		walkList(n.Arguments)
+		walkBlock(n.Body)
+	case *MinkowskiBlockPrimitive:
+		walkList(n.Arguments)
+		walkBlock(n.Body)
+	case *MirrorBlockPrimitive:
+		walkList(n.Arguments)
+		walkBlock(n.Body)
+	case *MultmatrixBlockPrimitive:
+		walkList(n.Arguments)
+		walkBlock(n.Body)
+	case *OffsetBlockPrimitive:
+		walkList(n.Arguments)
+		walkBlock(n.Body)
+	case *ProjectionBlockPrimitive:
+		walkList(n.Arguments)
+		walkBlock(n.Body)
+	case *ResizeBlockPrimitive:
+		walkList(n.Arguments)
+		walkBlock(n.Body)
+	case *RotateBlockPrimitive:
+		walkList(n.Arguments)
+		walkBlock(n.Body)
+	case *RotateExtrudeBlockPrimitive:
+		walkList(n.Arguments)
+		walkBlock(n.Body)
+	case *ScaleBlockPrimitive:
+		walkList(n.Arguments)
+		walkBlock(n.Body)
+	case *TranslateBlockPrimitive:
+		walkList(n.Arguments)
+		walkBlock(n.Body)
+	case *UnionBlockPrimitive:
+		walkBlock(n.Body)
+
+	default:
+		panic(fmt.Sprintf("ast.Walk: unexpected node type %T", n))
+	}
+
+	v.Visit(nil)
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverses an AST in depth-first order: It starts by calling
+// f(node); node must not be nil. If f returns true, Inspect invokes f
+// recursively for each of the non-nil children of node, followed by a
+// call of f(nil).
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
+
+// Rewrite traverses an AST in depth-first order, rewriting the children
+// of each node before calling f on the node itself. The node is replaced
+// by the result of f, which is returned.
+//
+// Within the statements of a Program or BlockStatement, f may also
+// return nil to remove the statement, a bare Expression (which is
+// wrapped in an ExpressionStatement), or a *BlockStatement whose
+// statements are spliced in its place. Elsewhere, the result must be
+// of a type that fits the field being rewritten, otherwise Rewrite panics.
+func Rewrite(node Node, f func(Node) Node) Node {
+	switch n := node.(type) {
+	case *Program:
+		n.Statements = rewriteStatements(n.Statements, f)
+	case *BlockStatement:
+		n.Statements = rewriteStatements(n.Statements, f)
+
+	// Statements
+	case *LetStatement:
+		if n.Name != nil {
+			n.Name = rewriteIdentifier(n.Name, f)
+		}
+		n.Value = rewriteExp(n.Value, f)
+	case *ReturnStatement:
+		n.ReturnValue = rewriteExp(n.ReturnValue, f)
+	case *ExpressionStatement:
+		n.Expression = rewriteExp(n.Expression, f)
+
+	// Expressions
+	case *Identifier, *LineComment, *IntegerLiteral, *FloatLiteral,
+		*StringLiteral, *BooleanLiteral, *UndefLiteral:
+		// nothing to do
+	case *PrefixExpression:
+		n.Right = rewriteExp(n.Right, f)
+	case *ModifierExpression:
+		n.Right = rewriteExp(n.Right, f)
+	case *InfixExpression:
+		n.Left = rewriteExp(n.Left, f)
+		n.Right = rewriteExp(n.Right, f)
+	case *IfExpression:
+		n.Condition = rewriteExp(n.Condition, f)
+		n.Consequence = rewriteBlock(n.Consequence, f)
+		n.Alternative = rewriteBlock(n.Alternative, f)
+	case *FunctionLiteral:
+		for i, param := range n.Parameters {
+			if param != nil {
+				n.Parameters[i] = rewriteIdentifier(param, f)
+			}
+		}
+		n.Body = rewriteBlock(n.Body, f)
+	case *CallExpression:
+		n.Function = rewriteExp(n.Function, f)
+		rewriteList(n.Arguments, f)
+	case *ArrayLiteral:
+		rewriteList(n.Elements, f)
+	case *IndexExpression:
+		n.Left = rewriteExp(n.Left, f)
+		n.Index = rewriteExp(n.Index, f)
+	case *HashLiteral:
+		pairs := make(map[Expression]Expression, len(n.Pairs))
+		for _, key := range sortedKeys(n.Pairs) {
+			pairs[rewriteExp(key, f)] = rewriteExp(n.Pairs[key], f)
+		}
+		n.Pairs = pairs
+	case *NamedArgument:
+		n.Name = rewriteExp(n.Name, f)
+		n.Value = rewriteExp(n.Value, f)
+
+	// CSG primitives
+	case *CirclePrimitive:
+		rewriteList(n.Arguments, f)
+	case *CubePrimitive:
+		rewriteList(n.Arguments, f)
+	case *CylinderPrimitive:
+		rewriteList(n.Arguments, f)
+	case *GroupPrimitive:
+		rewriteList(n.Arguments, f)
+	case *PolygonPrimitive:
+		rewriteList(n.Arguments, f)
+	case *PolyhedronPrimitive:
+		rewriteList(n.Arguments, f)
+	case *SpherePrimitive:
+		rewriteList(n.Arguments, f)
+	case *SquarePrimitive:
+		rewriteList(n.Arguments, f)
+	case *TextPrimitive:
+		rewriteList(n.Arguments, f)
+
+	// CSG block primitives
+	case *ColorBlockPrimitive:
+		rewriteList(n.Arguments, f)
+		n.Body = rewriteBlock(n.Body, f)
+	case *DifferenceBlockPrimitive:
+		n.Body = rewriteBlock(n.Body, f)
+	case *GroupBlockPrimitive:
+		n.Body = rewriteBlock(n.Body, f)
+	case *HullBlockPrimitive:
+		n.Body = rewriteBlock(n.Body, f)
+	case *IntersectionBlockPrimitive:
+		n.Body = rewriteBlock(n.Body, f)
+	case *LinearExtrudeBlockPrimitive:
+		rewriteList(n.Arguments, f)
+		n.Body = rewriteBlock(n.Body, f)
+	case *MinkowskiBlockPrimitive:
+		rewriteList(n.Arguments, f)
+		n.Body = rewriteBlock(n.Body, f)
+	case *MirrorBlockPrimitive:
+		rewriteList(n.Arguments, f)
+		n.Body = rewriteBlock(n.Body, f)
+	case *MultmatrixBlockPrimitive:
+		rewriteList(n.Arguments, f)
+		n.Body = rewriteBlock(n.Body, f)
+	case *OffsetBlockPrimitive:
+		rewriteList(n.Arguments, f)
+		n.Body = rewriteBlock(n.Body, f)
+	case *ProjectionBlockPrimitive:
+		rewriteList(n.Arguments, f)
+		n.Body = rewriteBlock(n.Body, f)
+	case *ResizeBlockPrimitive:
+		rewriteList(n.Arguments, f)
+		n.Body = rewriteBlock(n.Body, f)
+	case *RotateBlockPrimitive:
+		rewriteList(n.Arguments, f)
+		n.Body = rewriteBlock(n.Body, f)
+	case *RotateExtrudeBlockPrimitive:
+		rewriteList(n.Arguments, f)
+		n.Body = rewriteBlock(n.Body, f)
+	case *ScaleBlockPrimitive:
+		rewriteList(n.Arguments, f)
+		n.Body = rewriteBlock(n.Body, f)
+	case *TranslateBlockPrimitive:
+		rewriteList(n.Arguments, f)
+		n.Body = rewriteBlock(n.Body, f)
+	case *UnionBlockPrimitive:
+		n.Body = rewriteBlock(n.Body, f)
+
+	default:
+		panic(fmt.Sprintf("ast.Rewrite: unexpected node type %T", n))
+	}
+
+	return f(node)
+}
+
+func rewriteStatements(stmts []Statement, f func(Node) Node) []Statement {
+	var result []Statement
+	for _, stmt := range stmts {
+		if stmt == nil {
+			continue
+		}
+		switch n := Rewrite(stmt, f).(type) {
+		case nil:
+		case Statement:
+			result = append(result, n)
+		case *BlockStatement:
+			result = append(result, n.Statements...)
+		case Expression:
+			result = append(result, &ExpressionStatement{Expression: n})
+		}
+	}
+	return result
+}
+
+func rewriteExp(exp Expression, f func(Node) Node) Expression {
+	if exp == nil {
+		return nil
+	}
+	result := Rewrite(exp, f)
+	if result == nil {
+		return nil
+	}
+	e, ok := result.(Expression)
+	if !ok {
+		panic(fmt.Sprintf("ast.Rewrite: %T is not an Expression", result))
+	}
+	return e
+}
+
+func rewriteList(exps []Expression, f func(Node) Node) {
+	for i, exp := range exps {
+		exps[i] = rewriteExp(exp, f)
+	}
+}
+
+func rewriteBlock(block *BlockStatement, f func(Node) Node) *BlockStatement {
+	if block == nil {
+		return nil
+	}
+	result := Rewrite(block, f)
+	if result == nil {
+		return nil
+	}
+	b, ok := result.(*BlockStatement)
+	if !ok {
+		panic(fmt.Sprintf("ast.Rewrite: %T is not a *BlockStatement", result))
+	}
+	return b
+}
+
+func rewriteIdentifier(ident *Identifier, f func(Node) Node) *Identifier {
+	result := Rewrite(ident, f)
+	id, ok := result.(*Identifier)
+	if !ok {
+		panic(fmt.Sprintf("ast.Rewrite: %T is not an *Identifier", result))
+	}
+	return id
+}
+
+// sortedKeys returns the keys of a HashLiteral in a deterministic order.
+func sortedKeys(pairs map[Expression]Expression) []Expression {
+	keys := make([]Expression, 0, len(pairs))
+	for key := range pairs {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+	return keys
+}
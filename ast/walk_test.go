@@ -0,0 +1,150 @@
+package ast_test
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gmlewis/go-csg/ast"
+	"github.com/gmlewis/go-csg/lexer"
+	"github.com/gmlewis/go-csg/parser"
+	"github.com/gmlewis/go-csg/token"
+)
+
+func parse(t *testing.T, src string) *ast.Program {
+	t.Helper()
+	le := lexer.New(src)
+	p := parser.New(le)
+	program := p.ParseProgram()
+	if errs := p.Errors(); len(errs) != 0 {
+		t.Fatalf("ParseProgram: %v", strings.Join(errs, "\n"))
+	}
+	return program
+}
+
+func TestInspect(t *testing.T) {
+	tests := []struct {
+		src  string
+		want []string
+	}{
+		{
+			src: "cube(size = [1, 2, 3], center = false);",
+			want: []string{
+				"*ast.Program", "*ast.ExpressionStatement", "*ast.CubePrimitive",
+				"*ast.NamedArgument", "*ast.Identifier", "*ast.ArrayLiteral",
+				"*ast.IntegerLiteral", "*ast.IntegerLiteral", "*ast.IntegerLiteral",
+				"*ast.NamedArgument", "*ast.Identifier", "*ast.BooleanLiteral",
+			},
+		},
+		{
+			src: "union() { // hi\n sphere(r = -1.5); }",
+			want: []string{
+				"*ast.Program", "*ast.ExpressionStatement", "*ast.UnionBlockPrimitive",
+				"*ast.BlockStatement", "*ast.ExpressionStatement", "*ast.LineComment",
+				"*ast.ExpressionStatement", "*ast.SpherePrimitive", "*ast.NamedArgument",
+				"*ast.Identifier", "*ast.PrefixExpression", "*ast.FloatLiteral",
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test #%v", i), func(t *testing.T) {
+			var got []string
+			var depth int
+			ast.Inspect(parse(t, tt.src), func(node ast.Node) bool {
+				if node == nil {
+					depth--
+					return false
+				}
+				depth++
+				got = append(got, fmt.Sprintf("%T", node))
+				return true
+			})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Inspect = %#v, want %#v", got, tt.want)
+			}
+			if depth != 0 {
+				t.Errorf("unbalanced Visit(nil) calls: depth = %v", depth)
+			}
+		})
+	}
+}
+
+func TestInspect_Prune(t *testing.T) {
+	program := parse(t, "multmatrix([[1, 0, 0, 0], [0, 1, 0, 0], [0, 0, 1, 0], [0, 0, 0, 1]]) { cube(); sphere(); }")
+	var got []string
+	ast.Inspect(program, func(node ast.Node) bool {
+		switch node.(type) {
+		case *ast.MultmatrixBlockPrimitive:
+			got = append(got, "multmatrix")
+		case *ast.CubePrimitive, *ast.SpherePrimitive:
+			got = append(got, node.TokenLiteral())
+		case *ast.ArrayLiteral:
+			return false // skip the matrix elements
+		case *ast.IntegerLiteral:
+			t.Errorf("Inspect visited a pruned node")
+		}
+		return true
+	})
+	if want := []string{"multmatrix", "cube", "sphere"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Inspect = %v, want %v", got, want)
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	tests := []struct {
+		src  string
+		f    func(ast.Node) ast.Node
+		want string
+	}{
+		{
+			// Remove all comments.
+			src: "// top\ngroup() { // inner\n cube(); }",
+			f: func(node ast.Node) ast.Node {
+				if es, ok := node.(*ast.ExpressionStatement); ok {
+					if _, ok := es.Expression.(*ast.LineComment); ok {
+						return nil
+					}
+				}
+				return node
+			},
+			want: "group() { cube() }",
+		},
+		{
+			// Splice the bodies of groups into their parents.
+			src: "group() { cube(); group() { sphere(); } }",
+			f: func(node ast.Node) ast.Node {
+				if es, ok := node.(*ast.ExpressionStatement); ok {
+					if g, ok := es.Expression.(*ast.GroupBlockPrimitive); ok {
+						return g.Body
+					}
+				}
+				return node
+			},
+			want: "cube()sphere()",
+		},
+		{
+			// Replace spheres with cubes (a bare Expression is wrapped in a statement).
+			src: "union() { sphere(r = 2); }",
+			f: func(node ast.Node) ast.Node {
+				if es, ok := node.(*ast.ExpressionStatement); ok {
+					if s, ok := es.Expression.(*ast.SpherePrimitive); ok {
+						return &ast.CubePrimitive{Token: token.Token{Type: token.CUBE, Literal: "cube"}, Arguments: s.Arguments}
+					}
+				}
+				return node
+			},
+			want: "union() { cube(r = 2) }",
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test #%v", i), func(t *testing.T) {
+			got := ast.Rewrite(parse(t, tt.src), tt.f)
+			if got.String() != tt.want {
+				t.Errorf("Rewrite = %q, want %q", got.String(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,21 @@
+// csg-lsp is a Language Server Protocol server for CSG files,
+// which communicates with an editor over standard input and output.
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/gmlewis/go-csg/lsp"
+)
+
+func main() {
+	flag.Parse()
+
+	// Standard output carries the protocol, so log to standard error.
+	log.SetOutput(os.Stderr)
+	if err := lsp.NewServer(os.Stdin, os.Stdout).Serve(); err != nil {
+		log.Fatalf("csg-lsp: %v", err)
+	}
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gmlewis/go-csg/irmf"
+	"github.com/gmlewis/go-csg/lexer"
+	"github.com/gmlewis/go-csg/optimizer"
+	"github.com/gmlewis/go-csg/params"
+	"github.com/gmlewis/go-csg/parser"
+)
+
+// options represents the options of a conversion. Each conversion
+// has its own options, so that conversions can run concurrently.
+type options struct {
+	BVH        int
+	Center     bool
+	Cull       bool
+	Facets     bool
+	Instancing bool
+	Optimize   bool
+	Thickness  float64
+	Header     headerFields
+}
+
+// headerFields represents the fields of the IRMF header
+// that do not depend on the shader.
+type headerFields struct {
+	Author    string
+	IRMF      string
+	License   string
+	Materials string // comma-separated
+	Notes     string
+	Title     string
+	Units     string
+}
+
+// defaultOptions returns the default options of a conversion.
+func defaultOptions() *options {
+	return &options{
+		BVH:      8,
+		Center:   true,
+		Cull:     true,
+		Optimize: true,
+		Header:   headerFields{IRMF: "1.0", Materials: "PLA", Units: "mm"},
+	}
+}
+
+// optionsFromQuery returns the default options overridden by the
+// query parameters, which are named like the command-line flags.
+func optionsFromQuery(q url.Values) (*options, error) {
+	o := defaultOptions()
+	bools := map[string]*bool{
+		"center":     &o.Center,
+		"cull":       &o.Cull,
+		"facets":     &o.Facets,
+		"instancing": &o.Instancing,
+		"optimize":   &o.Optimize,
+	}
+	strs := map[string]*string{
+		"author":    &o.Header.Author,
+		"irmf":      &o.Header.IRMF,
+		"license":   &o.Header.License,
+		"materials": &o.Header.Materials,
+		"notes":     &o.Header.Notes,
+		"title":     &o.Header.Title,
+		"units":     &o.Header.Units,
+	}
+	for name, values := range q {
+		v := values[len(values)-1]
+		var err error
+		switch {
+		case bools[name] != nil:
+			*bools[name], err = strconv.ParseBool(v)
+		case strs[name] != nil:
+			*strs[name] = v
+		case name == "bvh":
+			o.BVH, err = strconv.Atoi(v)
+		case name == "thickness":
+			o.Thickness, err = strconv.ParseFloat(v, 64)
+		default:
+			return nil, fmt.Errorf("unknown option %q", name)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("option %q: %v", name, err)
+		}
+	}
+	return o, o.validate()
+}
+
+// validate reports whether the options are valid.
+func (o *options) validate() error {
+	if n := len(strings.Split(o.Header.Materials, ",")); n > 4 {
+		return fmt.Errorf("materials: at most 4 materials are supported, got %v", n)
+	}
+	return nil
+}
+
+// irmfOptions returns the options of the shader, whose generation
+// stops once ctx is done.
+func (o *options) irmfOptions(ctx context.Context) []irmf.Option {
+	opts := []irmf.Option{irmf.WithBVH(o.BVH), irmf.WithContext(ctx)}
+	if o.Cull {
+		opts = append(opts, irmf.WithBoundsCulling())
+	}
+	if o.Facets {
+		opts = append(opts, irmf.WithFacets())
+	}
+	if o.Instancing {
+		opts = append(opts, irmf.WithInstancing())
+	}
+	if o.Thickness > 0 {
+		opts = append(opts, irmf.WithPreviewThickness(o.Thickness))
+	}
+	return opts
+}
+
+// conversion represents the result of the conversion of CSG to IRMF.
+type conversion struct {
+	// Imports lists the files imported by the CSG.
+	Imports []string
+	Shader  *irmf.Shader
+	// Warnings includes the warnings of the shader.
+	Warnings []string
+	IRMF     string
+}
+
+// convert converts the CSG to IRMF, and gives up with ctx's error once
+// ctx is done. If the CSG parses, the returned conversion is not nil
+// (and holds its imports) even with an error.
+func (o *options) convert(ctx context.Context, buf []byte) (*conversion, error) {
+	le := lexer.New(string(buf))
+	p := parser.New(le)
+	program := p.ParseProgram()
+	if errs := p.Errors(); len(errs) != 0 {
+		return nil, errors.New(strings.Join(errs, "\n"))
+	}
+
+	c := &conversion{}
+	var err error
+	if c.Imports, err = params.Imports(program); err != nil {
+		return c, err
+	}
+
+	if o.Optimize {
+		program = optimizer.Optimize(program)
+	}
+
+	if c.Shader, err = irmf.New(program, o.Center, o.irmfOptions(ctx)...); err != nil {
+		return c, err
+	}
+	c.Warnings = c.Shader.Warnings
+	mbb := c.Shader.MBB
+	if mbb == nil {
+		c.Warnings = append(c.Warnings, "CSG contains features that are not yet supported.")
+		mbb = &irmf.MBB{}
+	}
+	c.IRMF = fmt.Sprintf("%v\n%v\n", o.Header.format(mbb), c.Shader.String())
+	return c, nil
+}
+
+// format returns the IRMF header, with its fields sorted by name.
+func (h *headerFields) format(mbb *irmf.MBB) string {
+	var mats []string
+	for _, m := range strings.Split(h.Materials, ",") {
+		mats = append(mats, strconv.Quote(strings.TrimSpace(m)))
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("/*{\n")
+	field := func(name, value string) {
+		if value != "" {
+			fmt.Fprintf(&buf, "  %v: %v,\n", name, strconv.Quote(value))
+		}
+	}
+	field("author", h.Author)
+	field("irmf", h.IRMF)
+	field("license", h.License)
+	fmt.Fprintf(&buf, "  materials: [%v],\n", strings.Join(mats, ","))
+	fmt.Fprintf(&buf, "  max: [%v,%v,%v],\n", mbb.XMax, mbb.YMax, mbb.ZMax)
+	fmt.Fprintf(&buf, "  min: [%v,%v,%v],\n", mbb.XMin, mbb.YMin, mbb.ZMin)
+	field("notes", h.Notes)
+	field("title", h.Title)
+	field("units", h.Units)
+	buf.WriteString("}*/\n")
+	return buf.String()
+}
@@ -1,68 +1,252 @@
-// csg2irmf reads a CSG file and writes out IRMF.
+// csg2irmf reads CSG files and writes out IRMF.
+//
+// The files are converted concurrently (see -j), and a failure
+// does not stop the conversion of the other files. A filename of "-"
+// reads the CSG from standard input, whose IRMF is written to standard
+// output (unless -o is used).
+//
+// "csg2irmf serve" instead runs a local HTTP conversion service
+// (see newHandler).
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"io/ioutil"
 	"log"
+	"os"
+	"path/filepath"
+	"runtime"
 	"strings"
-
-	"github.com/gmlewis/go-csg/irmf"
-	"github.com/gmlewis/go-csg/lexer"
-	"github.com/gmlewis/go-csg/parser"
+	"sync"
+	"time"
 )
 
+var defaults = defaultOptions()
+
 var (
-	center  = flag.Bool("center", true, "Center the IRMF in world space.")
-	verbose = flag.Bool("v", false, "Verbose logging")
+	bvh        = flag.Int("bvh", defaults.BVH, "Split unions of more than this many children into a bounding volume hierarchy (0 disables).")
+	center     = flag.Bool("center", defaults.Center, "Center the IRMF in world space.")
+	cull       = flag.Bool("cull", defaults.Cull, "Skip the evaluation of each block outside of its bounding box.")
+	facets     = flag.Bool("facets", defaults.Facets, "Render circles, cylinders, spheres and rotate_extrude with OpenSCAD's number of fragments ($fn, $fa, $fs).")
+	instancing = flag.Bool("instancing", defaults.Instancing, "Share one function between transformed copies of a subtree.")
+	optimize   = flag.Bool("optimize", defaults.Optimize, "Simplify the CSG tree before generating the shader.")
+	thickness  = flag.Float64("thickness", defaults.Thickness, "Extrude top-level 2D objects to this thickness (OpenSCAD's preview uses 1); 0 leaves them infinite along Z.")
+	verbose    = flag.Bool("v", false, "Verbose logging")
+
+	output = flag.String("o", "", "Output filename (only with a single input), or - for standard output.")
+	outDir = flag.String("outdir", "", "Output directory (default is the directory of each input).")
+	force  = flag.Bool("f", false, "Overwrite existing output files.")
+
+	jobs         = flag.Int("j", runtime.NumCPU(), "Number of files to convert concurrently.")
+	report       = flag.String("report", "", "Write a report of the conversion of each file to this filename.")
+	reportFormat = flag.String("report-format", "json", "Format of the report: json or junit.")
+
+	watchFlag = flag.Bool("watch", false, "Convert the given files and the .csg files within the given directories, then reconvert them (overwriting their output) whenever they or their imported files change.")
+	poll      = flag.Duration("poll", 500*time.Millisecond, "With -watch, how often to poll for changes when file notifications are not available.")
+
+	author    = flag.String("author", defaults.Header.Author, "Author field of the IRMF header.")
+	irmfVer   = flag.String("irmf", defaults.Header.IRMF, "IRMF version field of the IRMF header.")
+	license   = flag.String("license", defaults.Header.License, "License field of the IRMF header.")
+	materials = flag.String("materials", defaults.Header.Materials, "Comma-separated materials field of the IRMF header.")
+	notes     = flag.String("notes", defaults.Header.Notes, "Notes field of the IRMF header.")
+	title     = flag.String("title", defaults.Header.Title, "Title field of the IRMF header.")
+	units     = flag.String("units", defaults.Header.Units, "Units field of the IRMF header.")
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "serve" {
+		serve(os.Args[2:])
+		return
+	}
 	flag.Parse()
 
-	for _, arg := range flag.Args() {
-		process(arg)
+	if *output != "" && flag.NArg() != 1 {
+		log.Fatalf("-o requires a single input file, got %v", flag.NArg())
+	}
+	if *output != "" && *outDir != "" {
+		log.Fatalf("-o and -outdir cannot be used together")
+	}
+	opts := flagOptions()
+	check("-%v", opts.validate())
+	if *reportFormat != "json" && *reportFormat != "junit" {
+		log.Fatalf("-report-format: expected json or junit, got %q", *reportFormat)
+	}
+	if *outDir != "" {
+		check("MkdirAll(%q): %v", *outDir, os.MkdirAll(*outDir, 0755))
+	}
+
+	if *watchFlag {
+		for _, arg := range flag.Args() {
+			if arg == "-" {
+				log.Fatalf("-watch cannot watch standard input")
+			}
+		}
+		*force = true
+		watch(flag.Args(), *poll, opts)
+		return
+	}
+
+	start := time.Now()
+	results := convertAll(flag.Args(), *jobs, opts)
+
+	var failed int
+	for _, r := range results {
+		if r.Status != statusOK {
+			failed++
+		}
+	}
+	if *report != "" {
+		check("report: %v", writeReport(*report, *reportFormat, results, time.Since(start)))
 	}
 
+	if failed > 0 {
+		log.Fatalf("%v of %v files failed.", failed, len(results))
+	}
 	log.Println("Done.")
 }
 
-func process(filename string) {
-	log.Printf("Processing %v ...", filename)
-	buf, err := ioutil.ReadFile(filename)
-	check("ReadFile: %v", err)
+// convertAll converts the files with n concurrent workers and
+// returns their results, in the same order as the files.
+func convertAll(filenames []string, n int, opts *options) []*result {
+	if n < 1 {
+		n = 1
+	}
+	results := make([]*result, len(filenames))
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < n; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				results[i] = convert(filenames[i], opts)
+			}
+		}()
+	}
+	for i := range filenames {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+	return results
+}
 
-	le := lexer.New(string(buf))
-	p := parser.New(le)
-	program := p.ParseProgram()
-	if errs := p.Errors(); len(errs) != 0 {
-		log.Fatalf("%v\n", strings.Join(errs, "\n"))
+// convert converts a single file and logs its outcome.
+func convert(filename string, opts *options) *result {
+	log.Printf("Processing %v ...", filename)
+	start := time.Now()
+	r := &result{File: filename, Status: statusOK}
+	if err := process(filename, opts, r); err != nil {
+		r.Status = statusFailed
+		r.Error = err.Error()
+		log.Printf("ERROR: %v: %v", filename, err)
 	}
+	r.Seconds = time.Since(start).Seconds()
+	for _, w := range r.Warnings {
+		log.Printf("WARNING: %v: %v", filename, w)
+	}
+	return r
+}
 
-	shader := irmf.New(program, *center)
+// outputFilename returns the name of the IRMF file for the CSG file,
+// where "-" is standard output.
+func outputFilename(filename string) string {
+	if *output != "" {
+		return *output
+	}
+	if filename == "-" {
+		if *outDir != "" {
+			return filepath.Join(*outDir, "stdin.irmf")
+		}
+		return "-"
+	}
+	result := strings.TrimSuffix(filename, filepath.Ext(filename)) + ".irmf"
+	if *outDir != "" {
+		result = filepath.Join(*outDir, filepath.Base(result))
+	}
+	return result
+}
 
-	if shader.MBB == nil {
-		log.Println("WARNING: CSG contains features that are not yet supported.")
-		shader.MBB = &irmf.MBB{}
+// process converts the CSG file to IRMF and records the outcome in r.
+func process(filename string, opts *options, r *result) error {
+	var buf []byte
+	var err error
+	if filename == "-" {
+		if buf, err = ioutil.ReadAll(os.Stdin); err != nil {
+			return fmt.Errorf("ReadAll: %v", err)
+		}
+	} else if buf, err = ioutil.ReadFile(filename); err != nil {
+		return fmt.Errorf("ReadFile: %v", err)
 	}
 
-	out := fmt.Sprintf(`/*{
-  irmf: "1.0",
-  materials: ["PLA"],
-  max: [%v,%v,%v],
-  min: [%v,%v,%v],
-  units: "mm",
-}*/
+	outFilename := outputFilename(filename)
+	if outFilename != "-" && !*force {
+		if _, err := os.Stat(outFilename); err == nil {
+			return fmt.Errorf("%v already exists (use -f to overwrite)", outFilename)
+		}
+	}
 
-%v
-`, shader.MBB.XMax, shader.MBB.YMax, shader.MBB.ZMax,
-		shader.MBB.XMin, shader.MBB.YMin, shader.MBB.ZMin,
-		shader.String())
+	c, err := opts.convert(context.Background(), buf)
+	if c != nil {
+		r.Imports = c.Imports
+	}
+	if err != nil {
+		return err
+	}
+	r.Unsupported = c.Shader.Unsupported
+	r.Warnings = c.Warnings
+	if n := len(c.Shader.Removed); n > 0 {
+		logf("%v: removed %v nodes that cannot affect the result.", filename, n)
+		for _, rm := range c.Shader.Removed {
+			logf("  %v", rm)
+		}
+	}
+	if mbb := c.Shader.MBB; mbb != nil {
+		r.MBB = &bounds{
+			Min: [3]float64{mbb.XMin, mbb.YMin, mbb.ZMin},
+			Max: [3]float64{mbb.XMax, mbb.YMax, mbb.ZMax},
+		}
+	}
+	r.Functions = len(c.Shader.Functions)
+	out := c.IRMF
+	r.OutputSize = len(out)
 
-	outFilename := strings.Replace(filename, ".csg", ".irmf", -1)
+	if outFilename == "-" {
+		if _, err := os.Stdout.WriteString(out); err != nil {
+			return fmt.Errorf("Write: %v", err)
+		}
+		return nil
+	}
+	r.Output = outFilename
 	log.Printf("Writing %v", outFilename)
-	check("WriteFile(%q): %v", outFilename, ioutil.WriteFile(outFilename, []byte(out), 0644))
+	if err := ioutil.WriteFile(outFilename, []byte(out), 0644); err != nil {
+		return fmt.Errorf("WriteFile(%q): %v", outFilename, err)
+	}
+	return nil
+}
+
+// flagOptions returns the conversion options of the command-line flags.
+func flagOptions() *options {
+	return &options{
+		BVH:        *bvh,
+		Center:     *center,
+		Cull:       *cull,
+		Facets:     *facets,
+		Instancing: *instancing,
+		Optimize:   *optimize,
+		Thickness:  *thickness,
+		Header: headerFields{
+			Author:    *author,
+			IRMF:      *irmfVer,
+			License:   *license,
+			Materials: *materials,
+			Notes:     *notes,
+			Title:     *title,
+			Units:     *units,
+		},
+	}
 }
 
 func check(fmtStr string, args ...interface{}) {
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"sync"
+	"syscall"
+)
+
+// inotifyMask selects the events that can change a watched file,
+// including editors that save by renaming a new file over it.
+const inotifyMask = syscall.IN_CLOSE_WRITE | syscall.IN_CREATE | syscall.IN_DELETE |
+	syscall.IN_MODIFY | syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO
+
+// inotify represents a notifier that uses Linux's inotify.
+type inotify struct {
+	fd     int
+	events chan struct{}
+
+	mu      sync.Mutex
+	watched map[string]bool
+}
+
+func newNotifier() (notifier, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC)
+	if err != nil {
+		return nil, err
+	}
+	n := &inotify{fd: fd, events: make(chan struct{}, 1), watched: map[string]bool{}}
+	go n.read()
+	return n, nil
+}
+
+// Add watches the directory (if it is not already watched).
+func (n *inotify) Add(dir string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.watched[dir] {
+		return nil
+	}
+	if _, err := syscall.InotifyAddWatch(n.fd, dir, inotifyMask); err != nil {
+		return err
+	}
+	n.watched[dir] = true
+	return nil
+}
+
+// Events returns the channel that signals changes.
+func (n *inotify) Events() <-chan struct{} {
+	return n.events
+}
+
+// read signals each batch of events, whose details are not needed
+// because the watcher compares the files with their last known state.
+func (n *inotify) read() {
+	buf := make([]byte, 64*(syscall.SizeofInotifyEvent+255+1))
+	for {
+		if _, err := syscall.Read(n.fd, buf); err != nil && err != syscall.EINTR {
+			close(n.events)
+			return
+		}
+		select {
+		case n.events <- struct{}{}:
+		default: // A change is already pending.
+		}
+	}
+}
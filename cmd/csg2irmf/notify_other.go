@@ -0,0 +1,13 @@
+//go:build !linux
+// +build !linux
+
+package main
+
+import (
+	"fmt"
+	"runtime"
+)
+
+func newNotifier() (notifier, error) {
+	return nil, fmt.Errorf("file notifications are not supported on %v", runtime.GOOS)
+}
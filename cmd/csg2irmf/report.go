@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+)
+
+const (
+	statusOK     = "ok"
+	statusFailed = "failed"
+)
+
+// result represents the outcome of the conversion of a single file.
+type result struct {
+	File        string   `json:"file"`
+	Output      string   `json:"output,omitempty"`
+	Status      string   `json:"status"`
+	Error       string   `json:"error,omitempty"`
+	Imports     []string `json:"imports,omitempty"`
+	Unsupported []string `json:"unsupported,omitempty"`
+	Warnings    []string `json:"warnings,omitempty"`
+	MBB         *bounds  `json:"mbb,omitempty"`
+	Functions   int      `json:"functions"`
+	OutputSize  int      `json:"outputSize"`
+	Seconds     float64  `json:"seconds"`
+}
+
+// bounds represents the minimum bounding box of a shader.
+type bounds struct {
+	Min [3]float64 `json:"min"`
+	Max [3]float64 `json:"max"`
+}
+
+// jsonReport represents a report in JSON.
+type jsonReport struct {
+	Files   []*result `json:"files"`
+	Failed  int       `json:"failed"`
+	Seconds float64   `json:"seconds"`
+}
+
+// junitTestSuite represents a report in the JUnit XML format,
+// with one test case per file.
+type junitTestSuite struct {
+	XMLName  xml.Name         `xml:"testsuite"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Time     string           `xml:"time,attr"`
+	Cases    []*junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+}
+
+// writeReport writes the report of the results to filename
+// in the given format (json or junit).
+func writeReport(filename, format string, results []*result, elapsed time.Duration) error {
+	var failed int
+	for _, r := range results {
+		if r.Status != statusOK {
+			failed++
+		}
+	}
+
+	var buf []byte
+	var err error
+	switch format {
+	case "junit":
+		suite := &junitTestSuite{
+			Name:     "csg2irmf",
+			Tests:    len(results),
+			Failures: failed,
+			Time:     seconds(elapsed.Seconds()),
+		}
+		for _, r := range results {
+			tc := &junitTestCase{Name: r.File, ClassName: "csg2irmf", Time: seconds(r.Seconds)}
+			if r.Status != statusOK {
+				tc.Failure = &junitFailure{Message: r.Error}
+			}
+			tc.SystemOut = systemOut(r)
+			suite.Cases = append(suite.Cases, tc)
+		}
+		buf, err = xml.MarshalIndent(suite, "", "  ")
+		buf = append([]byte(xml.Header), buf...)
+	default:
+		buf, err = json.MarshalIndent(&jsonReport{Files: results, Failed: failed, Seconds: elapsed.Seconds()}, "", "  ")
+	}
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, append(buf, '\n'), 0644)
+}
+
+// systemOut returns the details of a successful conversion
+// for the system-out of a JUnit test case.
+func systemOut(r *result) string {
+	var lines []string
+	if r.MBB != nil {
+		lines = append(lines, fmt.Sprintf("mbb: min %v, max %v", r.MBB.Min, r.MBB.Max))
+		lines = append(lines, fmt.Sprintf("functions: %v, output size: %v", r.Functions, r.OutputSize))
+	}
+	if len(r.Unsupported) > 0 {
+		lines = append(lines, fmt.Sprintf("unsupported: %v", strings.Join(r.Unsupported, ", ")))
+	}
+	for _, w := range r.Warnings {
+		lines = append(lines, fmt.Sprintf("warning: %v", w))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func seconds(s float64) string {
+	return fmt.Sprintf("%.3f", s)
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gmlewis/go-csg/ast"
+	"github.com/gmlewis/go-csg/dims"
+	"github.com/gmlewis/go-csg/lexer"
+	"github.com/gmlewis/go-csg/parser"
+)
+
+// serve runs the HTTP conversion service until it fails.
+func serve(args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", "localhost:8080", "Address to listen on.")
+	maxBytes := fs.Int64("max-bytes", 10<<20, "Maximum size of a request body in bytes.")
+	timeout := fs.Duration("timeout", 30*time.Second, "Maximum duration of a request.")
+	fs.Parse(args)
+
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           newHandler(*maxBytes, *timeout),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	log.Printf("Serving on http://%v ...", *addr)
+	log.Fatal(srv.ListenAndServe())
+}
+
+// server represents the HTTP conversion service. It has no mutable
+// state, so that its requests can run concurrently.
+type server struct {
+	maxBytes int64
+}
+
+// newHandler returns the handler of the service's endpoints:
+//
+//	POST /convert   CSG in, IRMF out (options as query parameters)
+//	POST /parse     CSG in, AST out (as JSON)
+//	POST /validate  CSG in, diagnostics out (as JSON)
+//	GET  /healthz   liveness
+//	GET  /readyz    readiness
+func newHandler(maxBytes int64, timeout time.Duration) http.Handler {
+	s := &server{maxBytes: maxBytes}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/convert", s.post(s.convert))
+	mux.HandleFunc("/parse", s.post(s.parse))
+	mux.HandleFunc("/validate", s.post(s.validate))
+	mux.HandleFunc("/healthz", health)
+	mux.HandleFunc("/readyz", health)
+	// The timeout also cancels the context of the request,
+	// which stops its conversion.
+	return http.TimeoutHandler(mux, timeout, `{"error": "request timed out"}`)
+}
+
+func health(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprintln(w, "ok")
+}
+
+// post returns a handler that only accepts POST requests, whose
+// (size-limited) body is passed to f.
+func (s *server) post(f func(w http.ResponseWriter, r *http.Request, body []byte)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %v not allowed", r.Method))
+			return
+		}
+		// Read one byte more than allowed to tell a body that is too
+		// large from one that just fails to read.
+		body, err := ioutil.ReadAll(io.LimitReader(r.Body, s.maxBytes+1))
+		switch {
+		case err != nil:
+			writeError(w, http.StatusBadRequest, fmt.Errorf("reading request body: %v", err))
+			return
+		case int64(len(body)) > s.maxBytes:
+			w.Header().Set("Connection", "close")
+			writeError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("request body exceeds %v bytes", s.maxBytes))
+			return
+		}
+		f(w, r, body)
+	}
+}
+
+func (s *server) convert(w http.ResponseWriter, r *http.Request, body []byte) {
+	opts, err := optionsFromQuery(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	c, err := opts.convert(r.Context(), body)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write([]byte(c.IRMF))
+}
+
+func (s *server) parse(w http.ResponseWriter, r *http.Request, body []byte) {
+	p := parser.New(lexer.New(string(body)))
+	program := p.ParseProgram()
+	if errs := p.Errors(); len(errs) != 0 {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]interface{}{"errors": errs})
+		return
+	}
+	writeJSON(w, http.StatusOK, ast.JSON(program))
+}
+
+// diagnostic represents a problem found by /validate.
+type diagnostic struct {
+	Severity string `json:"severity"` // error or warning
+	Source   string `json:"source"`   // parser, dims or irmf
+	Message  string `json:"message"`
+}
+
+// validation represents the response of /validate.
+type validation struct {
+	Valid       bool         `json:"valid"`
+	Diagnostics []diagnostic `json:"diagnostics"`
+	Unsupported []string     `json:"unsupported,omitempty"`
+}
+
+func (s *server) validate(w http.ResponseWriter, r *http.Request, body []byte) {
+	opts, err := optionsFromQuery(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	v := &validation{Diagnostics: []diagnostic{}}
+	add := func(severity, source, msg string) {
+		v.Diagnostics = append(v.Diagnostics, diagnostic{Severity: severity, Source: source, Message: msg})
+	}
+
+	p := parser.New(lexer.New(string(body)))
+	program := p.ParseProgram()
+	for _, msg := range p.Errors() {
+		add("error", "parser", msg)
+	}
+	if len(v.Diagnostics) == 0 {
+		for _, err := range dims.Check(program).Errors {
+			if e, ok := err.(*dims.Error); ok && e.TopLevel() && opts.Thickness > 0 {
+				continue // The conversion extrudes the top-level 2D objects and warns.
+			}
+			add("error", "dims", err.Error())
+		}
+	}
+	if len(v.Diagnostics) == 0 {
+		c, err := opts.convert(r.Context(), body)
+		if err != nil {
+			add("error", "irmf", err.Error())
+		} else {
+			for _, msg := range c.Warnings {
+				add("warning", "irmf", msg)
+			}
+			v.Unsupported = c.Shader.Unsupported
+		}
+	}
+
+	v.Valid = true
+	for _, d := range v.Diagnostics {
+		if d.Severity == "error" {
+			v.Valid = false
+		}
+	}
+	writeJSON(w, http.StatusOK, v)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("ERROR: writing response: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
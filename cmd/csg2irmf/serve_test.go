@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type errReader struct{}
+
+func (errReader) Read(p []byte) (int, error) { return 0, errors.New("connection reset") }
+
+func TestHandler(t *testing.T) {
+	tests := []struct {
+		method string
+		target string
+		body   string
+		status int
+		want   string
+	}{
+		{method: "POST", target: "/parse", body: "cube(size = 1);", status: http.StatusOK, want: "CubePrimitive"},
+		{method: "POST", target: "/parse", body: "cube(size = 10);", status: http.StatusOK, want: "CubePrimitive"}, // exactly maxBytes
+		{method: "POST", target: "/parse", body: "cube(size = 100);", status: http.StatusRequestEntityTooLarge, want: "request body exceeds 16 bytes"},
+		{method: "POST", target: "/parse", body: "cube(", status: http.StatusUnprocessableEntity, want: "errors"},
+		{method: "POST", target: "/convert", body: "cube(", status: http.StatusUnprocessableEntity, want: "error"},
+		{method: "POST", target: "/convert?bogus=1", body: "cube(size = 1);", status: http.StatusBadRequest, want: `unknown option \"bogus\"`},
+		{method: "POST", target: "/convert", body: "cube(size = 1);", status: http.StatusOK, want: "mainModel4"},
+		{method: "POST", target: "/validate", body: "cube(", status: http.StatusOK, want: `"valid":false`},
+		{method: "GET", target: "/convert", status: http.StatusMethodNotAllowed, want: "method GET not allowed"},
+		{method: "GET", target: "/healthz", status: http.StatusOK, want: "ok"},
+	}
+
+	h := newHandler(16, time.Minute)
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test #%v", i), func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body)))
+			if w.Code != tt.status {
+				t.Errorf("status = %v, want %v", w.Code, tt.status)
+			}
+			if got := w.Body.String(); !strings.Contains(got, tt.want) {
+				t.Errorf("body = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandler_ReadError(t *testing.T) {
+	w := httptest.NewRecorder()
+	newHandler(16, time.Minute).ServeHTTP(w, httptest.NewRequest("POST", "/parse", errReader{}))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status = %v, want %v", w.Code, http.StatusBadRequest)
+	}
+	if got, want := w.Body.String(), "connection reset"; !strings.Contains(got, want) {
+		t.Errorf("body = %q, want %q", got, want)
+	}
+}
+
+func TestHandler_Timeout(t *testing.T) {
+	w := httptest.NewRecorder()
+	newHandler(1<<20, time.Nanosecond).ServeHTTP(w, httptest.NewRequest("POST", "/convert", strings.NewReader("cube(size = 1);")))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %v, want %v", w.Code, http.StatusServiceUnavailable)
+	}
+	if got, want := w.Body.String(), "request timed out"; !strings.Contains(got, want) {
+		t.Errorf("body = %q, want %q", got, want)
+	}
+}
+
+func TestConvert_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := defaultOptions().convert(ctx, []byte("union() { cube(size = 1); sphere(r = 1); }"))
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("convert = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestHandler_Concurrent(t *testing.T) {
+	ts := httptest.NewServer(newHandler(1<<20, time.Minute))
+	defer ts.Close()
+
+	const n = 16
+	var wg sync.WaitGroup
+	errs := make([]error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			src := fmt.Sprintf("cube(size = [%v, 1, 1], center = false);", i+1)
+			resp, err := http.Post(ts.URL+"/convert?center=false", "text/plain", strings.NewReader(src))
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			defer resp.Body.Close()
+			body, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			if want := fmt.Sprintf("max: [%v,1,1]", i+1); resp.StatusCode != http.StatusOK || !strings.Contains(string(body), want) {
+				errs[i] = fmt.Errorf("status = %v, body =\n%s\nwant %q", resp.StatusCode, body, want)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("request #%v: %v", i, err)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// notifier signals changes to the files within watched directories.
+type notifier interface {
+	// Add watches the directory.
+	Add(dir string) error
+	// Events returns the channel that signals changes.
+	Events() <-chan struct{}
+}
+
+// debounce is how long to wait for more events after a change,
+// since saving a file often produces a burst of events.
+const debounce = 100 * time.Millisecond
+
+// stamp represents the last known state of a file.
+type stamp struct {
+	exists  bool
+	size    int64
+	modTime time.Time
+}
+
+func stampOf(filename string) stamp {
+	fi, err := os.Stat(filename)
+	if err != nil {
+		return stamp{}
+	}
+	return stamp{exists: true, size: fi.Size(), modTime: fi.ModTime()}
+}
+
+// watched represents a CSG file and the files that it imports.
+type watched struct {
+	deps   []string
+	stamps map[string]stamp
+}
+
+// changed reports whether the file or any of its dependencies changed.
+func (w *watched) changed(filename string) bool {
+	if w.stamps[filename] != stampOf(filename) {
+		return true
+	}
+	for _, dep := range w.deps {
+		if w.stamps[dep] != stampOf(dep) {
+			return true
+		}
+	}
+	return false
+}
+
+// watcher reconverts the CSG files (given directly or found in
+// directories) whenever they or their imported files change.
+type watcher struct {
+	paths []string
+	opts  *options
+	files map[string]*watched
+}
+
+// csgFiles returns the CSG files of the watched paths.
+func (w *watcher) csgFiles() []string {
+	var result []string
+	for _, path := range w.paths {
+		if fi, err := os.Stat(path); err != nil || !fi.IsDir() {
+			result = append(result, path)
+			continue
+		}
+		matches, err := filepath.Glob(filepath.Join(path, "*.csg"))
+		if err != nil {
+			log.Printf("ERROR: %v: %v", path, err)
+			continue
+		}
+		result = append(result, matches...)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// dirs returns the directories that contain the watched paths,
+// the CSG files and their dependencies.
+func (w *watcher) dirs() []string {
+	var result []string
+	add := func(filename string) {
+		if fi, err := os.Stat(filename); err == nil && fi.IsDir() {
+			result = append(result, filename)
+			return
+		}
+		result = append(result, filepath.Dir(filename))
+	}
+	for _, path := range w.paths {
+		add(path)
+	}
+	for _, f := range w.files {
+		for _, dep := range f.deps {
+			add(dep)
+		}
+	}
+	return result
+}
+
+// scan reconverts the new and changed CSG files.
+func (w *watcher) scan() {
+	present := map[string]bool{}
+	var changed []string
+	for _, filename := range w.csgFiles() {
+		present[filename] = true
+		if f, ok := w.files[filename]; !ok || f.changed(filename) {
+			changed = append(changed, filename)
+		}
+	}
+	for filename := range w.files {
+		if !present[filename] {
+			log.Printf("%v was removed.", filename)
+			delete(w.files, filename)
+		}
+	}
+	if len(changed) == 0 {
+		return
+	}
+
+	// Record the stamps before converting, so that changes made
+	// during the conversion are noticed by the next scan.
+	stamps := map[string]map[string]stamp{}
+	for _, filename := range changed {
+		stamps[filename] = map[string]stamp{filename: stampOf(filename)}
+		if f, ok := w.files[filename]; ok {
+			for _, dep := range f.deps {
+				stamps[filename][dep] = stampOf(dep)
+			}
+		}
+	}
+
+	var failed int
+	for i, r := range convertAll(changed, *jobs, w.opts) {
+		filename := changed[i]
+		f, ok := w.files[filename]
+		if !ok {
+			f = &watched{}
+			w.files[filename] = f
+		}
+		if r.Status != statusOK {
+			failed++
+		}
+		// A file that fails to parse keeps its previous dependencies.
+		if r.Imports != nil || r.Status == statusOK {
+			f.deps = nil
+			for _, dep := range r.Imports {
+				if !filepath.IsAbs(dep) {
+					dep = filepath.Join(filepath.Dir(filename), dep)
+				}
+				f.deps = append(f.deps, dep)
+			}
+		}
+		f.stamps = stamps[filename]
+		for _, dep := range f.deps {
+			if _, ok := f.stamps[dep]; !ok {
+				f.stamps[dep] = stampOf(dep)
+			}
+		}
+	}
+	log.Printf("Converted %v files (%v failed). Watching for changes...", len(changed), failed)
+}
+
+// watch converts the CSG files of the paths, and then reconverts them
+// whenever they change, until the process is interrupted.
+func watch(paths []string, poll time.Duration, opts *options) {
+	w := &watcher{paths: paths, opts: opts, files: map[string]*watched{}}
+	w.scan()
+
+	var events <-chan struct{}
+	n, err := newNotifier()
+	if err != nil {
+		log.Printf("Polling for changes every %v (%v).", poll, err)
+	} else {
+		events = n.Events()
+	}
+
+	var tick <-chan time.Time
+	startPolling := func() {
+		tick = time.NewTicker(poll).C
+	}
+	if events == nil {
+		startPolling()
+	}
+
+	for {
+		if n != nil {
+			for _, dir := range w.dirs() {
+				if err := n.Add(dir); err != nil {
+					logf("unable to watch %v: %v", dir, err)
+				}
+			}
+		}
+
+		select {
+		case _, ok := <-events:
+			if !ok {
+				log.Printf("File notifications stopped. Polling for changes every %v.", poll)
+				n, events = nil, nil
+				startPolling()
+				continue
+			}
+			time.Sleep(debounce)
+			select {
+			case <-events:
+			default:
+			}
+		case <-tick:
+		}
+		w.scan()
+	}
+}
@@ -0,0 +1,96 @@
+// csg2mesh reads a CSG file, tessellates it, and writes out
+// 3MF, OBJ (with MTL), and/or binary glTF files with per-color materials.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"github.com/gmlewis/go-csg/lexer"
+	"github.com/gmlewis/go-csg/mesh"
+	"github.com/gmlewis/go-csg/parser"
+	"github.com/gmlewis/go-csg/solid"
+)
+
+var (
+	cellSize = flag.Float64("cell", 0.5, "Tessellation cell size in millimeters.")
+	write3MF = flag.Bool("3mf", true, "Write a 3MF file.")
+	writeOBJ = flag.Bool("obj", false, "Write OBJ and MTL files.")
+	writeGLB = flag.Bool("glb", false, "Write a binary glTF file.")
+	verbose  = flag.Bool("v", false, "Verbose logging")
+)
+
+func main() {
+	flag.Parse()
+
+	for _, arg := range flag.Args() {
+		process(arg)
+	}
+
+	log.Println("Done.")
+}
+
+func process(filename string) {
+	log.Printf("Processing %v ...", filename)
+	buf, err := ioutil.ReadFile(filename)
+	check("ReadFile: %v", err)
+
+	le := lexer.New(string(buf))
+	p := parser.New(le)
+	program := p.ParseProgram()
+	if errs := p.Errors(); len(errs) != 0 {
+		log.Fatalf("%v\n", strings.Join(errs, "\n"))
+	}
+
+	model, err := solid.New(program)
+	check("%v: %v", filename, err)
+	logf("%v: %v materials, bounds %+v", filename, len(model.Materials), model.Bounds)
+
+	m, err := mesh.Tessellate(model, *cellSize)
+	check("Tessellate: %v", err)
+	for _, obj := range m.Objects {
+		logf("%v: %v vertices, %v triangles", obj.Name, len(obj.Vertices), len(obj.Triangles))
+	}
+
+	base := strings.TrimSuffix(filename, filepath.Ext(filename))
+	if *write3MF {
+		var out bytes.Buffer
+		check("Write3MF: %v", m.Write3MF(&out))
+		write(base+".3mf", out.Bytes())
+	}
+	if *writeOBJ {
+		mtlFilename := base + ".mtl"
+		var out bytes.Buffer
+		check("WriteOBJ: %v", m.WriteOBJ(&out, filepath.Base(mtlFilename)))
+		write(base+".obj", out.Bytes())
+		out.Reset()
+		check("WriteMTL: %v", m.WriteMTL(&out))
+		write(mtlFilename, out.Bytes())
+	}
+	if *writeGLB {
+		var out bytes.Buffer
+		check("WriteGLB: %v", m.WriteGLB(&out))
+		write(base+".glb", out.Bytes())
+	}
+}
+
+func write(filename string, buf []byte) {
+	log.Printf("Writing %v", filename)
+	check("WriteFile(%q): %v", filename, ioutil.WriteFile(filename, buf, 0644))
+}
+
+func check(fmtStr string, args ...interface{}) {
+	if err := args[len(args)-1]; err != nil {
+		log.Fatalf(fmtStr, args...)
+	}
+}
+
+func logf(fmt string, args ...interface{}) {
+	if *verbose {
+		log.Printf(fmt, args...)
+	}
+}
@@ -0,0 +1,107 @@
+// csg2slices reads a CSG file and writes out a stack of PNG images
+// (one per Z layer, one material per color channel) plus a JSON
+// manifest describing the layer height and pixel pitch.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"image/png"
+	"io/ioutil"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gmlewis/go-csg/irmf"
+	"github.com/gmlewis/go-csg/lexer"
+	"github.com/gmlewis/go-csg/parser"
+	"github.com/gmlewis/go-csg/slicer"
+	"github.com/gmlewis/go-csg/solid"
+)
+
+var (
+	layerHeight = flag.Float64("layer", 0.05, "Layer height in millimeters.")
+	pixelPitch  = flag.Float64("pitch", 0.05, "Pixel pitch in millimeters.")
+	outDir      = flag.String("outdir", "", "Output directory (default is the input filename with a '-slices' suffix).")
+	verbose     = flag.Bool("v", false, "Verbose logging")
+)
+
+func main() {
+	flag.Parse()
+
+	for _, arg := range flag.Args() {
+		process(arg)
+	}
+
+	log.Println("Done.")
+}
+
+func process(filename string) {
+	log.Printf("Processing %v ...", filename)
+	buf, err := ioutil.ReadFile(filename)
+	check("ReadFile: %v", err)
+
+	le := lexer.New(string(buf))
+	p := parser.New(le)
+	program := p.ParseProgram()
+	if errs := p.Errors(); len(errs) != 0 {
+		log.Fatalf("%v\n", strings.Join(errs, "\n"))
+	}
+
+	model, err := solid.New(program)
+	check("%v: %v", filename, err)
+
+	// Slice across the same bounds as the IRMF shader when available,
+	// grown if necessary to cover the whole model.
+	box := model.Bounds
+	if shader, err := irmf.New(program, false); err == nil && shader.MBB != nil {
+		mbb := shader.MBB
+		for i, v := range []float64{mbb.XMin, mbb.YMin, mbb.ZMin} {
+			box.Min[i] = math.Min(box.Min[i], v)
+		}
+		for i, v := range []float64{mbb.XMax, mbb.YMax, mbb.ZMax} {
+			box.Max[i] = math.Max(box.Max[i], v)
+		}
+	}
+
+	s, err := slicer.New(model, box, *layerHeight, *pixelPitch)
+	check("%v: %v", filename, err)
+
+	dir := *outDir
+	if dir == "" {
+		dir = strings.TrimSuffix(filename, filepath.Ext(filename)) + "-slices"
+	}
+	check("MkdirAll: %v", os.MkdirAll(dir, 0755))
+
+	m := s.Manifest
+	log.Printf("Writing %v layers of %vx%v pixels to %v", len(m.Layers), m.Width, m.Height, dir)
+	for i := range m.Layers {
+		m.Layers[i].Filename = fmt.Sprintf("layer%05d.png", i)
+		var out bytes.Buffer
+		check("png.Encode: %v", png.Encode(&out, s.Layer(i)))
+		logf("Writing %v", m.Layers[i].Filename)
+		check("WriteFile: %v", ioutil.WriteFile(filepath.Join(dir, m.Layers[i].Filename), out.Bytes(), 0644))
+	}
+
+	manifest, err := json.MarshalIndent(m, "", "  ")
+	check("json.MarshalIndent: %v", err)
+	manifestFilename := filepath.Join(dir, "manifest.json")
+	log.Printf("Writing %v", manifestFilename)
+	check("WriteFile(%q): %v", manifestFilename, ioutil.WriteFile(manifestFilename, append(manifest, '\n'), 0644))
+}
+
+func check(fmtStr string, args ...interface{}) {
+	if err := args[len(args)-1]; err != nil {
+		log.Fatalf(fmtStr, args...)
+	}
+}
+
+func logf(fmt string, args ...interface{}) {
+	if *verbose {
+		log.Printf(fmt, args...)
+	}
+}
@@ -0,0 +1,78 @@
+// csg2svg reads a CSG file containing a purely 2D design
+// and writes out SVG (and optionally DXF).
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"github.com/gmlewis/go-csg/geom2d"
+	"github.com/gmlewis/go-csg/lexer"
+	"github.com/gmlewis/go-csg/parser"
+)
+
+var (
+	writeSVG = flag.Bool("svg", true, "Write an SVG file.")
+	writeDXF = flag.Bool("dxf", false, "Write a DXF file.")
+	verbose  = flag.Bool("v", false, "Verbose logging")
+)
+
+func main() {
+	flag.Parse()
+
+	for _, arg := range flag.Args() {
+		process(arg)
+	}
+
+	log.Println("Done.")
+}
+
+func process(filename string) {
+	log.Printf("Processing %v ...", filename)
+	buf, err := ioutil.ReadFile(filename)
+	check("ReadFile: %v", err)
+
+	le := lexer.New(string(buf))
+	p := parser.New(le)
+	program := p.ParseProgram()
+	if errs := p.Errors(); len(errs) != 0 {
+		log.Fatalf("%v\n", strings.Join(errs, "\n"))
+	}
+
+	shape, err := geom2d.Eval(program)
+	check("%v: %v", filename, err)
+	logf("%v: %v polygons, area %v", filename, len(shape.Polygons()), shape.Area())
+
+	base := strings.TrimSuffix(filename, filepath.Ext(filename))
+	if *writeSVG {
+		var out bytes.Buffer
+		check("WriteSVG: %v", shape.WriteSVG(&out))
+		write(base+".svg", out.Bytes())
+	}
+	if *writeDXF {
+		var out bytes.Buffer
+		check("WriteDXF: %v", shape.WriteDXF(&out))
+		write(base+".dxf", out.Bytes())
+	}
+}
+
+func write(filename string, buf []byte) {
+	log.Printf("Writing %v", filename)
+	check("WriteFile(%q): %v", filename, ioutil.WriteFile(filename, buf, 0644))
+}
+
+func check(fmtStr string, args ...interface{}) {
+	if err := args[len(args)-1]; err != nil {
+		log.Fatalf(fmtStr, args...)
+	}
+}
+
+func logf(fmt string, args ...interface{}) {
+	if *verbose {
+		log.Printf(fmt, args...)
+	}
+}
@@ -0,0 +1,90 @@
+// csgfmt formats CSG files as canonical, OpenSCAD-loadable CSG.
+//
+// With no file arguments, it formats standard input to standard output.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/gmlewis/go-csg/lexer"
+	"github.com/gmlewis/go-csg/parser"
+	"github.com/gmlewis/go-csg/printer"
+)
+
+var (
+	write     = flag.Bool("w", false, "Write the result to the source file instead of standard output.")
+	list      = flag.Bool("l", false, "List files whose formatting differs from csgfmt's.")
+	indent    = flag.Int("indent", 0, "Number of spaces per indentation level (0 uses tabs).")
+	precision = flag.Int("prec", -1, "Significant digits for float literals (-1 preserves full precision).")
+)
+
+func main() {
+	flag.Parse()
+
+	config := &printer.Config{Indent: "\t", Precision: *precision}
+	if *indent > 0 {
+		config.Indent = strings.Repeat(" ", *indent)
+	}
+
+	if flag.NArg() == 0 {
+		buf, err := ioutil.ReadAll(os.Stdin)
+		check("ReadAll: %v", err)
+		out, err := format(config, "<stdin>", buf)
+		check("%v", err)
+		os.Stdout.Write(out)
+		return
+	}
+
+	for _, arg := range flag.Args() {
+		process(config, arg)
+	}
+}
+
+func process(config *printer.Config, filename string) {
+	buf, err := ioutil.ReadFile(filename)
+	check("ReadFile: %v", err)
+
+	out, err := format(config, filename, buf)
+	check("%v", err)
+
+	changed := !bytes.Equal(buf, out)
+	if *list && changed {
+		fmt.Println(filename)
+	}
+	if *write {
+		if changed {
+			check("WriteFile(%q): %v", filename, ioutil.WriteFile(filename, out, 0644))
+		}
+		return
+	}
+	if !*list {
+		os.Stdout.Write(out)
+	}
+}
+
+func format(config *printer.Config, filename string, buf []byte) ([]byte, error) {
+	le := lexer.New(string(buf))
+	p := parser.New(le)
+	program := p.ParseProgram()
+	if errs := p.Errors(); len(errs) != 0 {
+		return nil, fmt.Errorf("%v: %v", filename, strings.Join(errs, "\n"))
+	}
+
+	var out bytes.Buffer
+	if err := config.Fprint(&out, program); err != nil {
+		return nil, fmt.Errorf("%v: %v", filename, err)
+	}
+	return out.Bytes(), nil
+}
+
+func check(fmtStr string, args ...interface{}) {
+	if err := args[len(args)-1]; err != nil {
+		log.Fatalf(fmtStr, args...)
+	}
+}
@@ -0,0 +1,144 @@
+// csginfo reads CSG files and reports the volume, surface area, mass,
+// center of mass, moments of inertia and bounding box of each material
+// (assuming millimeters), along with statistics of the CSG tree.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gmlewis/go-csg/lexer"
+	"github.com/gmlewis/go-csg/measure"
+	"github.com/gmlewis/go-csg/parser"
+)
+
+var (
+	resolution = flag.Float64("res", 0, "Voxel and tessellation size in millimeters (0 divides the bounding box into about a million voxels).")
+	samples    = flag.Int("samples", 0, "Use Monte Carlo integration with this many random points instead of voxels.")
+	seed       = flag.Int64("seed", 1, "Seed of the random points of -samples.")
+	density    = flag.String("density", "", "Comma-separated densities in g/cm³ of the materials, e.g. PLA=1.24,color_FF0000FF=1.05 (masses are only reported for these).")
+	jsonOut    = flag.Bool("json", false, "Write the reports as JSON.")
+)
+
+func main() {
+	flag.Parse()
+
+	opts := &measure.Options{Resolution: *resolution, Samples: *samples, Seed: *seed}
+	var err error
+	opts.Density, err = parseDensity(*density)
+	check("-density: %v", err)
+
+	reports := map[string]*measure.Report{}
+	for _, arg := range flag.Args() {
+		r := process(arg, opts)
+		if *jsonOut {
+			reports[arg] = r
+			continue
+		}
+		printReport(os.Stdout, arg, r)
+	}
+
+	if *jsonOut {
+		buf, err := json.MarshalIndent(reports, "", "  ")
+		check("json.MarshalIndent: %v", err)
+		fmt.Printf("%s\n", buf)
+	}
+}
+
+// parseDensity parses densities in g/cm³ and returns them in g/mm³.
+func parseDensity(s string) (map[string]float64, error) {
+	result := map[string]float64{}
+	if s == "" {
+		return result, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("expected name=density, got %q", pair)
+		}
+		v, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil || v < 0 {
+			return nil, fmt.Errorf("invalid density %q", parts[1])
+		}
+		result[strings.TrimSpace(parts[0])] = v / 1000
+	}
+	return result, nil
+}
+
+func process(filename string, opts *measure.Options) *measure.Report {
+	log.Printf("Processing %v ...", filename)
+	buf, err := ioutil.ReadFile(filename)
+	check("ReadFile: %v", err)
+
+	le := lexer.New(string(buf))
+	p := parser.New(le)
+	program := p.ParseProgram()
+	if errs := p.Errors(); len(errs) != 0 {
+		log.Fatalf("%v\n", strings.Join(errs, "\n"))
+	}
+
+	r, err := measure.Measure(program, opts)
+	check("%v: %v", filename, err)
+	return r
+}
+
+func printReport(w io.Writer, filename string, r *measure.Report) {
+	fmt.Fprintf(w, "%v:\n", filename)
+	fmt.Fprintf(w, "  bounds: %v to %v mm\n", vec(r.Min), vec(r.Max))
+	fmt.Fprintf(w, "  method: %v (%v samples, resolution %.4g mm)\n", r.Method, r.Samples, r.Resolution)
+	for _, p := range r.Materials {
+		fmt.Fprintf(w, "  material %v:\n", p.Material)
+		printProperties(w, p)
+	}
+	fmt.Fprintf(w, "  total:\n")
+	printProperties(w, r.Total)
+
+	fmt.Fprintf(w, "  tree: depth %v\n", r.Tree.Depth)
+	var names []string
+	for name := range r.Tree.Nodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "    %v: %v\n", name, r.Tree.Nodes[name])
+	}
+	if len(r.Tree.Unsupported) > 0 {
+		fmt.Fprintf(w, "    unsupported: %v\n", strings.Join(r.Tree.Unsupported, ", "))
+	}
+}
+
+func printProperties(w io.Writer, p *measure.Properties) {
+	if p.VolumeError > 0 {
+		fmt.Fprintf(w, "    volume: %.6g ± %.2g mm³\n", p.Volume, p.VolumeError)
+	} else {
+		fmt.Fprintf(w, "    volume: %.6g mm³\n", p.Volume)
+	}
+	fmt.Fprintf(w, "    area: %.6g mm²\n", p.Area)
+	inertiaUnits := "g·mm²"
+	if p.Mass > 0 {
+		fmt.Fprintf(w, "    mass: %.6g g\n", p.Mass)
+	} else {
+		inertiaUnits = "mm⁵ (per unit density)"
+	}
+	fmt.Fprintf(w, "    center of mass: %v mm\n", vec(p.CenterOfMass))
+	fmt.Fprintf(w, "    inertia: %v %v %v %v\n", vec(p.Inertia[0]), vec(p.Inertia[1]), vec(p.Inertia[2]), inertiaUnits)
+	fmt.Fprintf(w, "    bounds: %v to %v mm\n", vec(p.Min), vec(p.Max))
+}
+
+func vec(v [3]float64) string {
+	return fmt.Sprintf("[%.4g, %.4g, %.4g]", v[0], v[1], v[2])
+}
+
+func check(fmtStr string, args ...interface{}) {
+	if err := args[len(args)-1]; err != nil {
+		log.Fatalf(fmtStr, args...)
+	}
+}
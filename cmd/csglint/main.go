@@ -0,0 +1,127 @@
+// csglint checks CSG files for common modeling mistakes, such as
+// coplanar faces in a difference, zero or negative sizes and singular
+// matrices. It exits with status 1 when it reports any issue.
+//
+// With -fix, the fixable issues are fixed and the files are rewritten
+// in canonical format (as by csgfmt).
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/gmlewis/go-csg/lexer"
+	"github.com/gmlewis/go-csg/lint"
+	"github.com/gmlewis/go-csg/parser"
+	"github.com/gmlewis/go-csg/printer"
+)
+
+var (
+	disable = flag.String("disable", "", "Comma-separated names of the rules to disable.")
+	enable  = flag.String("enable", "", "Comma-separated names of the only rules to enable.")
+	maxFn   = flag.Float64("max-fn", lint.DefaultMaxFn, "Largest $fn that is not reported.")
+	noise   = flag.Float64("noise", lint.DefaultNoise, "Largest difference from an integer of a matrix entry that is considered noise.")
+	epsilon = flag.Float64("epsilon", lint.DefaultEpsilon, "Distance in millimeters by which -fix extends a subtrahend past a coplanar face.")
+	fix     = flag.Bool("fix", false, "Fix the fixable issues and rewrite the files.")
+	rules   = flag.Bool("rules", false, "List the rules and exit.")
+	jsonOut = flag.Bool("json", false, "Write the issues as JSON.")
+)
+
+func main() {
+	flag.Parse()
+
+	if *rules {
+		for _, rule := range lint.Rules {
+			fmt.Printf("%v: %v\n", rule.Name, rule.Doc)
+		}
+		return
+	}
+
+	opts := &lint.Options{Disabled: map[string]bool{}, MaxFn: *maxFn, Noise: *noise, Epsilon: *epsilon}
+	check("-disable: %v", disableRules(opts.Disabled, *disable, false))
+	check("-enable: %v", disableRules(opts.Disabled, *enable, true))
+
+	all := map[string][]*lint.Issue{}
+	var count int
+	for _, arg := range flag.Args() {
+		issues := process(arg, opts)
+		count += len(issues)
+		if *jsonOut {
+			all[arg] = issues
+			continue
+		}
+		for _, issue := range issues {
+			fmt.Printf("%v:%v\n", arg, issue)
+		}
+	}
+
+	if *jsonOut {
+		buf, err := json.MarshalIndent(all, "", "  ")
+		check("json.MarshalIndent: %v", err)
+		fmt.Printf("%s\n", buf)
+	}
+	if count > 0 {
+		os.Exit(1)
+	}
+}
+
+// disableRules disables the named rules or, if except is true,
+// all rules except the named ones.
+func disableRules(disabled map[string]bool, names string, except bool) error {
+	if names == "" {
+		return nil
+	}
+	named := map[string]bool{}
+	for _, name := range strings.Split(names, ",") {
+		named[strings.TrimSpace(name)] = true
+	}
+	for _, rule := range lint.Rules {
+		if named[rule.Name] != except {
+			disabled[rule.Name] = true
+		}
+		delete(named, rule.Name)
+	}
+	for name := range named {
+		return fmt.Errorf("unknown rule %q (see -rules)", name)
+	}
+	return nil
+}
+
+// process returns the issues of the file that remain after -fix.
+func process(filename string, opts *lint.Options) []*lint.Issue {
+	buf, err := ioutil.ReadFile(filename)
+	check("ReadFile: %v", err)
+
+	le := lexer.New(string(buf))
+	p := parser.New(le)
+	program := p.ParseProgram()
+	if errs := p.Errors(); len(errs) != 0 {
+		log.Fatalf("%v: %v\n", filename, strings.Join(errs, "\n"))
+	}
+
+	issues := lint.Check(program, opts)
+	if !*fix || lint.Fix(issues) == 0 {
+		return issues
+	}
+
+	var out bytes.Buffer
+	check("%v: %v", filename, printer.Fprint(&out, program))
+	check("WriteFile(%q): %v", filename, ioutil.WriteFile(filename, out.Bytes(), 0644))
+
+	// Positions refer to the rewritten file.
+	le = lexer.New(out.String())
+	p = parser.New(le)
+	return lint.Check(p.ParseProgram(), opts)
+}
+
+func check(fmtStr string, args ...interface{}) {
+	if err := args[len(args)-1]; err != nil {
+		log.Fatalf(fmtStr, args...)
+	}
+}
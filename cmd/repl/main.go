@@ -7,12 +7,14 @@ import (
 	"log"
 	"os"
 	"os/user"
+	"path/filepath"
 
 	"github.com/gmlewis/go-csg/repl"
 )
 
 var (
-	verbose = flag.Bool("v", false, "Verbose logging")
+	historyFile = flag.String("history", defaultHistory(), "File that keeps the history of inputs between sessions (empty disables).")
+	verbose     = flag.Bool("v", false, "Verbose logging")
 )
 
 func main() {
@@ -22,8 +24,20 @@ func main() {
 	check("user.Current: %v", err)
 
 	fmt.Printf("Hello %v! This is a CSG interpreter.\n", user.Username)
-	fmt.Printf("Feel free to type in commands\n")
-	repl.Start(os.Stdin, os.Stdout)
+	fmt.Printf("Feel free to type in commands (:help lists the meta-commands)\n")
+	var opts []repl.Option
+	if *historyFile != "" {
+		opts = append(opts, repl.WithHistory(*historyFile))
+	}
+	repl.Start(os.Stdin, os.Stdout, opts...)
+}
+
+func defaultHistory() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".csg_history")
 }
 
 func check(fmtStr string, args ...interface{}) {
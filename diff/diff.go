@@ -0,0 +1,416 @@
+// Package diff compares two CSG programs structurally, ignoring their
+// layout and tolerating float noise (e.g. 2.22045e-16 versus 0), and
+// reports the nodes that were added, removed or changed.
+package diff
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gmlewis/go-csg/ast"
+	"github.com/gmlewis/go-csg/evaluator"
+	"github.com/gmlewis/go-csg/object"
+	"github.com/gmlewis/go-csg/params"
+	"github.com/gmlewis/go-csg/printer"
+)
+
+// DefaultTolerance is the tolerance used when Options.Tolerance is zero.
+const DefaultTolerance = 1e-6
+
+// Kind represents the kind of a Change.
+type Kind string
+
+// Kinds of changes.
+const (
+	Added   Kind = "added"
+	Removed Kind = "removed"
+	Changed Kind = "changed"
+)
+
+// Param represents a changed parameter of a node.
+type Param struct {
+	// Name is the name of a named argument, the index of a positional
+	// argument, "modifier" or (for let statements) "value".
+	Name string `json:"name"`
+	Old  string `json:"old,omitempty"`
+	New  string `json:"new,omitempty"`
+	// Delta holds New minus Old for each number of a numeric
+	// parameter (flattening vectors and matrices).
+	Delta []float64 `json:"delta,omitempty"`
+}
+
+// Change represents an added, removed or changed node.
+type Change struct {
+	Kind Kind `json:"kind"`
+	// Path locates the node by the name and index of it and its
+	// ancestors within their bodies, e.g. "group[0]/cube[2]". It uses
+	// the indices of the new program, except for removed nodes.
+	Path string `json:"path"`
+	// Node is the node (without its body).
+	Node   string   `json:"node"`
+	Params []*Param `json:"params,omitempty"`
+}
+
+// String returns the string representation of the Change.
+func (c *Change) String() string {
+	switch c.Kind {
+	case Added:
+		return fmt.Sprintf("+ %v: %v", c.Path, c.Node)
+	case Removed:
+		return fmt.Sprintf("- %v: %v", c.Path, c.Node)
+	}
+	var params []string
+	for _, p := range c.Params {
+		s := fmt.Sprintf("%v: %v -> %v", p.Name, orNone(p.Old), orNone(p.New))
+		if len(p.Delta) > 0 {
+			var deltas []string
+			for _, d := range p.Delta {
+				deltas = append(deltas, strconv.FormatFloat(d, 'g', -1, 64))
+			}
+			s += fmt.Sprintf(" (delta %v)", strings.Join(deltas, ", "))
+		}
+		params = append(params, s)
+	}
+	return fmt.Sprintf("~ %v: %v", c.Path, strings.Join(params, "; "))
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+	return s
+}
+
+// Options represents the options of Programs.
+type Options struct {
+	// Tolerance is the largest difference between two numbers that are
+	// considered equal, relative to their magnitude when larger than 1.
+	Tolerance float64
+}
+
+// Programs returns the changes from program a to program b.
+func Programs(a, b *ast.Program, opts *Options) []*Change {
+	d := &differ{tolerance: DefaultTolerance}
+	if opts != nil && opts.Tolerance > 0 {
+		d.tolerance = opts.Tolerance
+	}
+	d.statements("", a.Statements, b.Statements)
+	return d.changes
+}
+
+type differ struct {
+	tolerance float64
+	changes   []*Change
+}
+
+// item represents a statement being compared.
+type item struct {
+	key      string // items with equal keys are compared with each other
+	name     string
+	modifier string
+	node     ast.Node
+	args     []ast.Expression
+	body     *ast.BlockStatement
+	index    int
+}
+
+func items(stmts []ast.Statement) []*item {
+	var result []*item
+	for _, stmt := range stmts {
+		it := &item{node: stmt, index: len(result)}
+		switch stmt := stmt.(type) {
+		case *ast.LetStatement:
+			it.name = "let " + stmt.Name.Value
+			it.key = it.name
+		case *ast.ExpressionStatement:
+			exp := stmt.Expression
+			if _, ok := exp.(*ast.LineComment); ok {
+				continue
+			}
+			for {
+				me, ok := exp.(*ast.ModifierExpression)
+				if !ok {
+					break
+				}
+				it.modifier += me.Modifier
+				exp = me.Right
+			}
+			it.node = exp
+			if args, body, ok := ast.Parts(exp); ok {
+				it.name = exp.TokenLiteral()
+				it.key = it.name
+				it.args, it.body = args, body
+			} else {
+				it.name, it.key = "expression", stmt.String()
+			}
+		default:
+			it.name, it.key = "statement", stmt.String()
+		}
+		result = append(result, it)
+	}
+	return result
+}
+
+func (it *item) path(parent string) string {
+	p := fmt.Sprintf("%v[%v]", strings.Replace(it.name, " ", ":", -1), it.index)
+	if parent == "" {
+		return p
+	}
+	return parent + "/" + p
+}
+
+// header returns the node without its body.
+func (it *item) header() string {
+	if it.name == "expression" || it.name == "statement" || strings.HasPrefix(it.name, "let ") {
+		return it.node.String()
+	}
+	var args []string
+	for _, arg := range it.args {
+		s, err := printer.String(arg)
+		if err != nil {
+			s = arg.String()
+		}
+		args = append(args, s)
+	}
+	s := fmt.Sprintf("%v%v(%v)", it.modifier, it.name, strings.Join(args, ", "))
+	if it.body != nil {
+		s += " { ... }"
+	}
+	return s
+}
+
+func (d *differ) statements(parent string, a, b []ast.Statement) {
+	as, bs := items(a), items(b)
+	for _, pair := range align(as, bs) {
+		switch {
+		case pair[0] == nil:
+			d.changes = append(d.changes, &Change{Kind: Added, Path: pair[1].path(parent), Node: pair[1].header()})
+		case pair[1] == nil:
+			d.changes = append(d.changes, &Change{Kind: Removed, Path: pair[0].path(parent), Node: pair[0].header()})
+		default:
+			d.compare(parent, pair[0], pair[1])
+		}
+	}
+}
+
+// align pairs the items with equal keys that form their longest common
+// subsequence; the other items are paired with nil.
+func align(as, bs []*item) [][2]*item {
+	n, m := len(as), len(bs)
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if as[i].key == bs[j].key {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var result [][2]*item
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case as[i].key == bs[j].key:
+			result = append(result, [2]*item{as[i], bs[j]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			result = append(result, [2]*item{as[i], nil})
+			i++
+		default:
+			result = append(result, [2]*item{nil, bs[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		result = append(result, [2]*item{as[i], nil})
+	}
+	for ; j < m; j++ {
+		result = append(result, [2]*item{nil, bs[j]})
+	}
+	return result
+}
+
+func (d *differ) compare(parent string, a, b *item) {
+	path := b.path(parent)
+	var changed []*Param
+	switch {
+	case strings.HasPrefix(a.name, "let "):
+		av, bv := a.node.(*ast.LetStatement).Value, b.node.(*ast.LetStatement).Value
+		if p := d.param("value", value(av), value(bv)); p != nil {
+			changed = append(changed, p)
+		}
+	case a.name == "expression" || a.name == "statement":
+		return // equal keys are equal statements
+	default:
+		if a.modifier != b.modifier {
+			changed = append(changed, &Param{Name: "modifier", Old: a.modifier, New: b.modifier})
+		}
+		changed = append(changed, d.args(a.args, b.args)...)
+	}
+	if len(changed) > 0 {
+		d.changes = append(d.changes, &Change{Kind: Changed, Path: path, Node: b.header(), Params: changed})
+	}
+
+	var as, bs []ast.Statement
+	if a.body != nil {
+		as = a.body.Statements
+	}
+	if b.body != nil {
+		bs = b.body.Statements
+	}
+	d.statements(path, as, bs)
+}
+
+// value evaluates a constant expression, or returns it unevaluated.
+func value(exp ast.Expression) interface{} {
+	obj := evaluator.Eval(exp, object.NewEnvironment())
+	if obj == nil || obj.Type() == object.ErrorT {
+		return exp.String()
+	}
+	return obj
+}
+
+// args returns the changed parameters of the arguments.
+func (d *differ) args(a, b []ast.Expression) []*Param {
+	aa, errA := params.Parse(a)
+	ba, errB := params.Parse(b)
+	if errA != nil || errB != nil {
+		if p := d.param("arguments", argsString(a), argsString(b)); p != nil {
+			return []*Param{p}
+		}
+		return nil
+	}
+
+	var result []*Param
+	for i := 0; i < len(aa.Positional) || i < len(ba.Positional); i++ {
+		var av, bv interface{}
+		if i < len(aa.Positional) {
+			av = aa.Positional[i]
+		}
+		if i < len(ba.Positional) {
+			bv = ba.Positional[i]
+		}
+		if p := d.param(strconv.Itoa(i), av, bv); p != nil {
+			result = append(result, p)
+		}
+	}
+
+	var names []string
+	for name := range aa.Named {
+		names = append(names, name)
+	}
+	for name := range ba.Named {
+		if _, ok := aa.Named[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		var av, bv interface{}
+		if v, ok := aa.Named[name]; ok {
+			av = v
+		}
+		if v, ok := ba.Named[name]; ok {
+			bv = v
+		}
+		if p := d.param(name, av, bv); p != nil {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+func argsString(exps []ast.Expression) string {
+	var args []string
+	for _, exp := range exps {
+		args = append(args, exp.String())
+	}
+	return strings.Join(args, ", ")
+}
+
+// param returns the changed parameter, or nil if a and b (each an
+// object.Object, a string or nil) are equal within the tolerance.
+func (d *differ) param(name string, a, b interface{}) *Param {
+	if d.equal(a, b) {
+		return nil
+	}
+	p := &Param{Name: name, Old: str(a), New: str(b)}
+	an, okA := numbers(a)
+	bn, okB := numbers(b)
+	if okA && okB && len(an) == len(bn) {
+		for i := range an {
+			p.Delta = append(p.Delta, bn[i]-an[i])
+		}
+	}
+	return p
+}
+
+func str(v interface{}) string {
+	switch v := v.(type) {
+	case object.Object:
+		return v.Inspect()
+	case string:
+		return v
+	}
+	return ""
+}
+
+func (d *differ) equal(a, b interface{}) bool {
+	ao, okA := a.(object.Object)
+	bo, okB := b.(object.Object)
+	if !okA || !okB {
+		return str(a) == str(b) && (a == nil) == (b == nil)
+	}
+	if x, ok := params.ToFloat(ao); ok {
+		y, ok := params.ToFloat(bo)
+		return ok && math.Abs(x-y) <= d.tolerance*math.Max(1, math.Max(math.Abs(x), math.Abs(y)))
+	}
+	if x, ok := params.ToArray(ao); ok {
+		y, ok := params.ToArray(bo)
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !d.equal(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return ao.Type() == bo.Type() && ao.Inspect() == bo.Inspect()
+}
+
+// numbers returns the numbers of a number, or of a (nested) array of numbers.
+func numbers(v interface{}) ([]float64, bool) {
+	obj, ok := v.(object.Object)
+	if !ok {
+		return nil, false
+	}
+	if x, ok := params.ToFloat(obj); ok {
+		return []float64{x}, true
+	}
+	elements, ok := params.ToArray(obj)
+	if !ok {
+		return nil, false
+	}
+	var result []float64
+	for _, el := range elements {
+		x, ok := numbers(el)
+		if !ok {
+			return nil, false
+		}
+		result = append(result, x...)
+	}
+	return result, true
+}
@@ -0,0 +1,135 @@
+package diff
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/gmlewis/go-csg/ast"
+	"github.com/gmlewis/go-csg/lexer"
+	"github.com/gmlewis/go-csg/parser"
+)
+
+func parse(t *testing.T, src string) *ast.Program {
+	t.Helper()
+	le := lexer.New(src)
+	p := parser.New(le)
+	program := p.ParseProgram()
+	if errs := p.Errors(); len(errs) != 0 {
+		t.Fatalf("ParseProgram: %v", strings.Join(errs, "\n"))
+	}
+	return program
+}
+
+func TestPrograms(t *testing.T) {
+	tests := []struct {
+		a, b string
+		opts *Options
+		want []string
+	}{
+		{
+			a:    "cube(size = [1, 2, 3], center = false);",
+			b:    "// a comment\ncube(size = [1, 2, 3.0000000001], center = false);",
+			want: nil,
+		},
+		{
+			a: "multmatrix([[1, 0, 0, 0], [0, 1, 0, 2.22045e-16], [0, 0, 1, 0], [0, 0, 0, 1]]) { sphere(r = 1); }",
+			b: `multmatrix([[1, 0, 0, 0], [0, 1, 0, 0], [0, 0, 1, 0], [0, 0, 0, 1]]) {
+	sphere(r = 1);
+}`,
+			want: nil,
+		},
+		{
+			a:    "cube(size = [1, 2, 3], center = false);",
+			b:    "cube(size = [1, 2.5, 3], center = true);",
+			want: []string{"~ cube[0]: center: false -> true; size: [1, 2, 3] -> [1, 2.5, 3] (delta 0, 0.5, 0)"},
+		},
+		{
+			a:    "cube(size = [1, 2, 3], center = false);",
+			b:    "cube(size = [1, 2.05, 3], center = false);",
+			opts: &Options{Tolerance: 0.1},
+			want: nil,
+		},
+		{
+			a: "group() { cube(size = 1); sphere(r = 1); }",
+			b: "group() { cube(size = 1); cylinder(h = 2, r1 = 1, r2 = 1); sphere(r = 2); }",
+			want: []string{
+				"+ group[0]/cylinder[1]: cylinder(h = 2, r1 = 1, r2 = 1)",
+				"~ group[0]/sphere[2]: r: 1 -> 2 (delta 1)",
+			},
+		},
+		{
+			a: "union() { cube(size = 1); translate([1, 0, 0]) { sphere(r = 1); } }",
+			b: "union() { %translate([1, 0, 0]) { sphere(r = 1); } }",
+			want: []string{
+				"- union[0]/cube[0]: cube(size = 1)",
+				"~ union[0]/translate[0]: modifier: (none) -> %",
+			},
+		},
+		{
+			a:    "let x = 1 + 1;\ncube(size = x);",
+			b:    "let x = 2;\ncube(size = x);",
+			want: nil,
+		},
+		{
+			a:    "let x = 1;",
+			b:    "let x = 3;",
+			want: []string{"~ let:x[0]: value: 1 -> 3 (delta 2)"},
+		},
+		{
+			a:    "sphere(r = 1);",
+			b:    "sphere(1);",
+			want: []string{"~ sphere[0]: 0: (none) -> 1; r: 1 -> (none)"},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test #%v", i), func(t *testing.T) {
+			changes := Programs(parse(t, tt.a), parse(t, tt.b), tt.opts)
+			var got []string
+			for _, c := range changes {
+				got = append(got, c.String())
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("Programs =\n%v\nwant\n%v", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestVolumes(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want *Volume
+	}{
+		{
+			a:    "cube(size = [10, 10, 10], center = false);",
+			b:    "cube(size = [10, 10, 10], center = false);",
+			want: &Volume{Old: 1000, New: 1000},
+		},
+		{
+			a:    "cube(size = [10, 10, 10], center = false);",
+			b:    "translate([5, 0, 0]) { cube(size = [10, 10, 10], center = false); }",
+			want: &Volume{Old: 1000, New: 1000, Added: 500, Removed: 500},
+		},
+		{
+			a:    "cube(size = [10, 10, 10], center = false);",
+			b:    "difference() { cube(size = [10, 10, 10], center = false); cube(size = [5, 10, 10], center = false); }",
+			want: &Volume{Old: 1000, New: 500, Removed: 500},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test #%v", i), func(t *testing.T) {
+			got, err := Volumes(parse(t, tt.a), parse(t, tt.b), 0.5)
+			if err != nil {
+				t.Fatalf("Volumes: %v", err)
+			}
+			near := func(got, want float64) bool { return math.Abs(got-want) <= 1e-6*math.Max(1, want) }
+			if !near(got.Old, tt.want.Old) || !near(got.New, tt.want.New) || !near(got.Added, tt.want.Added) || !near(got.Removed, tt.want.Removed) {
+				t.Errorf("Volumes = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
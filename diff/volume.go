@@ -0,0 +1,102 @@
+package diff
+
+import (
+	"errors"
+	"math"
+
+	"github.com/gmlewis/go-csg/ast"
+	"github.com/gmlewis/go-csg/measure"
+	"github.com/gmlewis/go-csg/solid"
+)
+
+// Volume represents the geometric difference between two programs.
+type Volume struct {
+	// Resolution is the voxel size used to estimate the volumes.
+	Resolution float64 `json:"resolution"`
+	// Old and New are the volumes of the old and new programs.
+	Old float64 `json:"old"`
+	New float64 `json:"new"`
+	// Added is the volume occupied only by the new program, and
+	// Removed is the volume occupied only by the old program.
+	Added   float64 `json:"added"`
+	Removed float64 `json:"removed"`
+}
+
+// Volumes estimates the geometric difference between programs a and b
+// by sampling both on a common voxel grid of the given resolution.
+// A zero resolution divides their combined bounding box into about
+// measure.DefaultCells voxels.
+func Volumes(a, b *ast.Program, resolution float64) (*Volume, error) {
+	ma, err := solid.New(a)
+	if err != nil {
+		return nil, err
+	}
+	mb, err := solid.New(b)
+	if err != nil {
+		return nil, err
+	}
+
+	box := union(ma.Bounds, mb.Bounds)
+	if box.Empty() {
+		return &Volume{Resolution: resolution}, nil
+	}
+	for i := 0; i < 3; i++ {
+		if math.IsInf(box.Max[i]-box.Min[i], 0) {
+			return nil, errors.New("the model is unbounded")
+		}
+	}
+	if resolution == 0 {
+		resolution = box.CellSize(measure.DefaultCells)
+	}
+
+	type counts struct{ old, new, added, removed int }
+	g := solid.NewGrid(box, resolution)
+	layers := make([]counts, g.N[2])
+	g.Sample(func(k int, p solid.Vec3) {
+		inA, inB := ma.At(p) >= 0, mb.At(p) >= 0
+		c := &layers[k]
+		if inA {
+			c.old++
+		}
+		if inB {
+			c.new++
+		}
+		if inA && !inB {
+			c.removed++
+		}
+		if inB && !inA {
+			c.added++
+		}
+	})
+
+	var total counts
+	for _, c := range layers {
+		total.old += c.old
+		total.new += c.new
+		total.added += c.added
+		total.removed += c.removed
+	}
+	v := g.Size[0] * g.Size[1] * g.Size[2]
+	return &Volume{
+		Resolution: resolution,
+		Old:        float64(total.old) * v,
+		New:        float64(total.new) * v,
+		Added:      float64(total.added) * v,
+		Removed:    float64(total.removed) * v,
+	}, nil
+}
+
+// union returns the smallest box containing a and b.
+func union(a, b solid.Box) solid.Box {
+	if a.Empty() {
+		return b
+	}
+	if b.Empty() {
+		return a
+	}
+	for i := 0; i < 3; i++ {
+		a.Min[i] = math.Min(a.Min[i], b.Min[i])
+		a.Max[i] = math.Max(a.Max[i], b.Max[i])
+	}
+	return a
+}
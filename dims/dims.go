@@ -0,0 +1,196 @@
+// Package dims infers the dimensionality (2D or 3D) of each node of a
+// CSG program and reports the nodes that mix 2D and 3D objects, which
+// OpenSCAD does not support.
+package dims
+
+import (
+	"fmt"
+
+	"github.com/gmlewis/go-csg/ast"
+)
+
+// Dim represents the dimensionality of a CSG node.
+type Dim int
+
+const (
+	// None is the dimensionality of a node without any geometry.
+	None Dim = iota
+	// Unknown is the dimensionality of a node whose geometry
+	// cannot be determined (e.g. a call to a user-defined module).
+	Unknown
+	// TwoD is the dimensionality of a 2D object.
+	TwoD
+	// ThreeD is the dimensionality of a 3D object.
+	ThreeD
+)
+
+// String returns the string representation of the Dim.
+func (d Dim) String() string {
+	switch d {
+	case None:
+		return "none"
+	case TwoD:
+		return "2D"
+	case ThreeD:
+		return "3D"
+	}
+	return "unknown"
+}
+
+// Error represents a dimensionality error within a CSG node.
+type Error struct {
+	Node ast.Node
+	Msg  string
+}
+
+// Error returns the string representation of the Error.
+func (e *Error) Error() string {
+	if e.TopLevel() {
+		return fmt.Sprintf("top level: %v", e.Msg)
+	}
+	return fmt.Sprintf("%v: %v", e.Node.TokenLiteral(), e.Msg)
+}
+
+// TopLevel reports whether the error is within the implicit union of
+// the top-level statements of the program.
+func (e *Error) TopLevel() bool {
+	_, ok := e.Node.(*ast.Program)
+	return ok
+}
+
+// Info represents the dimensionality of the nodes of a program.
+type Info struct {
+	dims map[ast.Node]Dim
+	// Errors holds the *Error of each node that mixes 2D and 3D
+	// objects, in the order that they appear in the program.
+	Errors []error
+}
+
+// Of returns the dimensionality of a node of the checked program.
+func (info *Info) Of(node ast.Node) Dim {
+	if es, ok := node.(*ast.ExpressionStatement); ok {
+		node = es.Expression
+	}
+	if d, ok := info.dims[node]; ok {
+		return d
+	}
+	return Unknown
+}
+
+// Check infers the dimensionality of each node of the program.
+// Like OpenSCAD, the top-level statements form an implicit union.
+func Check(program *ast.Program) *Info {
+	info := &Info{dims: map[ast.Node]Dim{}}
+	info.dims[program] = info.combine(program, program.Statements)
+	return info
+}
+
+func (info *Info) errorf(node ast.Node, format string, args ...interface{}) {
+	info.Errors = append(info.Errors, &Error{Node: node, Msg: fmt.Sprintf(format, args...)})
+}
+
+// combine returns the dimensionality of a node whose result has the
+// same dimensionality as its children, which must all agree.
+func (info *Info) combine(node ast.Node, stmts []ast.Statement) Dim {
+	result := None
+	var first ast.Expression
+	for _, stmt := range stmts {
+		es, ok := stmt.(*ast.ExpressionStatement)
+		if !ok {
+			continue
+		}
+		d := info.expression(es.Expression)
+		switch {
+		case d == None:
+		case result == None || result == Unknown:
+			result, first = d, es.Expression
+		case d != Unknown && d != result:
+			info.errorf(node, "mixing 2D and 3D objects is not supported: %v is %v but %v is %v",
+				first.TokenLiteral(), result, es.Expression.TokenLiteral(), d)
+		}
+	}
+	return result
+}
+
+// require returns the dimensionality of a node (with dimensionality
+// result) whose children must all have the dimensionality want.
+func (info *Info) require(node ast.Node, body *ast.BlockStatement, want, result Dim) Dim {
+	if body == nil {
+		return result
+	}
+	for _, stmt := range body.Statements {
+		es, ok := stmt.(*ast.ExpressionStatement)
+		if !ok {
+			continue
+		}
+		if d := info.expression(es.Expression); d != None && d != Unknown && d != want {
+			info.errorf(node, "expected %v children, got %v %v", want, d, es.Expression.TokenLiteral())
+		}
+	}
+	return result
+}
+
+func (info *Info) block(node ast.Node, body *ast.BlockStatement) Dim {
+	if body == nil {
+		return None
+	}
+	return info.combine(node, body.Statements)
+}
+
+func (info *Info) expression(exp ast.Expression) Dim {
+	d := info.infer(exp)
+	info.dims[exp] = d
+	return d
+}
+
+func (info *Info) infer(exp ast.Expression) Dim {
+	switch node := exp.(type) {
+	case *ast.CirclePrimitive, *ast.PolygonPrimitive, *ast.SquarePrimitive, *ast.TextPrimitive:
+		return TwoD
+	case *ast.CubePrimitive, *ast.CylinderPrimitive, *ast.PolyhedronPrimitive, *ast.SpherePrimitive:
+		return ThreeD
+	case *ast.CallExpression:
+		return Unknown
+	case *ast.ModifierExpression:
+		d := info.expression(node.Right)
+		if node.Disabled() {
+			return None
+		}
+		return d
+	case *ast.ColorBlockPrimitive:
+		return info.block(node, node.Body)
+	case *ast.DifferenceBlockPrimitive:
+		return info.block(node, node.Body)
+	case *ast.GroupBlockPrimitive:
+		return info.block(node, node.Body)
+	case *ast.HullBlockPrimitive:
+		return info.block(node, node.Body)
+	case *ast.IntersectionBlockPrimitive:
+		return info.block(node, node.Body)
+	case *ast.MinkowskiBlockPrimitive:
+		return info.block(node, node.Body)
+	case *ast.MirrorBlockPrimitive:
+		return info.block(node, node.Body)
+	case *ast.MultmatrixBlockPrimitive:
+		return info.block(node, node.Body)
+	case *ast.ResizeBlockPrimitive:
+		return info.block(node, node.Body)
+	case *ast.RotateBlockPrimitive:
+		return info.block(node, node.Body)
+	case *ast.ScaleBlockPrimitive:
+		return info.block(node, node.Body)
+	case *ast.TranslateBlockPrimitive:
+		return info.block(node, node.Body)
+	case *ast.UnionBlockPrimitive:
+		return info.block(node, node.Body)
+	case *ast.LinearExtrudeBlockPrimitive:
+		return info.require(node, node.Body, TwoD, ThreeD)
+	case *ast.RotateExtrudeBlockPrimitive:
+		return info.require(node, node.Body, TwoD, ThreeD)
+	case *ast.OffsetBlockPrimitive:
+		return info.require(node, node.Body, TwoD, TwoD)
+	case *ast.ProjectionBlockPrimitive:
+		return info.require(node, node.Body, ThreeD, TwoD)
+	}
+	return None
+}
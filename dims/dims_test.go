@@ -0,0 +1,100 @@
+package dims
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/gmlewis/go-csg/ast"
+	"github.com/gmlewis/go-csg/lexer"
+	"github.com/gmlewis/go-csg/parser"
+)
+
+func parse(t *testing.T, src string) *ast.Program {
+	t.Helper()
+	le := lexer.New(src)
+	p := parser.New(le)
+	program := p.ParseProgram()
+	if errs := p.Errors(); len(errs) != 0 {
+		t.Fatalf("ParseProgram: %v", strings.Join(errs, "\n"))
+	}
+	return program
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		src    string
+		want   []Dim // of each top-level statement
+		errors []string
+	}{
+		{
+			src:  "circle(r = 1); cube(size = 1);",
+			want: []Dim{TwoD, ThreeD},
+			errors: []string{
+				"top level: mixing 2D and 3D objects is not supported: circle is 2D but cube is 3D",
+			},
+		},
+		{
+			src:  "// comment\ngroup(); multmatrix([[1, 0, 0, 1], [0, 1, 0, 0], [0, 0, 1, 0], [0, 0, 0, 1]]) { square(size = 1); }",
+			want: []Dim{None, None, TwoD},
+		},
+		{
+			src:  "linear_extrude(height = 1) { offset(r = 1) { square(size = 1); } } projection() { sphere(r = 1); }",
+			want: []Dim{ThreeD, TwoD},
+			errors: []string{
+				"top level: mixing 2D and 3D objects is not supported: linear_extrude is 3D but projection is 2D",
+			},
+		},
+		{
+			src:  "union() { cube(size = 1); translate([1, 0, 0]) { square(size = 1); } }",
+			want: []Dim{ThreeD},
+			errors: []string{
+				"union: mixing 2D and 3D objects is not supported: cube is 3D but translate is 2D",
+			},
+		},
+		{
+			src:  "difference() { *cube(size = 1); circle(r = 2); %sphere(r = 1); }",
+			want: []Dim{TwoD},
+		},
+		{
+			src:  "rotate_extrude() { cube(size = 1); } projection() { circle(r = 1); }",
+			want: []Dim{ThreeD, TwoD},
+			errors: []string{
+				"rotate_extrude: expected 2D children, got 3D cube",
+				"projection: expected 3D children, got 2D circle",
+				"top level: mixing 2D and 3D objects is not supported: rotate_extrude is 3D but projection is 2D",
+			},
+		},
+		{
+			src:  "union() { foo(); cube(size = 1); }",
+			want: []Dim{ThreeD},
+		},
+		{
+			src:  "intersection() { }",
+			want: []Dim{None},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test #%v", i), func(t *testing.T) {
+			program := parse(t, tt.src)
+			info := Check(program)
+
+			var got []Dim
+			for _, stmt := range program.Statements {
+				got = append(got, info.Of(stmt))
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("dims = %v, want %v", got, tt.want)
+			}
+
+			var errs []string
+			for _, err := range info.Errors {
+				errs = append(errs, err.Error())
+			}
+			if strings.Join(errs, "\n") != strings.Join(tt.errors, "\n") {
+				t.Errorf("errors =\n%v\nwant:\n%v", strings.Join(errs, "\n"), strings.Join(tt.errors, "\n"))
+			}
+		})
+	}
+}
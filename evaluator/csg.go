@@ -0,0 +1,204 @@
+package evaluator
+
+import (
+	"github.com/gmlewis/go-csg/ast"
+	"github.com/gmlewis/go-csg/object"
+)
+
+// evalCSG evaluates a CSG node, and reports whether the node is one.
+func evalCSG(node ast.Node, env *object.Environment) (object.Object, bool) {
+	switch node := node.(type) {
+	case *ast.LineComment:
+		return &object.LineComment{Value: node.Value}, true
+
+	case *ast.NamedArgument:
+		value := Eval(node.Value, env)
+		if isError(value) {
+			return value, true
+		}
+		return &object.NamedArgument{Name: node.Name.String(), Value: value}, true
+
+	case *ast.UndefLiteral:
+		return Null, true
+
+	case *ast.ModifierExpression:
+		if node.Disabled() {
+			return Null, true
+		}
+		return Eval(node.Right, env), true
+
+	case *ast.CirclePrimitive:
+		return evalPrimitive(node.Arguments, env, func(args []object.Object) object.Object {
+			return &object.CirclePrimitive{Arguments: args}
+		}), true
+
+	case *ast.CubePrimitive:
+		return evalPrimitive(node.Arguments, env, func(args []object.Object) object.Object {
+			return &object.CubePrimitive{Arguments: args}
+		}), true
+
+	case *ast.CylinderPrimitive:
+		return evalPrimitive(node.Arguments, env, func(args []object.Object) object.Object {
+			return &object.CylinderPrimitive{Arguments: args}
+		}), true
+
+	case *ast.GroupPrimitive:
+		return evalPrimitive(node.Arguments, env, func(args []object.Object) object.Object {
+			return &object.GroupPrimitive{Arguments: args}
+		}), true
+
+	case *ast.PolygonPrimitive:
+		return evalPrimitive(node.Arguments, env, func(args []object.Object) object.Object {
+			return &object.PolygonPrimitive{Arguments: args}
+		}), true
+
+	case *ast.PolyhedronPrimitive:
+		return evalPrimitive(node.Arguments, env, func(args []object.Object) object.Object {
+			return &object.PolyhedronPrimitive{Arguments: args}
+		}), true
+
+	case *ast.SpherePrimitive:
+		return evalPrimitive(node.Arguments, env, func(args []object.Object) object.Object {
+			return &object.SpherePrimitive{Arguments: args}
+		}), true
+
+	case *ast.SquarePrimitive:
+		return evalPrimitive(node.Arguments, env, func(args []object.Object) object.Object {
+			return &object.SquarePrimitive{Arguments: args}
+		}), true
+
+	case *ast.TextPrimitive:
+		return evalPrimitive(node.Arguments, env, func(args []object.Object) object.Object {
+			return &object.TextPrimitive{Arguments: args}
+		}), true
+
+	case *ast.ColorBlockPrimitive:
+		return evalBlockPrimitive(node.Arguments, node.Body, env, func(args, children []object.Object) object.Object {
+			return &object.ColorBlockPrimitive{Arguments: args, Children: children}
+		}), true
+
+	case *ast.DifferenceBlockPrimitive:
+		return evalBlockPrimitive(nil, node.Body, env, func(args, children []object.Object) object.Object {
+			return &object.DifferenceBlockPrimitive{Children: children}
+		}), true
+
+	case *ast.GroupBlockPrimitive:
+		return evalBlockPrimitive(nil, node.Body, env, func(args, children []object.Object) object.Object {
+			return &object.GroupBlockPrimitive{Children: children}
+		}), true
+
+	case *ast.HullBlockPrimitive:
+		return evalBlockPrimitive(nil, node.Body, env, func(args, children []object.Object) object.Object {
+			return &object.HullBlockPrimitive{Children: children}
+		}), true
+
+	case *ast.IntersectionBlockPrimitive:
+		return evalBlockPrimitive(nil, node.Body, env, func(args, children []object.Object) object.Object {
+			return &object.IntersectionBlockPrimitive{Children: children}
+		}), true
+
+	case *ast.LinearExtrudeBlockPrimitive:
+		return evalBlockPrimitive(node.Arguments, node.Body, env, func(args, children []object.Object) object.Object {
+			return &object.LinearExtrudeBlockPrimitive{Arguments: args, Children: children}
+		}), true
+
+	case *ast.MinkowskiBlockPrimitive:
+		return evalBlockPrimitive(node.Arguments, node.Body, env, func(args, children []object.Object) object.Object {
+			return &object.MinkowskiBlockPrimitive{Arguments: args, Children: children}
+		}), true
+
+	case *ast.MirrorBlockPrimitive:
+		return evalBlockPrimitive(node.Arguments, node.Body, env, func(args, children []object.Object) object.Object {
+			return &object.MirrorBlockPrimitive{Arguments: args, Children: children}
+		}), true
+
+	case *ast.MultmatrixBlockPrimitive:
+		return evalBlockPrimitive(node.Arguments, node.Body, env, func(args, children []object.Object) object.Object {
+			return &object.MultmatrixBlockPrimitive{Arguments: args, Children: children}
+		}), true
+
+	case *ast.OffsetBlockPrimitive:
+		return evalBlockPrimitive(node.Arguments, node.Body, env, func(args, children []object.Object) object.Object {
+			return &object.OffsetBlockPrimitive{Arguments: args, Children: children}
+		}), true
+
+	case *ast.ProjectionBlockPrimitive:
+		return evalBlockPrimitive(node.Arguments, node.Body, env, func(args, children []object.Object) object.Object {
+			return &object.ProjectionBlockPrimitive{Arguments: args, Children: children}
+		}), true
+
+	case *ast.ResizeBlockPrimitive:
+		return evalBlockPrimitive(node.Arguments, node.Body, env, func(args, children []object.Object) object.Object {
+			return &object.ResizeBlockPrimitive{Arguments: args, Children: children}
+		}), true
+
+	case *ast.RotateBlockPrimitive:
+		return evalBlockPrimitive(node.Arguments, node.Body, env, func(args, children []object.Object) object.Object {
+			return &object.RotateBlockPrimitive{Arguments: args, Children: children}
+		}), true
+
+	case *ast.RotateExtrudeBlockPrimitive:
+		return evalBlockPrimitive(node.Arguments, node.Body, env, func(args, children []object.Object) object.Object {
+			return &object.RotateExtrudeBlockPrimitive{Arguments: args, Children: children}
+		}), true
+
+	case *ast.ScaleBlockPrimitive:
+		return evalBlockPrimitive(node.Arguments, node.Body, env, func(args, children []object.Object) object.Object {
+			return &object.ScaleBlockPrimitive{Arguments: args, Children: children}
+		}), true
+
+	case *ast.TranslateBlockPrimitive:
+		return evalBlockPrimitive(node.Arguments, node.Body, env, func(args, children []object.Object) object.Object {
+			return &object.TranslateBlockPrimitive{Arguments: args, Children: children}
+		}), true
+
+	case *ast.UnionBlockPrimitive:
+		return evalBlockPrimitive(nil, node.Body, env, func(args, children []object.Object) object.Object {
+			return &object.UnionBlockPrimitive{Children: children}
+		}), true
+	}
+
+	return nil, false
+}
+
+func evalPrimitive(exps []ast.Expression, env *object.Environment, fn func(args []object.Object) object.Object) object.Object {
+	args := evalExpressions(exps, env)
+	if len(args) == 1 && isError(args[0]) {
+		return args[0]
+	}
+	return fn(args)
+}
+
+func evalBlockPrimitive(exps []ast.Expression, body *ast.BlockStatement, env *object.Environment, fn func(args, children []object.Object) object.Object) object.Object {
+	args := evalExpressions(exps, env)
+	if len(args) == 1 && isError(args[0]) {
+		return args[0]
+	}
+	children, err := evalChildren(body, env)
+	if err != nil {
+		return err
+	}
+	return fn(args, children)
+}
+
+// evalChildren evaluates the statements of the body of a block
+// primitive within their own scope, and returns their objects,
+// skipping the statements without one (e.g. let statements).
+func evalChildren(body *ast.BlockStatement, env *object.Environment) ([]object.Object, object.Object) {
+	if body == nil {
+		return nil, nil
+	}
+	env = object.NewEnclosedEnvironment(env)
+	var children []object.Object
+	for _, stmt := range body.Statements {
+		obj := Eval(stmt, env)
+		if isError(obj) {
+			return nil, obj
+		}
+		if obj == nil || obj == Null {
+			continue
+		}
+		children = append(children, obj)
+	}
+	return children, nil
+}
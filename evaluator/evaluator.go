@@ -3,7 +3,6 @@ package evaluator
 
 import (
 	"fmt"
-	"log"
 
 	"github.com/gmlewis/go-csg/ast"
 	"github.com/gmlewis/go-csg/object"
@@ -121,74 +120,11 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 	case *ast.HashLiteral:
 		return evalHashLiteral(node, env)
 
-	// CSG...
-
-	case *ast.CubePrimitive:
-		args := evalExpressions(node.Arguments, env)
-		if len(args) == 1 && isError(args[0]) {
-			return args[0]
-		}
-
-		return &object.CubePrimitive{Arguments: args}
-
-	case *ast.CylinderPrimitive:
-		args := evalExpressions(node.Arguments, env)
-		if len(args) == 1 && isError(args[0]) {
-			return args[0]
-		}
-
-		return &object.CylinderPrimitive{Arguments: args}
-
-	case *ast.GroupBlockPrimitive:
-		body := Eval(node.Body, env)
-		return &object.GroupBlockPrimitive{Body: body}
-
-	case *ast.MultmatrixBlockPrimitive:
-		args := evalExpressions(node.Arguments, env)
-		if len(args) == 1 && isError(args[0]) {
-			return args[0]
-		}
-
-		body := Eval(node.Body, env)
-		return &object.MultmatrixBlockPrimitive{Arguments: args, Body: body}
-
-	case *ast.NamedArgument:
-		value := Eval(node.Value, env)
-		return &object.NamedArgument{Name: node.Name.String(), Value: value}
-
-	case *ast.PolygonPrimitive:
-		args := evalExpressions(node.Arguments, env)
-		if len(args) == 1 && isError(args[0]) {
-			return args[0]
-		}
-
-		return &object.PolygonPrimitive{Arguments: args}
-
-	case *ast.SpherePrimitive:
-		args := evalExpressions(node.Arguments, env)
-		if len(args) == 1 && isError(args[0]) {
-			return args[0]
-		}
-
-		return &object.SpherePrimitive{Arguments: args}
-
-	case *ast.SquarePrimitive:
-		args := evalExpressions(node.Arguments, env)
-		if len(args) == 1 && isError(args[0]) {
-			return args[0]
-		}
-
-		return &object.SquarePrimitive{Arguments: args}
-
-	case *ast.UndefLiteral:
-		return Null
-
-	case *ast.UnionBlockPrimitive:
-		body := Eval(node.Body, env)
-		return &object.UnionBlockPrimitive{Body: body}
-
 	default:
-		log.Fatalf("unhandled AST Node type %T (%+v)", node, node)
+		if obj, ok := evalCSG(node, env); ok {
+			return obj
+		}
+		return newError("unhandled AST node type %T", node)
 	}
 
 	return nil
@@ -455,3 +455,61 @@ func testNullObject(t *testing.T, obj object.Object) bool {
 
 	return true
 }
+
+func TestCSG(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"circle(r = 1);", "circle(r = 1);"},
+		{"// comment", "// comment"},
+		{"let s = 2; cube(size = [s, s * 2, 1], center = true);", "cube(size = [2, 4, 1], center = true);"},
+		{
+			"difference() { *sphere(r = 1); cube(size = 2); translate([1, 0, 0]) { cylinder(h = 1, r1 = 1, r2 = 1); } }",
+			"difference() {\n\tcube(size = 2);\n\ttranslate([1, 0, 0]) {\n\t\tcylinder(h = 1, r1 = 1, r2 = 1);\n\t}\n}",
+		},
+		{
+			`color([1, 0, 0, 1]) { linear_extrude(height = 1) { let w = 3; square(size = [w, 1]); } }`,
+			"color([1, 0, 0, 1]) {\n\tlinear_extrude(height = 1) {\n\t\tsquare(size = [3, 1]);\n\t}\n}",
+		},
+		{"intersection() { }", "intersection() {\n}"},
+		{
+			"hull() { minkowski(convexity = 0) { group(); } } projection(cut = false) { polyhedron(points = [[0, 0, 0]], faces = [[0]]); }",
+			"projection(cut = false) {\n\tpolyhedron(points = [[0, 0, 0]], faces = [[0]]);\n}",
+		},
+		{"rotate_extrude(angle = 360) { offset(r = 1) { circle(r = 2); } }", "rotate_extrude(angle = 360) {\n\toffset(r = 1) {\n\t\tcircle(r = 2);\n\t}\n}"},
+		{"mirror([1, 0, 0]) { rotate(a = 90) { scale([2, 2, 2]) { resize([1, 1, 1]) { polygon(points = [[0, 0], [1, 0], [0, 1]]); } } } }", "mirror([1, 0, 0]) {\n\trotate(a = 90) {\n\t\tscale([2, 2, 2]) {\n\t\t\tresize([1, 1, 1]) {\n\t\t\t\tpolygon(points = [[0, 0], [1, 0], [0, 1]]);\n\t\t\t}\n\t\t}\n\t}\n}"},
+		{"multmatrix([[1, 0, 0, 0], [0, 1, 0, 0], [0, 0, 1, 0], [0, 0, 0, 1]]) { union() { sphere(r = 1); } }", "multmatrix([[1, 0, 0, 0], [0, 1, 0, 0], [0, 0, 1, 0], [0, 0, 0, 1]]) {\n\tunion() {\n\t\tsphere(r = 1);\n\t}\n}"},
+		{"union() { cube(size = s); }", "ERROR: identifier not found: s"},
+		{"group() { let w = 1; } w", "ERROR: identifier not found: w"},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test #%v", i), func(t *testing.T) {
+			got := testEval(tt.input)
+			if got == nil {
+				t.Fatalf("Eval = nil, want %v", tt.want)
+			}
+			if got.Inspect() != tt.want {
+				t.Errorf("Eval =\n%v\nwant:\n%v", got.Inspect(), tt.want)
+			}
+		})
+	}
+}
+
+// unknownNode represents a node that the evaluator does not handle.
+type unknownNode struct{}
+
+func (n *unknownNode) String() string       { return "unknown" }
+func (n *unknownNode) TokenLiteral() string { return "unknown" }
+
+func TestEval_UnknownNode(t *testing.T) {
+	got := Eval(&unknownNode{}, object.NewEnvironment())
+	errObj, ok := got.(*object.Error)
+	if !ok {
+		t.Fatalf("got = %T (%+v), want *object.Error", got, got)
+	}
+	if want := "unhandled AST node type *evaluator.unknownNode"; errObj.Message != want {
+		t.Errorf("error = %v, want %v", errObj.Message, want)
+	}
+}
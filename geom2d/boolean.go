@@ -0,0 +1,305 @@
+package geom2d
+
+import (
+	"math"
+	"sort"
+)
+
+// op represents a 2D boolean operation.
+type op int
+
+const (
+	opUnion op = iota
+	opIntersection
+	opDifference
+)
+
+type edge struct {
+	a, b Point
+}
+
+// Union returns the union of the shapes.
+func Union(shapes ...Shape) Shape {
+	switch len(shapes) {
+	case 0:
+		return nil
+	case 1:
+		return shapes[0].normalize()
+	}
+	// Divide and conquer keeps the intermediate results small.
+	mid := len(shapes) / 2
+	return boolean(Union(shapes[:mid]...), Union(shapes[mid:]...), opUnion)
+}
+
+// Intersection returns the intersection of the shapes.
+func Intersection(shapes ...Shape) Shape {
+	if len(shapes) == 0 {
+		return nil
+	}
+	result := shapes[0].normalize()
+	for _, s := range shapes[1:] {
+		result = boolean(result, s, opIntersection)
+	}
+	return result
+}
+
+// Difference returns the first shape minus all the others.
+func Difference(shapes ...Shape) Shape {
+	if len(shapes) == 0 {
+		return nil
+	}
+	result := shapes[0].normalize()
+	if len(shapes) > 1 {
+		result = boolean(result, Union(shapes[1:]...), opDifference)
+	}
+	return result
+}
+
+// boolean performs a boolean operation by splitting the edges of both
+// shapes at their intersections, classifying each split edge against
+// the other shape, and relinking the kept edges into closed paths.
+func boolean(a, b Shape, o op) Shape {
+	a, b = a.normalize(), b.normalize()
+	if len(a) == 0 || len(b) == 0 {
+		switch o {
+		case opUnion:
+			return append(append(Shape{}, a...), b...)
+		case opDifference:
+			return a
+		}
+		return nil
+	}
+
+	amin, amax := a.Bounds()
+	bmin, bmax := b.Bounds()
+	if amax.X < bmin.X || bmax.X < amin.X || amax.Y < bmin.Y || bmax.Y < amin.Y {
+		switch o {
+		case opUnion:
+			return append(append(Shape{}, a...), b...)
+		case opDifference:
+			return a
+		}
+		return nil
+	}
+
+	aEdges, bEdges := edgesOf(a), edgesOf(b)
+	aSplit := splitEdges(aEdges, bEdges)
+	bSplit := splitEdges(bEdges, aEdges)
+
+	var kept []edge
+	for _, e := range aSplit {
+		switch classify(e, b, bEdges) {
+		case outside:
+			if o == opUnion || o == opDifference {
+				kept = append(kept, e)
+			}
+		case inside:
+			if o == opIntersection {
+				kept = append(kept, e)
+			}
+		case sameBoundary:
+			if o == opUnion || o == opIntersection {
+				kept = append(kept, e)
+			}
+		case oppositeBoundary:
+			if o == opDifference {
+				kept = append(kept, e)
+			}
+		}
+	}
+	for _, e := range bSplit {
+		switch classify(e, a, aEdges) {
+		case outside:
+			if o == opUnion {
+				kept = append(kept, e)
+			}
+		case inside:
+			if o == opIntersection {
+				kept = append(kept, e)
+			} else if o == opDifference {
+				kept = append(kept, edge{a: e.b, b: e.a})
+			}
+		}
+	}
+
+	return link(kept).normalize()
+}
+
+func edgesOf(s Shape) []edge {
+	var result []edge
+	for _, p := range s {
+		for i, a := range p {
+			result = append(result, edge{a: a, b: p[(i+1)%len(p)]})
+		}
+	}
+	return result
+}
+
+// splitEdges splits each edge in es at every point where it meets an
+// edge in others.
+func splitEdges(es, others []edge) []edge {
+	var result []edge
+	for _, e := range es {
+		ts := []float64{0, 1}
+		r := sub(e.b, e.a)
+		rr := dot(r, r)
+		if rr == 0 {
+			continue
+		}
+		emin, emax := edgeBounds(e)
+		for _, f := range others {
+			fmin, fmax := edgeBounds(f)
+			if fmax.X < emin.X-1e-7 || emax.X < fmin.X-1e-7 || fmax.Y < emin.Y-1e-7 || emax.Y < fmin.Y-1e-7 {
+				continue
+			}
+			s := sub(f.b, f.a)
+			qp := sub(f.a, e.a)
+			d := cross(r, s)
+			if math.Abs(d) > epsilon*math.Sqrt(rr*dot(s, s)) {
+				t := cross(qp, s) / d
+				u := cross(qp, r) / d
+				if t > -epsilon && t < 1+epsilon && u > -epsilon && u < 1+epsilon {
+					ts = append(ts, t)
+				}
+				continue
+			}
+			// Parallel: if collinear, split at the other edge's endpoints.
+			if math.Abs(cross(qp, r)) > 1e-7*math.Sqrt(rr) {
+				continue
+			}
+			for _, pt := range []Point{f.a, f.b} {
+				ts = append(ts, dot(sub(pt, e.a), r)/rr)
+			}
+		}
+
+		sort.Float64s(ts)
+		n := len(result)
+		prev := e.a
+		for _, t := range ts {
+			if t <= 0 || t > 1 {
+				continue
+			}
+			pt := lerp(e.a, e.b, t)
+			if t == 1 {
+				pt = e.b
+			}
+			if samePoint(prev, pt) {
+				if t == 1 && len(result) > n {
+					result[len(result)-1].b = e.b // snap to the exact endpoint
+				}
+				continue
+			}
+			result = append(result, edge{a: prev, b: pt})
+			prev = pt
+		}
+	}
+	return result
+}
+
+func edgeBounds(e edge) (min, max Point) {
+	return Point{X: math.Min(e.a.X, e.b.X), Y: math.Min(e.a.Y, e.b.Y)},
+		Point{X: math.Max(e.a.X, e.b.X), Y: math.Max(e.a.Y, e.b.Y)}
+}
+
+type classification int
+
+const (
+	outside classification = iota
+	inside
+	sameBoundary
+	oppositeBoundary
+)
+
+func classify(e edge, s Shape, sEdges []edge) classification {
+	m := lerp(e.a, e.b, 0.5)
+	dir := sub(e.b, e.a)
+	for _, f := range sEdges {
+		if onSegment(m, f) {
+			if dot(dir, sub(f.b, f.a)) > 0 {
+				return sameBoundary
+			}
+			return oppositeBoundary
+		}
+	}
+	if s.Contains(m) {
+		return inside
+	}
+	return outside
+}
+
+func onSegment(pt Point, e edge) bool {
+	r := sub(e.b, e.a)
+	l := math.Hypot(r.X, r.Y)
+	if l == 0 {
+		return false
+	}
+	qp := sub(pt, e.a)
+	if math.Abs(cross(r, qp))/l > 1e-7 {
+		return false
+	}
+	t := dot(qp, r) / (l * l)
+	return t > 0 && t < 1
+}
+
+type pointKey struct {
+	x, y int64
+}
+
+func keyOf(pt Point) pointKey {
+	const grid = 1e6
+	return pointKey{x: int64(math.Round(pt.X * grid)), y: int64(math.Round(pt.Y * grid))}
+}
+
+// link chains directed edges into closed paths.
+func link(edges []edge) Shape {
+	outgoing := map[pointKey][]int{}
+	for i, e := range edges {
+		k := keyOf(e.a)
+		outgoing[k] = append(outgoing[k], i)
+	}
+
+	used := make([]bool, len(edges))
+	var result Shape
+	for start := range edges {
+		if used[start] {
+			continue
+		}
+		used[start] = true
+		path := Path{edges[start].a}
+		startKey := keyOf(edges[start].a)
+		cur := edges[start]
+		closed := false
+		for step := 0; step <= len(edges); step++ {
+			endKey := keyOf(cur.b)
+			if endKey == startKey {
+				closed = true
+				break
+			}
+			path = append(path, cur.b)
+			next := -1
+			bestAngle := math.Inf(1)
+			in := sub(cur.b, cur.a)
+			for _, i := range outgoing[endKey] {
+				if used[i] {
+					continue
+				}
+				// Prefer the sharpest left turn to keep paths simple.
+				out := sub(edges[i].b, edges[i].a)
+				angle := math.Atan2(cross(in, out), dot(in, out))
+				if -angle < bestAngle {
+					bestAngle = -angle
+					next = i
+				}
+			}
+			if next < 0 {
+				break
+			}
+			used[next] = true
+			cur = edges[next]
+		}
+		if closed && len(path) >= 3 {
+			result = append(result, path)
+		}
+	}
+	return result
+}
@@ -0,0 +1,49 @@
+package geom2d
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// WriteDXF writes the shape as an ASCII DXF (R12) file. Each closed
+// path of the shape becomes a closed POLYLINE entity on layer "0".
+// R12 cannot record the units, which are millimeters.
+func (s Shape) WriteDXF(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	pair := func(code int, value interface{}) {
+		fmt.Fprintf(bw, "%3d\n%v\n", code, value)
+	}
+
+	pair(0, "SECTION")
+	pair(2, "HEADER")
+	pair(9, "$ACADVER")
+	pair(1, "AC1009")
+	pair(0, "ENDSEC")
+
+	pair(0, "SECTION")
+	pair(2, "ENTITIES")
+	for _, poly := range s.Polygons() {
+		for _, p := range append([]Path{poly.Outer}, poly.Holes...) {
+			pair(0, "POLYLINE")
+			pair(8, "0")
+			pair(66, 1)
+			pair(10, 0)
+			pair(20, 0)
+			pair(30, 0)
+			pair(70, 1) // closed
+			for _, pt := range p {
+				pair(0, "VERTEX")
+				pair(8, "0")
+				pair(10, num(pt.X))
+				pair(20, num(pt.Y))
+			}
+			pair(0, "SEQEND")
+			pair(8, "0")
+		}
+	}
+	pair(0, "ENDSEC")
+	pair(0, "EOF")
+
+	return bw.Flush()
+}
@@ -0,0 +1,225 @@
+package geom2d
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/gmlewis/go-csg/ast"
+	"github.com/gmlewis/go-csg/params"
+	"github.com/gmlewis/go-csg/transform"
+)
+
+// Circle returns a circle of radius r approximated by a regular
+// polygon with the given number of fragments, as OpenSCAD does.
+func Circle(r float64, fragments int) Path {
+	if fragments < 3 {
+		fragments = 3
+	}
+	result := make(Path, fragments)
+	for i := range result {
+		phi := 2 * math.Pi * float64(i) / float64(fragments)
+		result[i] = Point{X: r * math.Cos(phi), Y: r * math.Sin(phi)}
+	}
+	return result
+}
+
+// Square returns a rectangle of the given size.
+func Square(x, y float64, center bool) Path {
+	var x0, y0 float64
+	if center {
+		x0, y0 = -0.5*x, -0.5*y
+	}
+	return Path{{X: x0, Y: y0}, {X: x0 + x, Y: y0}, {X: x0 + x, Y: y0 + y}, {X: x0, Y: y0 + y}}
+}
+
+// Eval evaluates a program whose top-level statements are all 2D
+// and returns their union.
+func Eval(program *ast.Program) (Shape, error) {
+	return evalStatements(program.Statements)
+}
+
+// EvalNode evaluates a single 2D CSG node.
+func EvalNode(node ast.Node) (Shape, error) {
+	switch node := node.(type) {
+	case *ast.Program:
+		return Eval(node)
+	case *ast.BlockStatement:
+		return evalBlock(node)
+	case *ast.ExpressionStatement:
+		return EvalNode(node.Expression)
+	case *ast.LineComment:
+		return nil, nil
+	case *ast.ModifierExpression:
+		if node.Disabled() {
+			return nil, nil
+		}
+		return EvalNode(node.Right)
+	case *ast.CirclePrimitive:
+		r, fragments, err := params.Circle(node.Arguments)
+		if err != nil {
+			return nil, fmt.Errorf("circle: %v", err)
+		}
+		return Shape{Circle(r, fragments)}.normalize(), nil
+	case *ast.SquarePrimitive:
+		size, center, err := params.Square(node.Arguments)
+		if err != nil {
+			return nil, fmt.Errorf("square: %v", err)
+		}
+		return Shape{Square(size[0], size[1], center)}.normalize(), nil
+	case *ast.PolygonPrimitive:
+		return evalPolygon(node.Arguments)
+	case *ast.ColorBlockPrimitive:
+		return evalBlock(node.Body)
+	case *ast.GroupBlockPrimitive:
+		return evalBlock(node.Body)
+	case *ast.UnionBlockPrimitive:
+		return evalBlock(node.Body)
+	case *ast.DifferenceBlockPrimitive:
+		children, err := evalChildren(node.Body)
+		if err != nil {
+			return nil, err
+		}
+		return Difference(children...), nil
+	case *ast.IntersectionBlockPrimitive:
+		children, err := evalChildren(node.Body)
+		if err != nil {
+			return nil, err
+		}
+		return Intersection(children...), nil
+	case *ast.HullBlockPrimitive:
+		children, err := evalChildren(node.Body)
+		if err != nil {
+			return nil, err
+		}
+		return Hull(children...), nil
+	case *ast.MinkowskiBlockPrimitive:
+		children, err := evalChildren(node.Body)
+		if err != nil {
+			return nil, err
+		}
+		return Minkowski(children...)
+	case *ast.MultmatrixBlockPrimitive, *ast.TranslateBlockPrimitive, *ast.RotateBlockPrimitive,
+		*ast.ScaleBlockPrimitive, *ast.MirrorBlockPrimitive, *ast.ResizeBlockPrimitive:
+		return evalTransform(node.(ast.Expression))
+	case *ast.OffsetBlockPrimitive:
+		return evalOffset(node)
+	case *ast.ProjectionBlockPrimitive:
+		return evalProjection(node)
+	}
+	return nil, fmt.Errorf("not a 2D node: %v", node.TokenLiteral())
+}
+
+func evalStatements(stmts []ast.Statement) (Shape, error) {
+	children, err := evalStatementList(stmts)
+	if err != nil {
+		return nil, err
+	}
+	return Union(children...), nil
+}
+
+func evalBlock(block *ast.BlockStatement) (Shape, error) {
+	if block == nil {
+		return nil, nil
+	}
+	return evalStatements(block.Statements)
+}
+
+func evalChildren(block *ast.BlockStatement) ([]Shape, error) {
+	if block == nil {
+		return nil, nil
+	}
+	return evalStatementList(block.Statements)
+}
+
+func evalStatementList(stmts []ast.Statement) ([]Shape, error) {
+	var result []Shape
+	for _, stmt := range stmts {
+		if es, ok := stmt.(*ast.ExpressionStatement); ok {
+			if _, ok := es.Expression.(*ast.LineComment); ok {
+				continue
+			}
+		}
+		s, err := EvalNode(stmt)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, s)
+	}
+	return result, nil
+}
+
+func evalPolygon(exps []ast.Expression) (Shape, error) {
+	points, paths, err := params.Polygon(exps)
+	if err != nil {
+		return nil, err
+	}
+	if paths == nil {
+		return Shape{Path(points)}.normalize(), nil
+	}
+	var result Shape
+	for _, path := range paths {
+		var p Path
+		for _, i := range path {
+			p = append(p, points[i])
+		}
+		result = append(result, p)
+	}
+	return result.normalize(), nil
+}
+
+// evalTransform evaluates a multmatrix, translate, rotate, scale,
+// mirror or resize block.
+func evalTransform(node ast.Expression) (Shape, error) {
+	m, err := transform.Of(node, bounds)
+	if _, ok := err.(*transform.Warning); err != nil && !ok {
+		return nil, err
+	}
+	s, err := evalBlock(transform.Body(node))
+	if err != nil {
+		return nil, err
+	}
+	return s.Transform(m), nil
+}
+
+// bounds returns the bounding box of the children of a block.
+func bounds(body *ast.BlockStatement) (min, max [3]float64, ok bool) {
+	s, err := evalBlock(body)
+	if err != nil || len(s) == 0 {
+		return min, max, false
+	}
+	lo, hi := s.Bounds()
+	return [3]float64{lo.X, lo.Y}, [3]float64{hi.X, hi.Y}, true
+}
+
+func evalOffset(node *ast.OffsetBlockPrimitive) (Shape, error) {
+	a, err := params.Parse(node.Arguments)
+	if err != nil {
+		return nil, fmt.Errorf("offset: %v", err)
+	}
+	s, err := evalBlock(node.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if a.Has(0, "r") {
+		r, err := a.Float(0, "r", 0)
+		if err != nil {
+			return nil, fmt.Errorf("offset: %v", err)
+		}
+		fragments, err := a.Fragments(math.Abs(r))
+		if err != nil {
+			return nil, fmt.Errorf("offset: %v", err)
+		}
+		return Offset(s, r, fragments), nil
+	}
+
+	delta, err := a.Float(-1, "delta", 1)
+	if err != nil {
+		return nil, fmt.Errorf("offset: %v", err)
+	}
+	chamfer, err := a.Bool(-1, "chamfer", false)
+	if err != nil {
+		return nil, fmt.Errorf("offset: %v", err)
+	}
+	return OffsetDelta(s, delta, chamfer), nil
+}
@@ -0,0 +1,148 @@
+package geom2d
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/gmlewis/go-csg/lexer"
+	"github.com/gmlewis/go-csg/parser"
+)
+
+func TestEval(t *testing.T) {
+	tests := []struct {
+		src      string
+		area     float64
+		polygons int
+	}{
+		{src: "square(size = [10, 10], center = false);", area: 100, polygons: 1},
+		{src: "circle(r = 1, $fn = 4, $fa = 12, $fs = 2);", area: 2, polygons: 1},
+		{
+			src:      "union() { square(size = [10, 10], center = false); multmatrix([[1, 0, 0, 5], [0, 1, 0, 5], [0, 0, 1, 0], [0, 0, 0, 1]]) { square(size = [10, 10], center = false); } }",
+			area:     175,
+			polygons: 1,
+		},
+		{
+			src:      "intersection() { square(size = [10, 10], center = false); multmatrix([[1, 0, 0, 5], [0, 1, 0, 5], [0, 0, 1, 0], [0, 0, 0, 1]]) { square(size = [10, 10], center = false); } }",
+			area:     25,
+			polygons: 1,
+		},
+		{
+			src:      "union() { square(size = [10, 10], center = false); mirror([1, 0]) { resize([5, 0], auto = true) { square(size = [10, 10], center = false); } } }",
+			area:     125,
+			polygons: 1,
+		},
+		{
+			src:      "difference() { square(size = [10, 10], center = true); square(size = [5, 5], center = true); }",
+			area:     75,
+			polygons: 1,
+		},
+		{
+			src:      "square(size = [10, 10], center = false); multmatrix([[1, 0, 0, 20], [0, 1, 0, 0], [0, 0, 1, 0], [0, 0, 0, 1]]) { square(size = [10, 10], center = false); }",
+			area:     200,
+			polygons: 2,
+		},
+		{
+			src:      "hull() { square(size = [10, 10], center = false); multmatrix([[1, 0, 0, 20], [0, 1, 0, 0], [0, 0, 1, 0], [0, 0, 0, 1]]) { square(size = [5, 10], center = false); } }",
+			area:     250,
+			polygons: 1,
+		},
+		{src: "offset(delta = 1, chamfer = false) { square(size = [10, 10], center = false); }", area: 144, polygons: 1},
+		{src: "offset(delta = 1, chamfer = true) { square(size = [10, 10], center = false); }", area: 142, polygons: 1},
+		{src: "offset(delta = -1, chamfer = false) { square(size = [10, 10], center = false); }", area: 64, polygons: 1},
+		{src: "offset(r = 1, $fn = 4, $fa = 12, $fs = 2) { square(size = [10, 10], center = false); }", area: 142, polygons: 1},
+		{src: "offset(r = -1, $fn = 4, $fa = 12, $fs = 2) { square(size = [10, 10], center = false); }", area: 64, polygons: 1},
+		{
+			src:      "polygon(points = [[0, 0], [10, 0], [10, 10], [0, 10], [2, 2], [8, 2], [8, 8], [2, 8]], paths = [[0, 1, 2, 3], [4, 5, 6, 7]], convexity = 1);",
+			area:     64,
+			polygons: 1,
+		},
+		{src: "projection(cut = false) { cube(size = [10, 10, 10], center = false); }", area: 100, polygons: 1},
+		{
+			src:      "projection(cut = true) { difference() { cube(size = [10, 10, 10], center = true); cube(size = [5, 5, 20], center = true); } }",
+			area:     75,
+			polygons: 1,
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test #%v", i), func(t *testing.T) {
+			le := lexer.New(tt.src)
+			p := parser.New(le)
+			program := p.ParseProgram()
+			if errs := p.Errors(); len(errs) != 0 {
+				t.Fatalf("ParseProgram: %v", strings.Join(errs, "\n"))
+			}
+
+			shape, err := Eval(program)
+			if err != nil {
+				t.Fatalf("Eval: %v", err)
+			}
+			if got := shape.Area(); math.Abs(got-tt.area) > 1e-6 {
+				t.Errorf("area = %v, want %v", got, tt.area)
+			}
+			if got := len(shape.Polygons()); got != tt.polygons {
+				t.Errorf("polygons = %v, want %v", got, tt.polygons)
+			}
+		})
+	}
+}
+
+func TestEval_Errors(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{src: "cube(size = [1, 1, 1], center = false);", want: "not a 2D node: cube"},
+		{src: "polygon(convexity = 1);", want: "missing polygon points"},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test #%v", i), func(t *testing.T) {
+			le := lexer.New(tt.src)
+			p := parser.New(le)
+			program := p.ParseProgram()
+			if errs := p.Errors(); len(errs) != 0 {
+				t.Fatalf("ParseProgram: %v", strings.Join(errs, "\n"))
+			}
+
+			_, err := Eval(program)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteSVG(t *testing.T) {
+	s := Shape{Square(10, 5, false)}
+	var buf bytes.Buffer
+	if err := s.WriteSVG(&buf); err != nil {
+		t.Fatalf("WriteSVG: %v", err)
+	}
+	got := buf.String()
+	for _, want := range []string{`width="10mm"`, `height="5mm"`, `fill-rule="evenodd"`, `viewBox="0 -5 10 5"`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("WriteSVG missing %q:\n%v", want, got)
+		}
+	}
+}
+
+func TestWriteDXF(t *testing.T) {
+	s := Shape{Square(10, 5, false)}
+	var buf bytes.Buffer
+	if err := s.WriteDXF(&buf); err != nil {
+		t.Fatalf("WriteDXF: %v", err)
+	}
+	got := buf.String()
+	if n := strings.Count(got, "\nVERTEX\n"); n != 4 {
+		t.Errorf("VERTEX count = %v, want 4", n)
+	}
+	for _, want := range []string{"POLYLINE", "SEQEND", "AC1009", "EOF"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("WriteDXF missing %q", want)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+// Package geom2d implements a 2D geometry kernel that evaluates
+// purely 2D CSG subtrees (square, circle, polygon, offset, 2D booleans,
+// projection, etc.) to closed polylines and polygons with holes.
+// The results can be written out as SVG or DXF.
+package geom2d
+
+import (
+	"math"
+
+	"github.com/gmlewis/go-csg/params"
+)
+
+// Point represents a 2D point.
+type Point = params.Point
+
+// Path represents a closed polyline. The last point is implicitly
+// connected back to the first point.
+type Path []Point
+
+// Shape represents a 2D region bounded by closed paths using the
+// even-odd fill rule. After normalization, outer boundaries are
+// counterclockwise and holes are clockwise.
+type Shape []Path
+
+// Polygon represents a polygon with holes.
+type Polygon struct {
+	Outer Path
+	Holes []Path
+}
+
+// Area returns the signed area of the path (positive when counterclockwise).
+func (p Path) Area() float64 {
+	var sum float64
+	for i, a := range p {
+		b := p[(i+1)%len(p)]
+		sum += a.X*b.Y - b.X*a.Y
+	}
+	return 0.5 * sum
+}
+
+// Reverse returns the path in the opposite direction.
+func (p Path) Reverse() Path {
+	result := make(Path, len(p))
+	for i, pt := range p {
+		result[len(p)-1-i] = pt
+	}
+	return result
+}
+
+// Contains reports whether pt is inside the path (even-odd rule).
+func (p Path) Contains(pt Point) bool {
+	inside := false
+	for i, a := range p {
+		b := p[(i+1)%len(p)]
+		if (a.Y > pt.Y) != (b.Y > pt.Y) {
+			x := a.X + (pt.Y-a.Y)*(b.X-a.X)/(b.Y-a.Y)
+			if pt.X < x {
+				inside = !inside
+			}
+		}
+	}
+	return inside
+}
+
+// Contains reports whether pt is inside the shape (even-odd rule).
+func (s Shape) Contains(pt Point) bool {
+	inside := false
+	for _, p := range s {
+		if p.Contains(pt) {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// Area returns the area of the shape.
+func (s Shape) Area() float64 {
+	var sum float64
+	for _, p := range s.normalize() {
+		sum += p.Area()
+	}
+	return sum
+}
+
+// Bounds returns the bounding box of the shape.
+func (s Shape) Bounds() (min, max Point) {
+	min = Point{X: math.Inf(1), Y: math.Inf(1)}
+	max = Point{X: math.Inf(-1), Y: math.Inf(-1)}
+	for _, p := range s {
+		for _, pt := range p {
+			min.X = math.Min(min.X, pt.X)
+			min.Y = math.Min(min.Y, pt.Y)
+			max.X = math.Max(max.X, pt.X)
+			max.Y = math.Max(max.Y, pt.Y)
+		}
+	}
+	return min, max
+}
+
+// Transform applies the 2D affine part of a 4x4 multmatrix to the shape.
+func (s Shape) Transform(m [4][4]float64) Shape {
+	result := make(Shape, 0, len(s))
+	for _, p := range s {
+		np := make(Path, len(p))
+		for i, pt := range p {
+			np[i] = Point{
+				X: m[0][0]*pt.X + m[0][1]*pt.Y + m[0][3],
+				Y: m[1][0]*pt.X + m[1][1]*pt.Y + m[1][3],
+			}
+		}
+		result = append(result, np)
+	}
+	return result.normalize()
+}
+
+// depth returns the number of other paths in the shape that contain path i.
+func (s Shape) depth(i int) int {
+	var d int
+	for j, other := range s {
+		if j != i && len(s[i]) > 0 && other.Contains(interiorPoint(s[i])) {
+			d++
+		}
+	}
+	return d
+}
+
+// interiorPoint returns a point just inside the path, near its first edge.
+func interiorPoint(p Path) Point {
+	a, b := p[0], p[1%len(p)]
+	dx, dy := b.X-a.X, b.Y-a.Y
+	l := math.Hypot(dx, dy)
+	if l == 0 {
+		return a
+	}
+	sign := 1.0
+	if p.Area() < 0 {
+		sign = -1
+	}
+	const nudge = 1e-7
+	return Point{X: 0.5*(a.X+b.X) - sign*nudge*dy/l, Y: 0.5*(a.Y+b.Y) + sign*nudge*dx/l}
+}
+
+// normalize removes degenerate paths and orients the remaining paths
+// so that outer boundaries are counterclockwise and holes are clockwise.
+func (s Shape) normalize() Shape {
+	var clean Shape
+	for _, p := range s {
+		p = p.simplify()
+		if len(p) >= 3 && math.Abs(p.Area()) > epsilon*epsilon {
+			clean = append(clean, p)
+		}
+	}
+
+	result := make(Shape, len(clean))
+	for i, p := range clean {
+		ccw := p.Area() > 0
+		hole := clean.depth(i)%2 == 1
+		if ccw == hole {
+			p = p.Reverse()
+		}
+		result[i] = p
+	}
+	return result
+}
+
+// simplify removes duplicate and collinear points.
+func (p Path) simplify() Path {
+	var result Path
+	for _, pt := range p {
+		if n := len(result); n > 0 && samePoint(result[n-1], pt) {
+			continue
+		}
+		result = append(result, pt)
+	}
+	for len(result) > 1 && samePoint(result[0], result[len(result)-1]) {
+		result = result[:len(result)-1]
+	}
+
+	for changed := true; changed && len(result) >= 3; {
+		changed = false
+		for i := 0; i < len(result) && len(result) >= 3; i++ {
+			a := result[(i+len(result)-1)%len(result)]
+			b := result[i]
+			c := result[(i+1)%len(result)]
+			if math.Abs(cross(sub(b, a), sub(c, b))) <= epsilon*math.Max(dist(a, b), dist(b, c)) && dot(sub(b, a), sub(c, b)) >= 0 {
+				result = append(result[:i], result[i+1:]...)
+				changed = true
+			}
+		}
+	}
+	return result
+}
+
+// Polygons groups the paths of the shape into polygons with holes.
+func (s Shape) Polygons() []Polygon {
+	s = s.normalize()
+	depths := make([]int, len(s))
+	for i := range s {
+		depths[i] = s.depth(i)
+	}
+
+	var result []Polygon
+	index := map[int]int{}
+	for i, p := range s {
+		if depths[i]%2 == 0 {
+			index[i] = len(result)
+			result = append(result, Polygon{Outer: p})
+		}
+	}
+	for i, p := range s {
+		if depths[i]%2 == 0 {
+			continue
+		}
+		// Find the innermost outer path that contains this hole.
+		best, bestDepth := -1, -1
+		pt := interiorPoint(p)
+		for j, outer := range s {
+			if depths[j]%2 == 0 && depths[j] > bestDepth && outer.Contains(pt) {
+				best, bestDepth = j, depths[j]
+			}
+		}
+		if best >= 0 {
+			k := index[best]
+			result[k].Holes = append(result[k].Holes, p)
+		}
+	}
+	return result
+}
+
+const epsilon = 1e-9
+
+func sub(a, b Point) Point           { return Point{X: a.X - b.X, Y: a.Y - b.Y} }
+func add(a, b Point) Point           { return Point{X: a.X + b.X, Y: a.Y + b.Y} }
+func scale(a Point, s float64) Point { return Point{X: a.X * s, Y: a.Y * s} }
+func cross(a, b Point) float64       { return a.X*b.Y - a.Y*b.X }
+func dot(a, b Point) float64         { return a.X*b.X + a.Y*b.Y }
+func dist(a, b Point) float64        { return math.Hypot(a.X-b.X, a.Y-b.Y) }
+func lerp(a, b Point, t float64) Point {
+	return Point{X: a.X + t*(b.X-a.X), Y: a.Y + t*(b.Y-a.Y)}
+}
+
+func samePoint(a, b Point) bool {
+	return math.Abs(a.X-b.X) <= 1e-7 && math.Abs(a.Y-b.Y) <= 1e-7
+}
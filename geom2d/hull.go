@@ -0,0 +1,111 @@
+package geom2d
+
+import (
+	"errors"
+	"sort"
+)
+
+// ConvexHull returns the convex hull of the points as a
+// counterclockwise path (Andrew's monotone chain algorithm).
+func ConvexHull(pts []Point) Path {
+	if len(pts) < 3 {
+		return nil
+	}
+	sorted := append([]Point{}, pts...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].X != sorted[j].X {
+			return sorted[i].X < sorted[j].X
+		}
+		return sorted[i].Y < sorted[j].Y
+	})
+
+	hull := make(Path, 0, 2*len(sorted))
+	for _, pt := range sorted {
+		for len(hull) >= 2 && cross(sub(hull[len(hull)-1], hull[len(hull)-2]), sub(pt, hull[len(hull)-2])) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, pt)
+	}
+	lower := len(hull) + 1
+	for i := len(sorted) - 2; i >= 0; i-- {
+		pt := sorted[i]
+		for len(hull) >= lower && cross(sub(hull[len(hull)-1], hull[len(hull)-2]), sub(pt, hull[len(hull)-2])) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, pt)
+	}
+	hull = hull[:len(hull)-1]
+	if len(hull) < 3 {
+		return nil
+	}
+	return hull
+}
+
+// Hull returns the convex hull of all the shapes.
+func Hull(shapes ...Shape) Shape {
+	var pts []Point
+	for _, s := range shapes {
+		for _, p := range s {
+			pts = append(pts, p...)
+		}
+	}
+	if hull := ConvexHull(pts); hull != nil {
+		return Shape{hull}
+	}
+	return nil
+}
+
+// isConvex reports whether the shape consists of a single convex path.
+func (s Shape) isConvex() bool {
+	s = s.normalize()
+	if len(s) != 1 {
+		return false
+	}
+	p := s[0]
+	for i := range p {
+		a, b, c := p[i], p[(i+1)%len(p)], p[(i+2)%len(p)]
+		if cross(sub(b, a), sub(c, b)) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Minkowski returns the Minkowski sum of the shapes.
+// At least one of each pair of operands must be convex: the sum of a
+// shape A with a convex shape B is A (translated by a point of B)
+// unioned with B swept along every edge of A.
+func Minkowski(shapes ...Shape) (Shape, error) {
+	if len(shapes) == 0 {
+		return nil, nil
+	}
+	result := shapes[0].normalize()
+	for _, s := range shapes[1:] {
+		s = s.normalize()
+		if len(result) == 0 || len(s) == 0 {
+			result = nil
+			continue
+		}
+		a, b := result, s
+		if !b.isConvex() {
+			a, b = b, a
+		}
+		if !b.isConvex() {
+			return nil, errors.New("minkowski of two non-convex 2D shapes is not supported")
+		}
+
+		offset := b[0][0]
+		parts := []Shape{a.Transform([4][4]float64{{1, 0, 0, offset.X}, {0, 1, 0, offset.Y}})}
+		for _, e := range edgesOf(a) {
+			var pts []Point
+			for _, pb := range b[0] {
+				pts = append(pts, add(e.a, pb), add(e.b, pb))
+			}
+			if hull := ConvexHull(pts); hull != nil {
+				parts = append(parts, Shape{hull})
+			}
+		}
+		result = Union(parts...)
+	}
+	return result, nil
+}
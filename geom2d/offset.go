@@ -0,0 +1,96 @@
+package geom2d
+
+import "math"
+
+// Offset returns the shape offset by r using rounded corners
+// (OpenSCAD's offset(r=...)). fragments is the number of segments
+// used for a full circle of radius |r|.
+func Offset(s Shape, r float64, fragments int) Shape {
+	if r == 0 {
+		return s.normalize()
+	}
+	s = s.normalize()
+	circle := Circle(math.Abs(r), fragments)
+	var band []Shape
+	for _, e := range edgesOf(s) {
+		var pts []Point
+		for _, c := range circle {
+			pts = append(pts, add(e.a, c), add(e.b, c))
+		}
+		if hull := ConvexHull(pts); hull != nil {
+			band = append(band, Shape{hull})
+		}
+	}
+	if r > 0 {
+		return Union(append(band, s)...)
+	}
+	return Difference(s, Union(band...))
+}
+
+// OffsetDelta returns the shape offset by delta using mitered (or
+// chamfered) corners (OpenSCAD's offset(delta=..., chamfer=...)).
+func OffsetDelta(s Shape, delta float64, chamfer bool) Shape {
+	if delta == 0 {
+		return s.normalize()
+	}
+	s = s.normalize()
+	d := math.Abs(delta)
+	var band []Shape
+	for _, p := range s {
+		for i := range p {
+			a, b, c := p[i], p[(i+1)%len(p)], p[(i+2)%len(p)]
+			n1 := normal(a, b)
+			n2 := normal(b, c)
+			// Both sides of each edge are covered by the band.
+			band = append(band, Shape{Path{
+				add(a, scale(n1, -d)), add(b, scale(n1, -d)),
+				add(b, scale(n1, d)), add(a, scale(n1, d)),
+			}})
+
+			// Fill the corner at b on the side where the edges diverge:
+			// the outside (right) for left turns, the inside for right turns.
+			side := -1.0
+			if cross(sub(b, a), sub(c, b)) < 0 {
+				side = 1
+			}
+			if math.Abs(cross(sub(b, a), sub(c, b))) <= epsilon {
+				continue
+			}
+			p1 := add(b, scale(n1, side*d))
+			p2 := add(b, scale(n2, side*d))
+			corner := Path{b, p1, p2}
+			if !chamfer {
+				if m, ok := lineIntersection(add(a, scale(n1, side*d)), p1, p2, add(c, scale(n2, side*d))); ok && dist(m, b) < 1000*d {
+					corner = Path{b, p1, m, p2}
+				}
+			}
+			band = append(band, Shape{corner})
+		}
+	}
+	if delta > 0 {
+		return Union(append(band, s)...)
+	}
+	return Difference(s, Union(band...))
+}
+
+// normal returns the unit left normal of the edge from a to b.
+func normal(a, b Point) Point {
+	d := sub(b, a)
+	l := math.Hypot(d.X, d.Y)
+	if l == 0 {
+		return Point{}
+	}
+	return Point{X: -d.Y / l, Y: d.X / l}
+}
+
+// lineIntersection returns the intersection of the infinite lines a1-a2 and b1-b2.
+func lineIntersection(a1, a2, b1, b2 Point) (Point, bool) {
+	r := sub(a2, a1)
+	s := sub(b2, b1)
+	d := cross(r, s)
+	if math.Abs(d) <= epsilon {
+		return Point{}, false
+	}
+	t := cross(sub(b1, a1), s) / d
+	return lerp(a1, a2, t), true
+}
@@ -0,0 +1,323 @@
+package geom2d
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/gmlewis/go-csg/ast"
+	"github.com/gmlewis/go-csg/params"
+	"github.com/gmlewis/go-csg/transform"
+)
+
+type vec3 [3]float64
+
+type mat4 [4][4]float64
+
+var identity = mat4{{1, 0, 0, 0}, {0, 1, 0, 0}, {0, 0, 1, 0}, {0, 0, 0, 1}}
+
+func (m mat4) mul(o mat4) mat4 {
+	var result mat4
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			for k := 0; k < 4; k++ {
+				result[i][j] += m[i][k] * o[k][j]
+			}
+		}
+	}
+	return result
+}
+
+func (m mat4) apply(v vec3) vec3 {
+	return vec3{
+		m[0][0]*v[0] + m[0][1]*v[1] + m[0][2]*v[2] + m[0][3],
+		m[1][0]*v[0] + m[1][1]*v[1] + m[1][2]*v[2] + m[1][3],
+		m[2][0]*v[0] + m[2][1]*v[1] + m[2][2]*v[2] + m[2][3],
+	}
+}
+
+// projector evaluates the 3D children of a projection.
+//
+// Convex primitives (cube, cylinder, sphere) are represented by their
+// OpenSCAD vertices: their shadow is the 2D convex hull of the projected
+// vertices, and their cut at z=0 is the convex hull of the points where
+// the segments between vertices cross the plane.
+// Cuts of booleans are exact; shadows of differences and intersections
+// are conservative (the shadow of the first child, or the intersection
+// of the shadows, respectively).
+type projector struct {
+	cut bool
+}
+
+func evalProjection(node *ast.ProjectionBlockPrimitive) (Shape, error) {
+	a, err := params.Parse(node.Arguments)
+	if err != nil {
+		return nil, fmt.Errorf("projection: %v", err)
+	}
+	cut, err := a.Bool(-1, "cut", false)
+	if err != nil {
+		return nil, fmt.Errorf("projection: %v", err)
+	}
+	pr := &projector{cut: cut}
+	return pr.block(node.Body, identity)
+}
+
+func (pr *projector) block(block *ast.BlockStatement, m mat4) (Shape, error) {
+	children, err := pr.children(block, m)
+	if err != nil {
+		return nil, err
+	}
+	return Union(children...), nil
+}
+
+func (pr *projector) children(block *ast.BlockStatement, m mat4) ([]Shape, error) {
+	if block == nil {
+		return nil, nil
+	}
+	var result []Shape
+	for _, stmt := range block.Statements {
+		s, err := pr.node(stmt, m)
+		if err != nil {
+			return nil, err
+		}
+		if s != nil {
+			result = append(result, s)
+		}
+	}
+	return result, nil
+}
+
+func (pr *projector) node(node ast.Node, m mat4) (Shape, error) {
+	switch node := node.(type) {
+	case *ast.ExpressionStatement:
+		return pr.node(node.Expression, m)
+	case *ast.LineComment:
+		return nil, nil
+	case *ast.ModifierExpression:
+		if node.Disabled() {
+			return nil, nil
+		}
+		return pr.node(node.Right, m)
+	case *ast.CubePrimitive, *ast.CylinderPrimitive, *ast.SpherePrimitive, *ast.HullBlockPrimitive:
+		pts, err := vertices(node, m)
+		if err != nil {
+			return nil, err
+		}
+		return pr.convex(pts), nil
+	case *ast.PolyhedronPrimitive:
+		if pr.cut {
+			return nil, fmt.Errorf("projection(cut = true) of polyhedron is not supported")
+		}
+		return polyhedronShadow(node, m)
+	case *ast.ColorBlockPrimitive:
+		return pr.block(node.Body, m)
+	case *ast.GroupBlockPrimitive:
+		return pr.block(node.Body, m)
+	case *ast.UnionBlockPrimitive:
+		return pr.block(node.Body, m)
+	case *ast.DifferenceBlockPrimitive:
+		children, err := pr.children(node.Body, m)
+		if err != nil || len(children) == 0 {
+			return nil, err
+		}
+		if !pr.cut {
+			return children[0], nil
+		}
+		return Difference(children...), nil
+	case *ast.IntersectionBlockPrimitive:
+		children, err := pr.children(node.Body, m)
+		if err != nil {
+			return nil, err
+		}
+		return Intersection(children...), nil
+	case *ast.MultmatrixBlockPrimitive, *ast.TranslateBlockPrimitive, *ast.RotateBlockPrimitive,
+		*ast.ScaleBlockPrimitive, *ast.MirrorBlockPrimitive, *ast.ResizeBlockPrimitive:
+		mm, err := multmatrix(node.(ast.Expression))
+		if err != nil {
+			return nil, err
+		}
+		return pr.block(transform.Body(node.(ast.Expression)), m.mul(mm))
+	case *ast.LinearExtrudeBlockPrimitive:
+		return pr.linearExtrude(node, m)
+	}
+	return nil, fmt.Errorf("projection of %v is not supported", node.TokenLiteral())
+}
+
+// multmatrix returns the matrix of a transform block. The size of
+// 3D children is unknown here, so resize is not supported.
+func multmatrix(node ast.Expression) (mat4, error) {
+	m, err := transform.Of(node, nil)
+	return mat4(m), err
+}
+
+// convex returns the shadow or cut of the convex hull of the points.
+func (pr *projector) convex(pts []vec3) Shape {
+	var flat []Point
+	if !pr.cut {
+		for _, p := range pts {
+			flat = append(flat, Point{X: p[0], Y: p[1]})
+		}
+	} else {
+		for i, p := range pts {
+			if p[2] == 0 {
+				flat = append(flat, Point{X: p[0], Y: p[1]})
+			}
+			for _, q := range pts[i+1:] {
+				if (p[2] < 0 && q[2] > 0) || (p[2] > 0 && q[2] < 0) {
+					t := p[2] / (p[2] - q[2])
+					flat = append(flat, Point{X: p[0] + t*(q[0]-p[0]), Y: p[1] + t*(q[1]-p[1])})
+				}
+			}
+		}
+	}
+	if hull := ConvexHull(flat); hull != nil {
+		return Shape{hull}
+	}
+	return nil
+}
+
+// vertices returns the transformed vertices of a convex primitive
+// (or of all the primitives within a hull).
+func vertices(node ast.Node, m mat4) ([]vec3, error) {
+	var local []vec3
+	switch node := node.(type) {
+	case *ast.ExpressionStatement:
+		return vertices(node.Expression, m)
+	case *ast.LineComment:
+		return nil, nil
+	case *ast.ModifierExpression:
+		if node.Disabled() {
+			return nil, nil
+		}
+		return vertices(node.Right, m)
+	case *ast.CubePrimitive:
+		size, center, err := params.Cube(node.Arguments)
+		if err != nil {
+			return nil, fmt.Errorf("cube: %v", err)
+		}
+		var o vec3
+		if center {
+			o = vec3{-0.5 * size[0], -0.5 * size[1], -0.5 * size[2]}
+		}
+		for i := 0; i < 8; i++ {
+			local = append(local, vec3{
+				o[0] + float64(i&1)*size[0],
+				o[1] + float64((i>>1)&1)*size[1],
+				o[2] + float64((i>>2)&1)*size[2],
+			})
+		}
+	case *ast.CylinderPrimitive:
+		c, err := params.Cylinder(node.Arguments)
+		if err != nil {
+			return nil, fmt.Errorf("cylinder: %v", err)
+		}
+		z1, z2 := 0.0, c.H
+		if c.Center {
+			z1, z2 = -0.5*c.H, 0.5*c.H
+		}
+		for _, ring := range []struct{ r, z float64 }{{c.R1, z1}, {c.R2, z2}} {
+			for _, pt := range Circle(ring.r, c.Fragments) {
+				local = append(local, vec3{pt.X, pt.Y, ring.z})
+			}
+		}
+	case *ast.SpherePrimitive:
+		r, fragments, err := params.Sphere(node.Arguments)
+		if err != nil {
+			return nil, fmt.Errorf("sphere: %v", err)
+		}
+		rings := (fragments + 1) / 2
+		for i := 0; i < rings; i++ {
+			phi := math.Pi * (float64(i) + 0.5) / float64(rings)
+			for _, pt := range Circle(r*math.Sin(phi), fragments) {
+				local = append(local, vec3{pt.X, pt.Y, r * math.Cos(phi)})
+			}
+		}
+	case *ast.HullBlockPrimitive:
+		return blockVertices(node.Body, m)
+	case *ast.GroupBlockPrimitive:
+		return blockVertices(node.Body, m)
+	case *ast.UnionBlockPrimitive:
+		return blockVertices(node.Body, m)
+	case *ast.ColorBlockPrimitive:
+		return blockVertices(node.Body, m)
+	case *ast.MultmatrixBlockPrimitive, *ast.TranslateBlockPrimitive, *ast.RotateBlockPrimitive,
+		*ast.ScaleBlockPrimitive, *ast.MirrorBlockPrimitive, *ast.ResizeBlockPrimitive:
+		mm, err := multmatrix(node.(ast.Expression))
+		if err != nil {
+			return nil, err
+		}
+		return blockVertices(transform.Body(node.(ast.Expression)), m.mul(mm))
+	default:
+		return nil, fmt.Errorf("projection of %v within hull is not supported", node.TokenLiteral())
+	}
+
+	result := make([]vec3, len(local))
+	for i, v := range local {
+		result[i] = m.apply(v)
+	}
+	return result, nil
+}
+
+func blockVertices(block *ast.BlockStatement, m mat4) ([]vec3, error) {
+	if block == nil {
+		return nil, nil
+	}
+	var result []vec3
+	for _, stmt := range block.Statements {
+		pts, err := vertices(stmt, m)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, pts...)
+	}
+	return result, nil
+}
+
+// linearExtrude projects an untwisted, unscaled linear_extrude whose
+// transform keeps the XY plane horizontal.
+func (pr *projector) linearExtrude(node *ast.LinearExtrudeBlockPrimitive, m mat4) (Shape, error) {
+	le, err := params.LinearExtrude(node.Arguments)
+	if err != nil {
+		return nil, fmt.Errorf("linear_extrude: %v", err)
+	}
+	if le.Twist != 0 || le.Scale[0] != 1 || le.Scale[1] != 1 || le.V != [3]float64{0, 0, 1} || m[0][2] != 0 || m[1][2] != 0 || m[2][0] != 0 || m[2][1] != 0 {
+		return nil, fmt.Errorf("projection of twisted, scaled or tilted linear_extrude is not supported")
+	}
+
+	s, err := evalBlock(node.Body)
+	if err != nil {
+		return nil, err
+	}
+	if pr.cut {
+		z1, z2 := 0.0, le.Height
+		if le.Center {
+			z1, z2 = -0.5*le.Height, 0.5*le.Height
+		}
+		z1, z2 = m[2][2]*z1+m[2][3], m[2][2]*z2+m[2][3]
+		if math.Min(z1, z2) > 0 || math.Max(z1, z2) < 0 {
+			return nil, nil
+		}
+	}
+	return s.Transform(m), nil
+}
+
+// polyhedronShadow returns the union of the projections of all the faces.
+func polyhedronShadow(node *ast.PolyhedronPrimitive, m mat4) (Shape, error) {
+	points, faces, err := params.Polyhedron(node.Arguments)
+	if err != nil {
+		return nil, fmt.Errorf("polyhedron: %v", err)
+	}
+	pts := make([]Point, len(points))
+	for i, v := range points {
+		p := m.apply(vec3(v))
+		pts[i] = Point{X: p[0], Y: p[1]}
+	}
+	var parts []Shape
+	for _, face := range faces {
+		var p Path
+		for _, i := range face {
+			p = append(p, pts[i])
+		}
+		parts = append(parts, Shape{p})
+	}
+	return Union(parts...), nil
+}
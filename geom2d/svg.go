@@ -0,0 +1,60 @@
+package geom2d
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// WriteSVG writes the shape as an SVG document in millimeters.
+// Each polygon (with its holes) becomes a single path element
+// using the even-odd fill rule. The Y axis is flipped so that
+// the drawing matches the CSG coordinate system.
+func (s Shape) WriteSVG(w io.Writer) error {
+	polys := s.Polygons()
+	min, max := s.Bounds()
+	if len(polys) == 0 {
+		min, max = Point{}, Point{}
+	}
+	width, height := max.X-min.X, max.Y-min.Y
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, `<?xml version="1.0" encoding="UTF-8" standalone="no"?>
+<svg xmlns="http://www.w3.org/2000/svg" version="1.1" width="%vmm" height="%vmm" viewBox="%v %v %v %v">
+`, num(width), num(height), num(min.X), num(-max.Y), num(width), num(height))
+	for _, poly := range polys {
+		var d []string
+		for _, p := range append([]Path{poly.Outer}, poly.Holes...) {
+			d = append(d, svgPath(p))
+		}
+		fmt.Fprintf(bw, "<path d=\"%v\" fill=\"lightgray\" fill-rule=\"evenodd\" stroke=\"black\" stroke-width=\"0.1\"/>\n", strings.Join(d, " "))
+	}
+	bw.WriteString("</svg>\n")
+	return bw.Flush()
+}
+
+func svgPath(p Path) string {
+	var parts []string
+	for i, pt := range p {
+		cmd := "L"
+		if i == 0 {
+			cmd = "M"
+		}
+		parts = append(parts, fmt.Sprintf("%v%v,%v", cmd, num(pt.X), num(-pt.Y)))
+	}
+	return strings.Join(parts, " ") + " Z"
+}
+
+// num formats a coordinate compactly, avoiding "-0" and float noise.
+func num(v float64) string {
+	if math.Abs(v) < 1e-12 {
+		return "0"
+	}
+	s := fmt.Sprintf("%.6g", v)
+	if s == "-0" {
+		s = "0"
+	}
+	return s
+}
@@ -0,0 +1,91 @@
+package irmf
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// earlyOut returns the statement that makes a generated function
+// return early when xyz is outside of mbb, if culling is enabled.
+func (s *Shader) earlyOut(mbb *MBB) string {
+	if !s.culling || mbb == nil || mbb.partial {
+		return ""
+	}
+	return boundsTest(mbb)
+}
+
+// boundsTest returns a statement that returns 0.0 when xyz is outside
+// of mbb. A 2D MBB only tests xyz.xy, since 2D geometry is never
+// culled along Z.
+func boundsTest(mbb *MBB) string {
+	if mbb.flat {
+		return fmt.Sprintf("\tif (any(lessThan(xyz.xy, vec2(%v,%v))) || any(greaterThan(xyz.xy, vec2(%v,%v)))) { return 0.0; }\n",
+			mbb.XMin, mbb.YMin, mbb.XMax, mbb.YMax)
+	}
+	return fmt.Sprintf("\tif (any(lessThan(xyz, vec3(%v,%v,%v))) || any(greaterThan(xyz, vec3(%v,%v,%v)))) { return 0.0; }\n",
+		mbb.XMin, mbb.YMin, mbb.ZMin, mbb.XMax, mbb.YMax, mbb.ZMax)
+}
+
+// bvh returns the calls of a bounding volume hierarchy of the children.
+// The children are recursively split in two halves along the longest
+// axis of their centers, and each half (of more than one child) becomes
+// a function that is only evaluated within its MBB. The sum of the calls is the sum of the
+// children's calls.
+func (s *Shader) bvh(children []*child) []string {
+	if len(children) <= s.bvhLeafSize {
+		var calls []string
+		for _, c := range children {
+			calls = append(calls, c.call)
+		}
+		return calls
+	}
+
+	centers := &MBB{}
+	for i, c := range children {
+		x, y, z := c.mbb.center()
+		p := &MBB{XMin: x, YMin: y, ZMin: z, XMax: x, YMax: y, ZMax: z}
+		if i == 0 {
+			*centers = *p
+			continue
+		}
+		centers.update(p)
+	}
+	dx, dy, dz := centers.XMax-centers.XMin, centers.YMax-centers.YMin, centers.ZMax-centers.ZMin
+	axis := func(c *child) float64 {
+		x, y, z := c.mbb.center()
+		switch {
+		case dx >= dy && dx >= dz:
+			return x
+		case dy >= dz:
+			return y
+		}
+		return z
+	}
+
+	sorted := append([]*child{}, children...)
+	sort.SliceStable(sorted, func(i, j int) bool { return axis(sorted[i]) < axis(sorted[j]) })
+
+	half := len(sorted) / 2
+	var calls []string
+	for _, part := range [][]*child{sorted[:half], sorted[half:]} {
+		if len(part) == 1 {
+			calls = append(calls, part[0].call)
+			continue
+		}
+		mbb := *part[0].mbb
+		for _, c := range part[1:] {
+			mbb.update(c.mbb)
+		}
+		fName := s.addFunction("bvh", `float %v(in vec3 xyz) {
+%v	return %v;
+}
+`, boundsTest(&mbb), strings.Join(s.bvh(part), " + "))
+		calls = append(calls, fmt.Sprintf("%v(xyz)", fName))
+	}
+	return calls
+}
+
+func (mbb *MBB) center() (x, y, z float64) {
+	return 0.5 * (mbb.XMin + mbb.XMax), 0.5 * (mbb.YMin + mbb.YMax), 0.5 * (mbb.ZMin + mbb.ZMax)
+}
@@ -0,0 +1,187 @@
+package irmf
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/gmlewis/go-csg/lexer"
+	"github.com/gmlewis/go-csg/parser"
+)
+
+func TestBoundsCulling(t *testing.T) {
+	tests := []struct {
+		src  string
+		opts []Option
+		want string
+	}{
+		{
+			src: `union() {
+	cube(size = [1, 2, 3], center = false);
+	multmatrix([[1, 0, 0, 5], [0, 1, 0, 0], [0, 0, 1, 0], [0, 0, 0, 1]]) {
+		sphere(r = 1);
+	}
+}`,
+			opts: []Option{WithBoundsCulling()},
+			want: `float multimatrixBlock0(in vec3 xyz) {
+	if (any(lessThan(xyz, vec3(4,-1,-1))) || any(greaterThan(xyz, vec3(6,1,1)))) { return 0.0; }
+	mat4 xfm = mat4(vec4(1, 0, 0, -5), vec4(0, 1, 0, 0), vec4(0, 0, 1, 0), vec4(0, 0, 0, 1));
+	xyz = (vec4(xyz, 1.0) * xfm).xyz;
+	return sphere(float(1), xyz);
+}
+
+float union1(in vec3 xyz) {
+	if (any(lessThan(xyz, vec3(0,-1,-1))) || any(greaterThan(xyz, vec3(6,2,3)))) { return 0.0; }
+	return clamp(cube(vec3(1, 2, 3), false, xyz) + multimatrixBlock0(xyz), 0.0, 1.0);
+}
+
+void mainModel4(out vec4 materials, in vec3 xyz) {
+	materials[0] = union1(xyz);
+}
+`,
+		},
+		{
+			src: `linear_extrude(height = 2, center = false, scale = [1, 1]) {
+	square(size = [1, 1], center = false);
+}`,
+			opts: []Option{WithBoundsCulling()},
+			want: `float linearExtrudeBlock0(in vec3 xyz) {
+	if (any(lessThan(xyz, vec3(0,0,0))) || any(greaterThan(xyz, vec3(1,1,2)))) { return 0.0; }
+	float t = (xyz.z - float(0)) / float(2);
+	if (t < 0.0 || t > 1.0) { return 0.0; }
+	mat2 m = linearExtrudeSlice(float(0), vec2(1, 1), float(1), t);
+	if (determinant(m) == 0.0) { return 0.0; }
+	xyz.xy = inverse(m) * xyz.xy;
+	return square(vec2(1, 1), false, xyz);
+}
+
+void mainModel4(out vec4 materials, in vec3 xyz) {
+	materials[0] = linearExtrudeBlock0(xyz);
+}
+`,
+		},
+		{
+			src: `color([1, 0, 0, 1]) {
+	circle(r = 2);
+}`,
+			opts: []Option{WithBoundsCulling()},
+			want: `float colorBlock0(in vec3 xyz) {
+	if (any(lessThan(xyz.xy, vec2(-2,-2))) || any(greaterThan(xyz.xy, vec2(2,2)))) { return 0.0; }
+	return circle(float(2), xyz);
+}
+
+void mainModel4(out vec4 materials, in vec3 xyz) {
+	materials[0] = colorBlock0(xyz);
+}
+`,
+		},
+		{
+			src: `sphere(r = 1);
+multmatrix([[1, 0, 0, 30], [0, 1, 0, 0], [0, 0, 1, 0], [0, 0, 0, 1]]) { sphere(r = 1); }
+multmatrix([[1, 0, 0, 10], [0, 1, 0, 0], [0, 0, 1, 0], [0, 0, 0, 1]]) { sphere(r = 1); }
+multmatrix([[1, 0, 0, 20], [0, 1, 0, 0], [0, 0, 1, 0], [0, 0, 0, 1]]) { sphere(r = 1); }`,
+			opts: []Option{WithBVH(1)},
+			want: `float multimatrixBlock0(in vec3 xyz) {
+	mat4 xfm = mat4(vec4(1, 0, 0, -30), vec4(0, 1, 0, 0), vec4(0, 0, 1, 0), vec4(0, 0, 0, 1));
+	xyz = (vec4(xyz, 1.0) * xfm).xyz;
+	return sphere(float(1), xyz);
+}
+
+float multimatrixBlock1(in vec3 xyz) {
+	mat4 xfm = mat4(vec4(1, 0, 0, -10), vec4(0, 1, 0, 0), vec4(0, 0, 1, 0), vec4(0, 0, 0, 1));
+	xyz = (vec4(xyz, 1.0) * xfm).xyz;
+	return sphere(float(1), xyz);
+}
+
+float multimatrixBlock2(in vec3 xyz) {
+	mat4 xfm = mat4(vec4(1, 0, 0, -20), vec4(0, 1, 0, 0), vec4(0, 0, 1, 0), vec4(0, 0, 0, 1));
+	xyz = (vec4(xyz, 1.0) * xfm).xyz;
+	return sphere(float(1), xyz);
+}
+
+float bvh3(in vec3 xyz) {
+	if (any(lessThan(xyz, vec3(-1,-1,-1))) || any(greaterThan(xyz, vec3(11,1,1)))) { return 0.0; }
+	return sphere(float(1), xyz) + multimatrixBlock1(xyz);
+}
+
+float bvh4(in vec3 xyz) {
+	if (any(lessThan(xyz, vec3(19,-1,-1))) || any(greaterThan(xyz, vec3(31,1,1)))) { return 0.0; }
+	return multimatrixBlock2(xyz) + multimatrixBlock0(xyz);
+}
+
+void mainModel4(out vec4 materials, in vec3 xyz) {
+	materials[0] = bvh3(xyz) + bvh4(xyz);
+}
+`,
+		},
+		{
+			src: `linear_extrude(height = 10) {
+	translate([0, 0, 5]) {
+		square(size = 2);
+	}
+}`,
+			opts: []Option{WithBoundsCulling()},
+			want: `float multimatrixBlock0(in vec3 xyz) {
+	if (any(lessThan(xyz.xy, vec2(0,0))) || any(greaterThan(xyz.xy, vec2(2,2)))) { return 0.0; }
+	mat4 xfm = mat4(vec4(1, 0, 0, 0), vec4(0, 1, 0, 0), vec4(0, 0, 1, -5), vec4(0, 0, 0, 1));
+	xyz = (vec4(xyz, 1.0) * xfm).xyz;
+	return square(vec2(2), false, xyz);
+}
+
+float linearExtrudeBlock1(in vec3 xyz) {
+	if (any(lessThan(xyz, vec3(0,0,0))) || any(greaterThan(xyz, vec3(2,2,10)))) { return 0.0; }
+	float t = (xyz.z - float(0)) / float(10);
+	if (t < 0.0 || t > 1.0) { return 0.0; }
+	mat2 m = linearExtrudeSlice(float(0), vec2(1, 1), float(1), t);
+	if (determinant(m) == 0.0) { return 0.0; }
+	xyz.xy = inverse(m) * xyz.xy;
+	return multimatrixBlock0(xyz);
+}
+
+void mainModel4(out vec4 materials, in vec3 xyz) {
+	materials[0] = linearExtrudeBlock1(xyz);
+}
+`,
+		},
+		{
+			src:  `union() { cube(size = [1, 1, 1], center = false); polyhedron(points = [[0, 0, 0], [1, 0, 0], [0, 1, 0], [0, 0, 1]], faces = [[0, 1, 2], [0, 3, 1]], convexity = 1); }`,
+			opts: []Option{WithBoundsCulling()},
+			want: `float union0(in vec3 xyz) {
+	return clamp(cube(vec3(1, 1, 1), false, xyz) + polyhedron(TODO), 0.0, 1.0);
+}
+
+void mainModel4(out vec4 materials, in vec3 xyz) {
+	materials[0] = union0(xyz);
+}
+`,
+		},
+		{
+			src:  `sphere(r = 1); sphere(r = 2);`,
+			opts: []Option{WithBoundsCulling(), WithBVH(2)},
+			want: `void mainModel4(out vec4 materials, in vec3 xyz) {
+	materials[0] = sphere(float(1), xyz) + sphere(float(2), xyz);
+}
+`,
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test #%v", i), func(t *testing.T) {
+			le := lexer.New(tt.src)
+			p := parser.New(le)
+			program := p.ParseProgram()
+			if errs := p.Errors(); len(errs) != 0 {
+				t.Fatalf("ParseProgram: %v", strings.Join(errs, "\n"))
+			}
+
+			shader, err := New(program, false, tt.opts...)
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+			got := strings.Join(shader.Functions, "\n")
+			if got != tt.want {
+				t.Errorf("functions =\n%v\nwant:\n%v", got, tt.want)
+			}
+		})
+	}
+}
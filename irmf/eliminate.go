@@ -0,0 +1,148 @@
+package irmf
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/gmlewis/go-csg/ast"
+)
+
+// child represents the generated call and MBB of a child node.
+// An empty call means that the child is empty.
+type child struct {
+	call string
+	mbb  *MBB
+}
+
+// getChildren returns the children of a block, in order. Statements that
+// are not geometry (such as comments or disabled nodes) are skipped, just
+// like OpenSCAD does, so that the first child of a difference is always
+// its minuend.
+func (s *Shader) getChildren(stmts []ast.Statement) []*child {
+	var result []*child
+	for _, stmt := range stmts {
+		if !isGeometry(stmt) {
+			if es, ok := stmt.(*ast.ExpressionStatement); ok {
+				s.processExpression(es.Expression) // Report disabled nodes.
+			}
+			continue
+		}
+		call, mbb := s.processStatement(stmt)
+		result = append(result, &child{call: call, mbb: mbb})
+	}
+	return result
+}
+
+func isGeometry(stmt ast.Statement) bool {
+	es, ok := stmt.(*ast.ExpressionStatement)
+	if !ok {
+		return true
+	}
+	switch exp := es.Expression.(type) {
+	case *ast.LineComment, *ast.GroupPrimitive:
+		return false
+	case *ast.ModifierExpression:
+		return !exp.Disabled()
+	}
+	return true
+}
+
+// removef records the removal of geometry.
+func (s *Shader) removef(format string, args ...interface{}) {
+	s.Removed = append(s.Removed, fmt.Sprintf(format, args...))
+}
+
+// overlaps reports whether the two MBBs intersect (or touch).
+// Z is ignored if either MBB is 2D.
+func (mbb *MBB) overlaps(other *MBB) bool {
+	return mbb.XMin <= other.XMax && other.XMin <= mbb.XMax &&
+		mbb.YMin <= other.YMax && other.YMin <= mbb.YMax &&
+		(mbb.flat || other.flat || mbb.ZMin <= other.ZMax && other.ZMin <= mbb.ZMax)
+}
+
+// known reports whether the MBB bounds all of its geometry, so that
+// it can be used to eliminate geometry.
+func known(mbb *MBB) bool {
+	return mbb != nil && !mbb.partial
+}
+
+// intersect returns the intersection of the two MBBs, where a nil MBB
+// is unknown. The result is empty if the MBBs do not overlap.
+func intersect(mbb, other *MBB) *MBB {
+	if mbb == nil {
+		return other
+	}
+	if other == nil {
+		return mbb
+	}
+	return &MBB{
+		XMin: max(mbb.XMin, other.XMin), XMax: min(mbb.XMax, other.XMax),
+		YMin: max(mbb.YMin, other.YMin), YMax: min(mbb.YMax, other.YMax),
+		ZMin: max(mbb.ZMin, other.ZMin), ZMax: min(mbb.ZMax, other.ZMax),
+
+		partial: mbb.partial || other.partial,
+		flat:    mbb.flat && other.flat,
+	}
+}
+
+// empty reports whether the MBB contains no points. Z is ignored
+// if the MBB is 2D.
+func (mbb *MBB) empty() bool {
+	return mbb.XMin > mbb.XMax || mbb.YMin > mbb.YMax || !mbb.flat && mbb.ZMin > mbb.ZMax
+}
+
+func min(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+var (
+	funcNameRE = regexp.MustCompile(`^\w+ (\w+)\(`)
+	funcCallRE = regexp.MustCompile(`\b(\w+)\(`)
+)
+
+// prune removes the generated functions that are no longer called
+// from mainModel4, such as the functions of eliminated geometry.
+func (s *Shader) prune() {
+	index := map[string]int{}
+	for i, f := range s.Functions {
+		if m := funcNameRE.FindStringSubmatch(f); m != nil {
+			index[m[1]] = i
+		}
+	}
+
+	used := make([]bool, len(s.Functions))
+	var visit func(i int)
+	visit = func(i int) {
+		if used[i] {
+			return
+		}
+		used[i] = true
+		// Skip the function's own name.
+		body := s.Functions[i][strings.Index(s.Functions[i], "(")+1:]
+		for _, m := range funcCallRE.FindAllStringSubmatch(body, -1) {
+			if j, ok := index[m[1]]; ok {
+				visit(j)
+			}
+		}
+	}
+	visit(index["mainModel4"])
+
+	var functions []string
+	for i, f := range s.Functions {
+		if used[i] {
+			functions = append(functions, f)
+		}
+	}
+	s.Functions = functions
+}
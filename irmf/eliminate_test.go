@@ -0,0 +1,103 @@
+package irmf
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gmlewis/go-csg/lexer"
+	"github.com/gmlewis/go-csg/parser"
+)
+
+func TestDeadGeometryElimination(t *testing.T) {
+	tests := []struct {
+		src       string
+		main      string
+		functions int
+		removed   []string
+	}{
+		{
+			src: `difference() {
+	cube(size = [1, 1, 1], center = false);
+	multmatrix([[1, 0, 0, 5], [0, 1, 0, 0], [0, 0, 1, 0], [0, 0, 0, 1]]) {
+		cube(size = [1, 1, 1], center = false);
+	}
+	sphere(r = 1);
+}`,
+			main:      "difference1(xyz)",
+			functions: 2,
+			removed:   []string{"removed difference subtrahend multimatrixBlock0(xyz) (outside of the minuend's MBB)"},
+		},
+		{
+			src: `intersection() {
+	cube(size = [1, 1, 1], center = false);
+	multmatrix([[1, 0, 0, 5], [0, 1, 0, 0], [0, 0, 1, 0], [0, 0, 0, 1]]) {
+		cube(size = [1, 1, 1], center = false);
+	}
+}
+sphere(r = 1);`,
+			main:      "sphere(float(1), xyz)",
+			functions: 1,
+			removed:   []string{"removed intersection of cube(vec3(1, 1, 1), false, xyz), multimatrixBlock0(xyz) (the children's MBBs do not overlap)"},
+		},
+		{
+			src:       `union() { cube(size = [0, 1, 1], center = false); sphere(r = 0); sphere(r = 2); }`,
+			main:      "union0(xyz)",
+			functions: 2,
+			removed:   []string{"removed zero-size cube(size = [0, 1, 1])", "removed zero-size sphere(r = 0)"},
+		},
+		{
+			src:       `difference() { *cube(size = [3, 3, 3], center = true); sphere(r = 1); %sphere(r = 2); }`,
+			main:      "difference0(xyz)",
+			functions: 2,
+			removed:   []string{"removed *cube (disabled by modifier)", "removed %sphere (disabled by modifier)"},
+		},
+		{
+			src:       `difference() { cube(size = [1, 0, 1], center = false); sphere(r = 1); } #sphere(r = 2);`,
+			main:      "sphere(float(2), xyz)",
+			functions: 1,
+			removed:   []string{"removed zero-size cube(size = [1, 0, 1])", "removed difference with an empty minuend"},
+		},
+		{
+			src:       `difference() { square(size = 10); translate([1, 1, 1]) { square(size = 2); } }`,
+			main:      "difference1(xyz)",
+			functions: 3,
+		},
+		{
+			src:       `difference() { cube(size = 1); translate([5, 0, 0]) { union() { cube(size = 1); polyhedron(points = [[0, 0, 0], [1, 0, 0], [0, 1, 0], [0, 0, 1]], faces = [[0, 1, 2], [0, 3, 1]]); } } }`,
+			main:      "difference2(xyz)",
+			functions: 4,
+		},
+		{
+			src:       `intersection() { cube(size = 1); translate([5, 0, 0]) { union() { cube(size = 1); polyhedron(points = [[0, 0, 0], [1, 0, 0], [0, 1, 0], [0, 0, 1]], faces = [[0, 1, 2], [0, 3, 1]]); } } }`,
+			main:      "intersection2(xyz)",
+			functions: 4,
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test #%v", i), func(t *testing.T) {
+			le := lexer.New(tt.src)
+			p := parser.New(le)
+			program := p.ParseProgram()
+			if errs := p.Errors(); len(errs) != 0 {
+				t.Fatalf("ParseProgram: %v", strings.Join(errs, "\n"))
+			}
+
+			shader, err := New(program, false)
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+			if got, want := shader.Functions[len(shader.Functions)-1], fmt.Sprintf(mainBodyFmt, tt.main); got != want {
+				t.Errorf("main = %v, want %v", got, want)
+			}
+			if got := len(shader.Functions); got != tt.functions {
+				t.Errorf("got %v functions, want %v:\n%v", got, tt.functions, strings.Join(shader.Functions, "\n"))
+			}
+			if !reflect.DeepEqual(shader.Removed, tt.removed) {
+				t.Errorf("removed = %#v, want %#v", shader.Removed, tt.removed)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package irmf
+
+import (
+	"math"
+
+	"github.com/gmlewis/go-csg/ast"
+	"github.com/gmlewis/go-csg/params"
+)
+
+// circleFragments returns the number of fragments that OpenSCAD uses
+// for a circle or sphere, or 0 if it is rendered perfectly round.
+func (s *Shader) circleFragments(exps []ast.Expression) int {
+	if !s.facets {
+		return 0
+	}
+	_, fragments, err := params.Circle(exps)
+	if err != nil {
+		s.warnf("unable to compute fragments (%v); rendering it round", err)
+		return 0
+	}
+	return fragments
+}
+
+// cylinderFragments returns the number of fragments that OpenSCAD uses
+// for a cylinder, or 0 if it is rendered perfectly round.
+func (s *Shader) cylinderFragments(exps []ast.Expression) int {
+	if !s.facets {
+		return 0
+	}
+	c, err := params.Cylinder(exps)
+	if err != nil {
+		s.warnf("unable to compute fragments (%v); rendering it round", err)
+		return 0
+	}
+	return c.Fragments
+}
+
+// revolveFragments returns the number of fragments that OpenSCAD uses
+// for a rotate_extrude of the given angle whose profile reaches out to
+// radius r, or 0 if it is rendered perfectly round.
+func (s *Shader) revolveFragments(exps []ast.Expression, r, angle float64) int {
+	if !s.facets {
+		return 0
+	}
+	a, err := params.Parse(exps)
+	if err == nil {
+		var fragments int
+		if fragments, err = a.Fragments(r); err == nil {
+			return int(math.Max(float64(fragments)*math.Abs(angle)/360, 1))
+		}
+	}
+	s.warnf("unable to compute fragments (%v); rendering it round", err)
+	return 0
+}
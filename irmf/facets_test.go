@@ -0,0 +1,66 @@
+package irmf
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/gmlewis/go-csg/lexer"
+	"github.com/gmlewis/go-csg/parser"
+)
+
+func TestFacets(t *testing.T) {
+	tests := []struct {
+		src  string
+		opts []Option
+		want string
+	}{
+		{
+			src:  `cylinder($fn = 6, $fa = 12, $fs = 2, h = 2, r1 = 1, r2 = 1, center = false);`,
+			want: "cylinder(float(2), float(1), float(1), false, xyz)",
+		},
+		{
+			src:  `cylinder($fn = 6, $fa = 12, $fs = 2, h = 2, r1 = 1, r2 = 1, center = false);`,
+			opts: []Option{WithFacets()},
+			want: "facetedCylinder(float(2), float(1), float(1), false, 6.0, xyz)",
+		},
+		{
+			src:  `circle($fn = 0, $fa = 12, $fs = 2, r = 10);`,
+			opts: []Option{WithFacets()},
+			want: "facetedCircle(float(10), 30.0, xyz)",
+		},
+		{
+			src:  `sphere($fn = 0, $fa = 12, $fs = 2, r = 1);`,
+			opts: []Option{WithFacets()},
+			want: "facetedSphere(float(1), 5.0, xyz)",
+		},
+		{
+			src:  `sphere($fn = 16, $fa = 12, $fs = 2, r = 1);`,
+			opts: []Option{WithFacets()},
+			want: "facetedSphere(float(1), 16.0, xyz)",
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test #%v", i), func(t *testing.T) {
+			le := lexer.New(tt.src)
+			p := parser.New(le)
+			program := p.ParseProgram()
+			if errs := p.Errors(); len(errs) != 0 {
+				t.Fatalf("ParseProgram: %v", strings.Join(errs, "\n"))
+			}
+
+			shader, err := New(program, false, tt.opts...)
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+			if got, want := shader.Functions[len(shader.Functions)-1], fmt.Sprintf(mainBodyFmt, tt.want); got != want {
+				t.Errorf("main = %v, want %v", got, want)
+			}
+			name := tt.want[:strings.Index(tt.want, "(")]
+			if !shader.Primitives[name] || (strings.HasPrefix(name, "faceted") && !shader.Primitives["facet"]) {
+				t.Errorf("primitives = %v, want %v", shader.Primitives, name)
+			}
+		})
+	}
+}
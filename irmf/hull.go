@@ -0,0 +1,91 @@
+package irmf
+
+import "math"
+
+// maxPieces limits the number of pieces kept for an MBB. Larger
+// unions fall back to the corners of their box.
+const maxPieces = 64
+
+// piece represents a point, disc, or ball: the set of c + a*u for all u
+// in the unit ball of len(a) dimensions. The geometry of an MBB with
+// pieces lies within the convex hull of its pieces, which (unlike its
+// box) can be transformed without growing.
+type piece struct {
+	c [3]float64
+	a [][3]float64
+}
+
+func point(x, y, z float64) piece {
+	return piece{c: [3]float64{x, y, z}}
+}
+
+// disc returns a disc of radius r parallel to the XY plane.
+func disc(x, y, z, r float64) piece {
+	return piece{c: [3]float64{x, y, z}, a: [][3]float64{{r, 0, 0}, {0, r, 0}}}
+}
+
+func ball(r float64) piece {
+	return piece{a: [][3]float64{{r, 0, 0}, {0, r, 0}, {0, 0, r}}}
+}
+
+// extent returns the half-size of the piece along the given axis.
+func (p piece) extent(axis int) float64 {
+	var sum float64
+	for _, v := range p.a {
+		sum += v[axis] * v[axis]
+	}
+	return math.Sqrt(sum)
+}
+
+// pieces returns the pieces of the MBB, which are the corners
+// of its box if it has none.
+func (mbb *MBB) pieces() []piece {
+	if mbb.hull != nil {
+		return mbb.hull
+	}
+	zs := []float64{mbb.ZMin, mbb.ZMax}
+	if mbb.ZMin == mbb.ZMax {
+		zs = zs[:1]
+	}
+	var result []piece
+	for _, x := range []float64{mbb.XMin, mbb.XMax} {
+		for _, y := range []float64{mbb.YMin, mbb.YMax} {
+			for _, z := range zs {
+				result = append(result, point(x, y, z))
+			}
+		}
+	}
+	return result
+}
+
+// hullMBB returns the MBB of the pieces.
+func hullMBB(pieces []piece) *MBB {
+	mbb := &MBB{
+		XMin: math.Inf(1), YMin: math.Inf(1), ZMin: math.Inf(1),
+		XMax: math.Inf(-1), YMax: math.Inf(-1), ZMax: math.Inf(-1),
+	}
+	for _, p := range pieces {
+		dx, dy, dz := p.extent(0), p.extent(1), p.extent(2)
+		mbb.update(&MBB{
+			XMin: p.c[0] - dx, YMin: p.c[1] - dy, ZMin: p.c[2] - dz,
+			XMax: p.c[0] + dx, YMax: p.c[1] + dy, ZMax: p.c[2] + dz,
+		})
+	}
+	if len(pieces) <= maxPieces {
+		mbb.hull = pieces
+	}
+	return mbb
+}
+
+// union grows the MBB to include other, keeping the pieces of both.
+func (mbb *MBB) union(other *MBB) {
+	if other == nil {
+		return
+	}
+	pieces := append(append([]piece{}, mbb.pieces()...), other.pieces()...)
+	mbb.update(other)
+	mbb.hull = nil
+	if len(pieces) <= maxPieces {
+		mbb.hull = pieces
+	}
+}
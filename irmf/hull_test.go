@@ -0,0 +1,109 @@
+package irmf
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/gmlewis/go-csg/lexer"
+	"github.com/gmlewis/go-csg/parser"
+)
+
+func TestMBB(t *testing.T) {
+	const c45 = "0.7071067811865476"
+	rot45 := fmt.Sprintf("[[%v, -%v, 0, 0], [%v, %v, 0, 0], [0, 0, 1, 0], [0, 0, 0, 1]]", c45, c45, c45, c45)
+
+	tests := []struct {
+		src  string
+		want *MBB
+	}{
+		{
+			src:  fmt.Sprintf("multmatrix(%v) { cube(size = [2, 2, 2], center = true); }", rot45),
+			want: &MBB{XMin: -math.Sqrt2, XMax: math.Sqrt2, YMin: -math.Sqrt2, YMax: math.Sqrt2, ZMin: -1, ZMax: 1},
+		},
+		{
+			src:  fmt.Sprintf("multmatrix(%v) { sphere(r = 1); }", rot45),
+			want: &MBB{XMin: -1, XMax: 1, YMin: -1, YMax: 1, ZMin: -1, ZMax: 1},
+		},
+		{
+			src:  "multmatrix([[0, 0, 1, 0], [0, 1, 0, 0], [-1, 0, 0, 0], [0, 0, 0, 1]]) { cylinder(h = 4, r1 = 1, r2 = 1, center = false); }",
+			want: &MBB{XMin: 0, XMax: 4, YMin: -1, YMax: 1, ZMin: -1, ZMax: 1},
+		},
+		{
+			src:  "rotate([0, 0, 45]) { translate([1, 0, 0]) { sphere(r = 1); } }",
+			want: &MBB{XMin: math.Sqrt2/2 - 1, XMax: math.Sqrt2/2 + 1, YMin: math.Sqrt2/2 - 1, YMax: math.Sqrt2/2 + 1, ZMin: -1, ZMax: 1},
+		},
+		{
+			src:  "resize([4, 0, 0], auto = [false, true, false]) { translate([1, 0, 0]) { sphere(r = 1); } }",
+			want: &MBB{XMin: 0, XMax: 4, YMin: -2, YMax: 2, ZMin: -1, ZMax: 1},
+		},
+		{
+			src:  "rotate_extrude(angle = 90) { multmatrix([[1, 0, 0, 2], [0, 1, 0, 0], [0, 0, 1, 0], [0, 0, 0, 1]]) { square(size = [1, 1], center = false); } }",
+			want: &MBB{XMin: 0, XMax: 3, YMin: 0, YMax: 3, ZMin: 0, ZMax: 1},
+		},
+		{
+			src:  "rotate_extrude(angle = 180) { multmatrix([[1, 0, 0, 2], [0, 1, 0, 0], [0, 0, 1, 0], [0, 0, 0, 1]]) { square(size = [1, 1], center = false); } }",
+			want: &MBB{XMin: -3, XMax: 3, YMin: 0, YMax: 3, ZMin: 0, ZMax: 1},
+		},
+		{
+			src:  "rotate_extrude(angle = -90) { multmatrix([[1, 0, 0, 2], [0, 1, 0, 0], [0, 0, 1, 0], [0, 0, 0, 1]]) { square(size = [1, 1], center = false); } }",
+			want: &MBB{XMin: 0, XMax: 3, YMin: -3, YMax: 0, ZMin: 0, ZMax: 1},
+		},
+		{
+			src:  "rotate_extrude(angle = 90) { multmatrix([[1, 0, 0, -3], [0, 1, 0, 0], [0, 0, 1, 0], [0, 0, 0, 1]]) { square(size = [1, 1], center = false); } }",
+			want: &MBB{XMin: -3, XMax: 0, YMin: -3, YMax: 0, ZMin: 0, ZMax: 1},
+		},
+		{
+			src:  "rotate_extrude() { multmatrix([[1, 0, 0, 2], [0, 1, 0, 0], [0, 0, 1, 0], [0, 0, 0, 1]]) { square(size = [1, 1], center = false); } }",
+			want: &MBB{XMin: -3, XMax: 3, YMin: -3, YMax: 3, ZMin: 0, ZMax: 1},
+		},
+		{
+			src:  "linear_extrude(height = 1, center = false, scale = [2, 2]) { multmatrix([[1, 0, 0, 1], [0, 1, 0, 1], [0, 0, 1, 0], [0, 0, 0, 1]]) { square(size = [1, 1], center = false); } }",
+			want: &MBB{XMin: 1, XMax: 4, YMin: 1, YMax: 4, ZMin: 0, ZMax: 1},
+		},
+		{
+			src:  "linear_extrude(height = 1, center = false, twist = 90, scale = [1, 1]) { multmatrix([[1, 0, 0, 2], [0, 1, 0, 0], [0, 0, 1, 0], [0, 0, 0, 1]]) { circle(r = 1); } }",
+			want: &MBB{XMin: -1, XMax: 3, YMin: -3, YMax: 1, ZMin: 0, ZMax: 1},
+		},
+		{
+			src:  "linear_extrude(v = [1, 0, 1], center = false) { square(size = [1, 1], center = false); }",
+			want: &MBB{XMin: 0, XMax: 2, YMin: 0, YMax: 1, ZMin: 0, ZMax: 1},
+		},
+		{
+			src:  "linear_extrude(height = 2, v = [0, 0, 5], center = true) { circle(r = 1); }",
+			want: &MBB{XMin: -1, XMax: 1, YMin: -1, YMax: 1, ZMin: -1, ZMax: 1},
+		},
+		{
+			src:  "linear_extrude(scale = 0) { square(size = [2, 2], center = true); }",
+			want: &MBB{XMin: -1, XMax: 1, YMin: -1, YMax: 1, ZMin: 0, ZMax: 100},
+		},
+		{
+			src:  "linear_extrude(height = 1, twist = 180, slices = 2, scale = [1, 0.5]) { square(size = [1, 1], center = false); }",
+			want: &MBB{XMin: -1, XMax: 1, YMin: -0.75, YMax: 1, ZMin: 0, ZMax: 1},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test #%v", i), func(t *testing.T) {
+			le := lexer.New(tt.src)
+			p := parser.New(le)
+			program := p.ParseProgram()
+			if errs := p.Errors(); len(errs) != 0 {
+				t.Fatalf("ParseProgram: %v", strings.Join(errs, "\n"))
+			}
+
+			shader, err := New(program, false)
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+			got := shader.MBB
+			const eps = 1e-9
+			if math.Abs(got.XMin-tt.want.XMin) > eps || math.Abs(got.XMax-tt.want.XMax) > eps ||
+				math.Abs(got.YMin-tt.want.YMin) > eps || math.Abs(got.YMax-tt.want.YMax) > eps ||
+				math.Abs(got.ZMin-tt.want.ZMin) > eps || math.Abs(got.ZMax-tt.want.ZMax) > eps {
+				t.Errorf("mbb = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -4,13 +4,16 @@
 package irmf
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
 	"math"
 	"sort"
 	"strings"
 
 	"github.com/gmlewis/go-csg/ast"
+	"github.com/gmlewis/go-csg/dims"
+	"github.com/gmlewis/go-csg/transform"
 )
 
 const (
@@ -31,6 +34,88 @@ type Shader struct {
 	Functions  []string
 	Primitives map[string]bool
 	MBB        *MBB
+	// Removed describes the geometry that was eliminated because
+	// it cannot contribute to the result.
+	Removed []string
+	// Warnings describes the problems that did not prevent the
+	// generation of the shader (e.g. skipped nodes).
+	Warnings []string
+	// Unsupported lists the names of the nodes that are not yet
+	// supported, in the order that they were encountered.
+	Unsupported []string
+
+	// funcs maps the text of each generated function
+	// (named funcName) to its actual name.
+	funcs       map[string]string
+	instancing  bool
+	culling     bool
+	bvhLeafSize int
+	facets      bool
+	// previewThickness is the thickness of top-level 2D objects.
+	previewThickness float64
+	ctx              context.Context
+}
+
+// Option represents an option that controls the generation of a Shader.
+type Option func(s *Shader)
+
+// WithInstancing generates a transformed call to a shared function
+// (rather than a new function) for a multmatrix block with a single
+// child, so that repeated subtrees that only differ by their transform
+// are emitted once.
+func WithInstancing() Option {
+	return func(s *Shader) { s.instancing = true }
+}
+
+// WithFacets renders circles, cylinders and spheres as polygons and
+// polyhedra with the number of fragments that OpenSCAD uses (from $fn,
+// $fa and $fs) instead of as perfectly round shapes.
+func WithFacets() Option {
+	return func(s *Shader) { s.facets = true }
+}
+
+// WithPreviewThickness extrudes each top-level 2D object to the
+// thickness h, centered on Z=0, like OpenSCAD's preview does (with
+// a thickness of 1). Otherwise, 2D objects extend infinitely along Z.
+func WithPreviewThickness(h float64) Option {
+	return func(s *Shader) { s.previewThickness = h }
+}
+
+// WithBoundsCulling makes each generated function return early
+// when xyz is outside of the MBB of its geometry.
+func WithBoundsCulling() Option {
+	return func(s *Shader) { s.culling = true }
+}
+
+// WithBVH splits unions of more than leafSize children into a hierarchy
+// of bounding volumes, so that only the children near xyz are evaluated.
+// A leafSize of 0 disables the hierarchy.
+func WithBVH(leafSize int) Option {
+	return func(s *Shader) { s.bvhLeafSize = leafSize }
+}
+
+// WithContext stops the generation of the Shader (and New returns
+// an error) once ctx is done, e.g. when a request times out.
+func WithContext(ctx context.Context) Option {
+	return func(s *Shader) { s.ctx = ctx }
+}
+
+// funcName is a placeholder for the name of a generated function.
+const funcName = "_FUNC_NAME_"
+
+// addFunction adds the function generated by formatting its name and args
+// with format, and returns its name. The name is made from prefix and the
+// function's index. Identical functions are only added once, so identical
+// subtrees share a single function.
+func (s *Shader) addFunction(prefix, format string, args ...interface{}) string {
+	text := fmt.Sprintf(format, append([]interface{}{funcName}, args...)...)
+	if name, ok := s.funcs[text]; ok {
+		return name
+	}
+	name := fmt.Sprintf("%v%v", prefix, len(s.Functions))
+	s.funcs[text] = name
+	s.Functions = append(s.Functions, strings.Replace(text, funcName, name, 1))
+	return name
 }
 
 // String returns the strings representation of the IRMF Shader.
@@ -52,17 +137,85 @@ func (s *Shader) String() string {
 	return strings.Join(result, "\n")
 }
 
-// New returns a new IRMF Shader from a CSG ast.Program.
-func New(program *ast.Program, center bool) *Shader {
+// shaderError represents an error that stops the generation of a Shader.
+type shaderError struct {
+	err error
+}
+
+// errorf stops the generation of the Shader, and New returns the error.
+func (s *Shader) errorf(format string, args ...interface{}) {
+	panic(shaderError{err: fmt.Errorf(format, args...)})
+}
+
+// warnf records a warning, unless it was already recorded.
+func (s *Shader) warnf(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	for _, w := range s.Warnings {
+		if w == msg {
+			return
+		}
+	}
+	s.Warnings = append(s.Warnings, msg)
+}
+
+// unsupported records a node that is not yet supported.
+// If skipped, it does not contribute to the shader. Otherwise,
+// the shader contains a placeholder for it.
+func (s *Shader) unsupported(node ast.Node, skipped bool) {
+	name := node.TokenLiteral()
+	if ce, ok := node.(*ast.CallExpression); ok {
+		name = ce.Function.String()
+	}
+	s.Unsupported = append(s.Unsupported, name)
+	if skipped {
+		s.warnf("node currently not supported. Skipping: %v", node.String())
+		return
+	}
+	s.warnf("node currently not supported. Generating a TODO placeholder: %v", name)
+}
+
+// New returns a new IRMF Shader from a CSG ast.Program, or
+// an error if the program cannot be translated to IRMF.
+func New(program *ast.Program, center bool, opts ...Option) (shader *Shader, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			e, ok := r.(shaderError)
+			if !ok {
+				panic(r)
+			}
+			shader, err = nil, e.err
+		}
+	}()
+
 	s := &Shader{
 		Program:    program,
 		Primitives: map[string]bool{},
+		funcs:      map[string]string{},
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
 
-	calls, mbb := s.getCalls(program.Statements)
+	info := dims.Check(program)
+	var errs []string
+	for _, err := range info.Errors {
+		// The preview thickness extrudes the top-level 2D objects,
+		// so they can be unioned with the top-level 3D objects.
+		if e, ok := err.(*dims.Error); ok && e.TopLevel() && s.previewThickness > 0 {
+			s.warnf("%v; the top-level 2D objects are extruded to the preview thickness.", err)
+			continue
+		}
+		errs = append(errs, err.Error())
+	}
+	if len(errs) > 0 {
+		return nil, errors.New(strings.Join(errs, "\n"))
+	}
+
+	calls, mbb := s.getCalls(s.topLevel(program, info))
 	if len(calls) > 0 {
 		mainFunc := fmt.Sprintf(mainBodyFmt, strings.Join(calls, " + "))
-		if center {
+		// Placeholders (e.g. polyhedron) have no MBB.
+		if center && mbb != nil {
 			cx := 0.5 * (mbb.XMax + mbb.XMin)
 			cy := 0.5 * (mbb.YMax + mbb.YMin)
 			cz := 0.5 * (mbb.ZMax + mbb.ZMin)
@@ -76,10 +229,13 @@ func New(program *ast.Program, center bool) *Shader {
 		}
 
 		s.Functions = append(s.Functions, mainFunc)
-		s.MBB = mbb
+		if mbb != nil {
+			s.MBB = &MBB{XMin: mbb.XMin, YMin: mbb.YMin, ZMin: mbb.ZMin, XMax: mbb.XMax, YMax: mbb.YMax, ZMax: mbb.ZMax}
+		}
+		s.prune()
 	}
 
-	return s
+	return s, nil
 }
 
 // MBB represents a minimum bounding box.
@@ -87,12 +243,25 @@ type MBB struct {
 	XMin, XMax float64
 	YMin, YMax float64
 	ZMin, ZMax float64
+
+	// partial reports that some of the geometry has an unknown extent,
+	// so the MBB cannot be used to cull it.
+	partial bool
+	// flat reports that the geometry is 2D, so its extent along Z
+	// is meaningless (e.g. within a translated linear_extrude child).
+	flat bool
+	// hull optionally holds pieces whose convex hull
+	// contains the geometry.
+	hull []piece
 }
 
 func (mbb *MBB) update(other *MBB) {
 	if other == nil {
 		return
 	}
+	mbb.partial = mbb.partial || other.partial
+	mbb.flat = mbb.flat && other.flat
+	mbb.hull = nil
 	if other.XMin < mbb.XMin {
 		mbb.XMin = other.XMin
 	}
@@ -116,46 +285,101 @@ func (mbb *MBB) update(other *MBB) {
 func (s *Shader) getCalls(stmts []ast.Statement) ([]string, *MBB) {
 	var mbb *MBB
 	var calls []string
-	for _, stmt := range stmts {
-		if call, callMBB := s.processStatement(stmt); call != "" {
-			calls = append(calls, call)
-			if mbb == nil {
-				mbb = callMBB
-			} else {
-				mbb.update(callMBB)
-			}
+	var bounded []*child
+	var unbounded []string
+	var partial bool
+	for _, c := range s.getChildren(stmts) {
+		if c.call == "" {
+			continue
+		}
+		calls = append(calls, c.call)
+		if c.mbb == nil || c.mbb.partial {
+			unbounded = append(unbounded, c.call)
+			partial = true
+		} else {
+			bounded = append(bounded, c)
+		}
+		if c.mbb == nil {
+			continue
+		}
+		if mbb == nil {
+			m := *c.mbb
+			mbb = &m
+		} else {
+			mbb.union(c.mbb)
 		}
 	}
+	if mbb != nil {
+		mbb.partial = partial
+	}
+	if s.bvhLeafSize > 0 && len(bounded) > s.bvhLeafSize {
+		calls = append(s.bvh(bounded), unbounded...)
+	}
 	return calls, mbb
 }
 
+// bounds returns the MBB of the children of a block (as needed by resize).
+// The children are processed again afterwards, so everything that
+// processing them records is rolled back.
+func (s *Shader) bounds(body *ast.BlockStatement) (min, max [3]float64, ok bool) {
+	if body == nil {
+		return min, max, false
+	}
+	removed, warnings, unsupported, functions := len(s.Removed), len(s.Warnings), len(s.Unsupported), len(s.Functions)
+	_, mbb := s.getCalls(body.Statements)
+	s.Removed, s.Warnings, s.Unsupported = s.Removed[:removed], s.Warnings[:warnings], s.Unsupported[:unsupported]
+	added := map[string]bool{}
+	for _, f := range s.Functions[functions:] {
+		added[f] = true
+	}
+	for text, name := range s.funcs {
+		if added[strings.Replace(text, funcName, name, 1)] {
+			delete(s.funcs, text)
+		}
+	}
+	s.Functions = s.Functions[:functions]
+	if mbb == nil || mbb.partial {
+		return min, max, false
+	}
+	return [3]float64{mbb.XMin, mbb.YMin, mbb.ZMin}, [3]float64{mbb.XMax, mbb.YMax, mbb.ZMax}, true
+}
+
 func (s *Shader) processStatement(stmt ast.Statement) (string, *MBB) {
 	switch node := stmt.(type) {
 	case *ast.ExpressionStatement:
 		return s.processExpression(node.Expression)
 	default:
-		log.Fatalf("unhandled statement type %T (%+v)", node, node)
+		s.errorf("unhandled statement type %T (%+v)", node, node)
 	}
 	return "", nil
 }
 
 func (s *Shader) processExpression(exp ast.Expression) (string, *MBB) {
+	if s.ctx != nil {
+		if err := s.ctx.Err(); err != nil {
+			s.errorf("%w", err)
+		}
+	}
 	switch node := exp.(type) {
+	case *ast.LineComment, *ast.GroupPrimitive:
+		// Not geometry.
+	case *ast.ModifierExpression:
+		if !node.Disabled() {
+			return s.processExpression(node.Right)
+		}
+		s.removef("removed %v%v (disabled by modifier)", node.Modifier, node.Right.TokenLiteral())
 	case *ast.CallExpression:
-		log.Printf("WARNING: node currently not supported. Skipping: %v", node.String())
+		s.unsupported(node, true)
 	case *ast.CirclePrimitive:
 		return s.processCirclePrimitive(node.Arguments)
 	case *ast.ColorBlockPrimitive: // Currently, color itself is a NOOP.
 		if node.Body != nil {
 			calls, mbb := s.getCalls(node.Body.Statements)
 			if len(calls) > 0 {
-				fNum := len(s.Functions)
-				fName := fmt.Sprintf("colorBlock%v", fNum)
-				newFunc := fmt.Sprintf(`float %v(in vec3 xyz) {
-	return %v;
+				fName := s.addFunction("colorBlock", `float %v(in vec3 xyz) {
+%v	return %v;
 }
-`, fName, strings.Join(calls, " + "))
-				s.Functions = append(s.Functions, newFunc)
+`, s.earlyOut(mbb), strings.Join(calls, " + "))
 				return fmt.Sprintf("%v(xyz)", fName), mbb
 			}
 		}
@@ -172,13 +396,10 @@ func (s *Shader) processExpression(exp ast.Expression) (string, *MBB) {
 			// TODO: make a new function to call these statements.
 			calls, mbb := s.getCalls(node.Body.Statements)
 			if len(calls) > 0 {
-				fNum := len(s.Functions)
-				fName := fmt.Sprintf("groupBlock%v", fNum)
-				newFunc := fmt.Sprintf(`float %v(in vec3 xyz) {
-	return %v;
+				fName := s.addFunction("groupBlock", `float %v(in vec3 xyz) {
+%v	return %v;
 }
-`, fName, strings.Join(calls, " + "))
-				s.Functions = append(s.Functions, newFunc)
+`, s.earlyOut(mbb), strings.Join(calls, " + "))
 				return fmt.Sprintf("%v(xyz)", fName), mbb
 			}
 		}
@@ -187,13 +408,11 @@ func (s *Shader) processExpression(exp ast.Expression) (string, *MBB) {
 			// TODO: make a new function to call these statements.
 			calls, mbb := s.getCalls(node.Body.Statements)
 			if len(calls) > 0 {
-				fNum := len(s.Functions)
-				fName := fmt.Sprintf("hullBlock%v", fNum)
-				newFunc := fmt.Sprintf(`float %v(TODO) {
+				s.unsupported(node, false)
+				fName := s.addFunction("hullBlock", `float %v(TODO) {
 	return %v;
 }
-`, fName, strings.Join(calls, " + "))
-				s.Functions = append(s.Functions, newFunc)
+`, strings.Join(calls, " + "))
 				return fmt.Sprintf("%v(TODO)", fName), mbb
 			}
 		}
@@ -210,13 +429,15 @@ func (s *Shader) processExpression(exp ast.Expression) (string, *MBB) {
 			// TODO: make a new function to call these statements after wrapping in a minkowski.
 			calls, mbb := s.getCalls(node.Body.Statements)
 			if len(calls) > 0 {
-				fNum := len(s.Functions)
-				fName := fmt.Sprintf("minkowskiBlock%v", fNum)
-				newFunc := fmt.Sprintf(`float %v(TODO) {
+				if mbb != nil {
+					// The Minkowski sum extends beyond its children.
+					mbb.partial = true
+				}
+				s.unsupported(node, false)
+				fName := s.addFunction("minkowskiBlock", `float %v(TODO) {
 	return %v;
 }
-`, fName, strings.Join(calls, " + "))
-				s.Functions = append(s.Functions, newFunc)
+`, strings.Join(calls, " + "))
 				return fmt.Sprintf("%v(TODO)", fName), mbb
 			}
 		}
@@ -224,9 +445,21 @@ func (s *Shader) processExpression(exp ast.Expression) (string, *MBB) {
 		if node.Body != nil {
 			return s.processMultmatrixBlockPrimitive(node.Arguments, node.Body.Statements)
 		}
+	case *ast.MirrorBlockPrimitive, *ast.ResizeBlockPrimitive, *ast.RotateBlockPrimitive,
+		*ast.ScaleBlockPrimitive, *ast.TranslateBlockPrimitive:
+		mm, err := transform.Lower(node, s.bounds)
+		if w, ok := err.(*transform.Warning); ok {
+			s.warnf("%v", w)
+		} else if err != nil {
+			s.errorf("%v", err)
+		}
+		return s.processExpression(mm)
+	case *ast.OffsetBlockPrimitive:
+		s.unsupported(node, true)
 	case *ast.PolygonPrimitive:
 		return s.processPolygonPrimitive(node.Arguments)
 	case *ast.PolyhedronPrimitive:
+		s.unsupported(node, false)
 		s.Primitives["polyhedron"] = true
 		// TODO: make a new function to call this primitive.
 		return "polyhedron(TODO)", nil
@@ -235,13 +468,11 @@ func (s *Shader) processExpression(exp ast.Expression) (string, *MBB) {
 			// TODO: make a new function to call these statements after wrapping in a projection.
 			calls, mbb := s.getCalls(node.Body.Statements)
 			if len(calls) > 0 {
-				fNum := len(s.Functions)
-				fName := fmt.Sprintf("projectionBlock%v", fNum)
-				newFunc := fmt.Sprintf(`float %v(TODO) {
+				s.unsupported(node, false)
+				fName := s.addFunction("projectionBlock", `float %v(TODO) {
 	return %v;
 }
-`, fName, strings.Join(calls, " + "))
-				s.Functions = append(s.Functions, newFunc)
+`, strings.Join(calls, " + "))
 				return fmt.Sprintf("%v(TODO)", fName), mbb
 			}
 		}
@@ -258,7 +489,7 @@ func (s *Shader) processExpression(exp ast.Expression) (string, *MBB) {
 			return s.processUnionBlockPrimitive(node.Body.Statements)
 		}
 	default:
-		log.Fatalf("unhandled expression type %T (%+v)", node, node)
+		s.errorf("unhandled expression type %T (%+v)", node, node)
 	}
 	return "", nil
 }
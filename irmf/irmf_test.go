@@ -13,6 +13,7 @@ func TestShader_String(t *testing.T) {
 	tests := []struct {
 		program string
 		center  bool
+		opts    []Option
 		want    string
 	}{
 		{
@@ -45,6 +46,63 @@ void mainModel4(out vec4 materials, in vec3 xyz) {
 	xyz += vec3(0.5, 0.5, 0.5);
 	materials[0] = groupBlock0(xyz);
 }
+`,
+		},
+		{
+			program: `group() {
+				cube(size = [1, 1, 1], center = false);
+			}
+			group() {
+				cube(size = [1, 1, 1], center = false);
+			}
+			`,
+			want: primitives["cube"] + `
+float groupBlock0(in vec3 xyz) {
+	return cube(vec3(1, 1, 1), false, xyz);
+}
+
+void mainModel4(out vec4 materials, in vec3 xyz) {
+	materials[0] = groupBlock0(xyz) + groupBlock0(xyz);
+}
+`,
+		},
+		{
+			program: `multmatrix([[1, 0, 0, 2], [0, 1, 0, 0], [0, 0, 1, 0], [0, 0, 0, 1]]) {
+				group() {
+					cube(size = [1, 1, 1], center = false);
+				}
+			}
+			group() {
+				cube(size = [1, 1, 1], center = false);
+			}
+			`,
+			opts: []Option{WithInstancing()},
+			want: primitives["cube"] + `
+float groupBlock0(in vec3 xyz) {
+	return cube(vec3(1, 1, 1), false, xyz);
+}
+
+void mainModel4(out vec4 materials, in vec3 xyz) {
+	materials[0] = groupBlock0((vec4(xyz, 1.0) * mat4(vec4(1, 0, 0, -2), vec4(0, 1, 0, 0), vec4(0, 0, 1, 0), vec4(0, 0, 0, 1))).xyz) + groupBlock0(xyz);
+}
+`,
+		},
+		{
+			program: `circle(r = 2);`,
+			opts:    []Option{WithPreviewThickness(1)},
+			want: primitives["circle"] + "\n" + primitives["linearExtrudeLayer"] + "\n" + primitives["linearExtrudeSlice"] + `
+float linearExtrudeBlock0(in vec3 xyz) {
+	float t = (xyz.z - float(-0.5)) / float(1);
+	if (t < 0.0 || t > 1.0) { return 0.0; }
+	mat2 m = linearExtrudeSlice(float(0), vec2(1, 1), float(1), t);
+	if (determinant(m) == 0.0) { return 0.0; }
+	xyz.xy = inverse(m) * xyz.xy;
+	return circle(float(2), xyz);
+}
+
+void mainModel4(out vec4 materials, in vec3 xyz) {
+	materials[0] = linearExtrudeBlock0(xyz);
+}
 `,
 		},
 	}
@@ -58,7 +116,10 @@ void mainModel4(out vec4 materials, in vec3 xyz) {
 				t.Fatalf("ParseProgram: %v", strings.Join(errs, "\n"))
 			}
 
-			shader := New(program, tt.center)
+			shader, err := New(program, tt.center, tt.opts...)
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
 
 			if got := shader.String(); got != tt.want {
 				t.Errorf("shader.String =\n%v\nwant:\n%v", got, tt.want)
@@ -66,3 +127,131 @@ void mainModel4(out vec4 materials, in vec3 xyz) {
 		})
 	}
 }
+
+func TestNew_Errors(t *testing.T) {
+	tests := []struct {
+		program string
+		want    string
+	}{
+		{
+			program: `union() { circle(r = 1); cube(size = 1); }`,
+			want:    "union: mixing 2D and 3D objects is not supported",
+		},
+		{
+			program: `multmatrix([[0, 0, 0, 0], [0, 1, 0, 0], [0, 0, 1, 0], [0, 0, 0, 1]]) { cube(size = 1); }`,
+			want:    "multmatrix: singular 4x4 matrix",
+		},
+		{
+			program: `rotate_extrude() { square(size = 2, center = true); }`,
+			want:    "rotate_extrude: all points must have the same X coordinate sign",
+		},
+		{
+			program: `multmatrix() { cube(size = 1); }`,
+			want:    "multmatrix: expected a 4x4 matrix, got 0 rows",
+		},
+		{
+			program: `multmatrix([[1, 0, 0, 0], [0, 1, 0, 0], [0, 0, 1, 0], [0, 0, 0, 1]], [[1, 0, 0, 0], [0, 1, 0, 0], [0, 0, 1, 0], [0, 0, 0, 1]]) { cube(size = 1); }`,
+			want:    "multmatrix: expected a 4x4 matrix, got 8 rows",
+		},
+		{
+			program: `resize([2, 0, 0]);`,
+			want:    "resize: unknown bounding box of its children",
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test #%v", i), func(t *testing.T) {
+			le := lexer.New(tt.program)
+			p := parser.New(le)
+			program := p.ParseProgram()
+			if errs := p.Errors(); len(errs) != 0 {
+				t.Fatalf("ParseProgram: %v", strings.Join(errs, "\n"))
+			}
+
+			_, err := New(program, false)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("New = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestNew_Unsupported(t *testing.T) {
+	program := parser.New(lexer.New(`cube(size = 1); foo(); linear_extrude(height = 1) { offset(r = 1) { square(size = 1); } }`)).ParseProgram()
+	shader, err := New(program, false)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if got, want := strings.Join(shader.Unsupported, ","), "foo,offset"; got != want {
+		t.Errorf("Unsupported = %v, want %v", got, want)
+	}
+	if len(shader.Warnings) != 2 {
+		t.Errorf("Warnings = %q, want 2 warnings", shader.Warnings)
+	}
+}
+
+func TestNew_Placeholder(t *testing.T) {
+	program := parser.New(lexer.New(`polyhedron();`)).ParseProgram()
+	shader, err := New(program, true)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if shader.MBB != nil {
+		t.Errorf("MBB = %+v, want nil", shader.MBB)
+	}
+}
+
+func TestNew_PreviewThickness(t *testing.T) {
+	program := parser.New(lexer.New(`circle(r = 2); cube(size = 1);`)).ParseProgram()
+	if _, err := New(program, false); err == nil || !strings.Contains(err.Error(), "top level: mixing 2D and 3D objects") {
+		t.Errorf("New = %v, want a top-level mixing error", err)
+	}
+
+	shader, err := New(program, false, WithPreviewThickness(1))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if got, want := shader.Functions[len(shader.Functions)-1], fmt.Sprintf(mainBodyFmt, "linearExtrudeBlock0(xyz) + cube(vec3(1), false, xyz)"); got != want {
+		t.Errorf("main = %v, want %v", got, want)
+	}
+	if got := strings.Join(shader.Warnings, "\n"); !strings.Contains(got, "extruded to the preview thickness") {
+		t.Errorf("Warnings = %q, want a preview thickness warning", got)
+	}
+	if shader.MBB == nil || shader.MBB.ZMin != -0.5 || shader.MBB.ZMax != 1 {
+		t.Errorf("MBB = %+v, want Z from -0.5 to 1", shader.MBB)
+	}
+
+	program = parser.New(lexer.New(`union() { circle(r = 2); cube(size = 1); }`)).ParseProgram()
+	if _, err := New(program, false, WithPreviewThickness(1)); err == nil || !strings.Contains(err.Error(), "union: mixing 2D and 3D objects") {
+		t.Errorf("New = %v, want a union mixing error", err)
+	}
+}
+
+func TestNew_Resize(t *testing.T) {
+	program := parser.New(lexer.New(`resize([2, 0, 0]) { cube(size = 1); foo(); }`)).ParseProgram()
+	shader, err := New(program, false)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if got, want := strings.Join(shader.Unsupported, ","), "foo"; got != want {
+		t.Errorf("Unsupported = %v, want %v", got, want)
+	}
+	if len(shader.Warnings) != 1 {
+		t.Errorf("Warnings = %q, want 1 warning", shader.Warnings)
+	}
+	for i, f := range shader.Functions {
+		for _, g := range shader.Functions[i+1:] {
+			if f == g {
+				t.Errorf("duplicate function:\n%v", f)
+			}
+		}
+	}
+
+	program = parser.New(lexer.New(`resize([2, 2]) { polygon(points = [[0, 0], [0, 1], [0, 2]]); }`)).ParseProgram()
+	if shader, err = New(program, false); err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if got := strings.Join(shader.Warnings, "\n"); !strings.Contains(got, "resize: the children have no size along x") {
+		t.Errorf("Warnings = %q, want a resize warning", got)
+	}
+}
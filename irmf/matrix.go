@@ -2,39 +2,33 @@ package irmf
 
 import (
 	"fmt"
-	"log"
 	"strings"
 )
 
 func matrixMult(mbb *MBB, vec0, vec1, vec2, vec3 []float64) *MBB {
-	mult := func(x, y, z float64) (float64, float64, float64) {
-		a := x*vec0[0] + y*vec0[1] + z*vec0[2] + vec0[3]
-		b := x*vec1[0] + y*vec1[1] + z*vec1[2] + vec1[3]
-		c := x*vec2[0] + y*vec2[1] + z*vec2[2] + vec2[3]
-		return a, b, c
+	mult := func(x, y, z, w float64) [3]float64 {
+		a := x*vec0[0] + y*vec0[1] + z*vec0[2] + w*vec0[3]
+		b := x*vec1[0] + y*vec1[1] + z*vec1[2] + w*vec1[3]
+		c := x*vec2[0] + y*vec2[1] + z*vec2[2] + w*vec2[3]
+		return [3]float64{a, b, c}
 	}
-	xmin, ymin, zmin := mult(mbb.XMin, mbb.YMin, mbb.ZMin)
-	xmax, ymax, zmax := mult(mbb.XMax, mbb.YMax, mbb.ZMax)
-	if xmin > xmax {
-		xmin, xmax = xmax, xmin
-	}
-	if ymin > ymax {
-		ymin, ymax = ymax, ymin
-	}
-	if zmin > zmax {
-		zmin, zmax = zmax, zmin
-	}
-	return &MBB{
-		XMin: xmin,
-		YMin: ymin,
-		ZMin: zmin,
-		XMax: xmax,
-		YMax: ymax,
-		ZMax: zmax,
+	// Transform the pieces (or all 8 corners) rather than the box,
+	// since a rotation can move any corner to the outside of the new box.
+	var pieces []piece
+	for _, p := range mbb.pieces() {
+		q := piece{c: mult(p.c[0], p.c[1], p.c[2], 1)}
+		for _, v := range p.a {
+			q.a = append(q.a, mult(v[0], v[1], v[2], 0))
+		}
+		pieces = append(pieces, q)
 	}
+	result := hullMBB(pieces)
+	result.partial = mbb.partial
+	result.flat = mbb.flat
+	return result
 }
 
-func matrixInverse(vec0, vec1, vec2, vec3 []float64) (inv0, inv1, inv2, inv3 []float64) {
+func matrixInverse(vec0, vec1, vec2, vec3 []float64) (inv0, inv1, inv2, inv3 []float64, err error) {
 	f := func(v0, v1, v2 []float64) []float64 {
 		return []float64{
 			v0[1]*v1[2]*v2[3] + v1[1]*v2[2]*v0[3] + v2[1]*v0[2]*v1[3] - v2[1]*v1[2]*v0[3] - v1[1]*v0[2]*v2[3] - v0[1]*v2[2]*v1[3],
@@ -58,7 +52,7 @@ func matrixInverse(vec0, vec1, vec2, vec3 []float64) (inv0, inv1, inv2, inv3 []f
 
 	det := vec0[0]*inv0[0] + vec1[0]*inv1[0] + vec2[0]*inv2[0] + vec3[0]*inv3[0]
 	if det == 0 {
-		log.Fatalf("Got singular 4x4 matrix with determinant 0:\n%+v\n%+v\n%+v\n%+v", vec0, vec1, vec2, vec3)
+		return nil, nil, nil, nil, fmt.Errorf("singular 4x4 matrix with determinant 0: %v %v %v %v", vec0, vec1, vec2, vec3)
 	}
 
 	// Matrix of Adjoints:
@@ -73,7 +67,7 @@ func matrixInverse(vec0, vec1, vec2, vec3 []float64) (inv0, inv1, inv2, inv3 []f
 	inv2 = []float64{det * inv2[0], det * inv2[1], det * inv2[2], det * inv2[3]}
 	inv3 = []float64{det * inv3[0], det * inv3[1], det * inv3[2], det * inv3[3]}
 
-	return inv0, inv1, inv2, inv3
+	return inv0, inv1, inv2, inv3, nil
 }
 
 func vs(vec []float64) string {
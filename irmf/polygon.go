@@ -2,50 +2,17 @@ package irmf
 
 import (
 	"fmt"
-	"log"
 	"sort"
 	"strings"
 
 	"github.com/gmlewis/go-csg/ast"
-	"github.com/gmlewis/go-csg/evaluator"
-	"github.com/gmlewis/go-csg/object"
+	"github.com/gmlewis/go-csg/params"
 )
 
 func (s *Shader) processPolygonPrimitive(exps []ast.Expression) (string, *MBB) {
-	var points, paths *object.Array
-
-	for _, exp := range exps {
-		switch exp := exp.(type) {
-		case *ast.NamedArgument:
-			switch exp.Name.String() {
-			case "points":
-				if val, ok := exp.Value.(*ast.ArrayLiteral); ok {
-					obj := evaluator.Eval(val, nil)
-					if v, ok := obj.(*object.Array); ok {
-						points = v
-					} else {
-						log.Fatalf("polygon unexpected points type %T (%+v)", obj, obj)
-					}
-				} else {
-					log.Fatalf("polygon unexpected points type %T (%+v)", exp.Value, exp.Value)
-				}
-			case "paths":
-				if val, ok := exp.Value.(*ast.ArrayLiteral); ok {
-					obj := evaluator.Eval(val, nil)
-					if v, ok := obj.(*object.Array); ok {
-						paths = v
-					} else {
-						log.Fatalf("polygon unexpected paths type %T (%+v)", obj, obj)
-					}
-				}
-			}
-		default:
-			log.Fatalf("exp=%T (%+v)", exp, exp)
-		}
-	}
-
-	if points == nil {
-		log.Fatalf("missing polygon points")
+	points, paths, err := params.Polygon(exps)
+	if err != nil {
+		s.errorf("polygon: %v", err)
 	}
 
 	if paths == nil {
@@ -61,50 +28,19 @@ type ptT struct {
 	x, y float64
 }
 
-func getPT(array *object.Array) ptT {
-	if len(array.Elements) != 2 {
-		log.Fatalf("polygon expected 2 elements per point: %+v", array)
-	}
-	result := ptT{}
-
-	switch x := array.Elements[0].(type) {
-	case *object.Float:
-		result.x = x.Value
-	case *object.Integer:
-		result.x = float64(x.Value)
-	default:
-		log.Fatalf("polygon unexpected point x type %T %+v", x, x)
-	}
-
-	switch y := array.Elements[1].(type) {
-	case *object.Float:
-		result.y = y.Value
-	case *object.Integer:
-		result.y = float64(y.Value)
-	default:
-		log.Fatalf("polygon unexpected point y type %T %+v", y, y)
-	}
-
-	return result
-}
-
-func (s *Shader) processSimplePolygonPrimitive(points *object.Array) (string, *MBB) {
+func (s *Shader) processSimplePolygonPrimitive(points []params.Point) (string, *MBB) {
 	var xvals, yvals []float64
 	var pts []ptT
-	for _, el := range points.Elements {
-		switch el := el.(type) {
-		case *object.Array:
-			pt := getPT(el)
-			pts = append(pts, pt)
-			xvals = append(xvals, pt.x)
-			yvals = append(yvals, pt.y)
-		default:
-			log.Fatalf("polygon unexpected element type %T (%+v)", el, el)
-		}
+	var hull []piece
+	for _, pt := range points {
+		pts = append(pts, ptT{x: pt.X, y: pt.Y})
+		hull = append(hull, point(pt.X, pt.Y, 0))
+		xvals = append(xvals, pt.X)
+		yvals = append(yvals, pt.Y)
 	}
 
 	if len(xvals) < 3 || len(yvals) < 3 {
-		log.Fatalf("polygon expected to have a least 3 points")
+		s.errorf("polygon expected to have a least 3 points")
 	}
 	sort.Float64s(xvals)
 	sort.Float64s(yvals)
@@ -113,36 +49,42 @@ func (s *Shader) processSimplePolygonPrimitive(points *object.Array) (string, *M
 	xmax := xvals[len(xvals)-1]
 	ymin := yvals[0]
 	ymax := yvals[len(yvals)-1]
-	mbb := &MBB{XMin: xmin, YMin: ymin, XMax: xmax, YMax: ymax}
-
-	fNum := len(s.Functions)
-	fName := fmt.Sprintf("simplePolygon%v", fNum)
+	mbb := &MBB{XMin: xmin, YMin: ymin, XMax: xmax, YMax: ymax, flat: true}
+	if len(hull) <= maxPieces {
+		mbb.hull = hull
+	}
 
 	s.Primitives["testTwoLineSegments"] = true
 
-	lines := processSimplePolygonSegments(pts, yvals)
+	lines, err := processSimplePolygonSegments(pts, yvals)
+	if err != nil {
+		s.errorf("polygon: %v", err)
+	}
 
-	newFunc := fmt.Sprintf(`float %v(in vec3 xyz) {
+	fName := s.addFunction("simplePolygon", `float %v(in vec3 xyz) {
 	if (any(lessThan(xyz.xy, vec2(%v,%v))) || any(greaterThan(xyz.xy, vec2(%v,%v)))) { return 0.0; }
 	%v
 	return 1.0;
 }
-`, fName, xmin, ymin, xmax, ymax, strings.Join(lines, "\n\t"))
-	s.Functions = append(s.Functions, newFunc)
+`, xmin, ymin, xmax, ymax, strings.Join(lines, "\n\t"))
 
 	return fmt.Sprintf("%v(xyz)", fName), mbb
 }
 
-func processSimplePolygonSegments(pts []ptT, yvals []float64) []string {
+func processSimplePolygonSegments(pts []ptT, yvals []float64) ([]string, error) {
 	var result []string
 
 	for i := 0; i < len(yvals)-1; i++ {
-		if line := processSegment(yvals[i], yvals[i+1], pts); line != "" {
+		line, err := processSegment(yvals[i], yvals[i+1], pts)
+		if err != nil {
+			return nil, err
+		}
+		if line != "" {
 			result = append(result, line)
 		}
 	}
 
-	return result
+	return result, nil
 }
 
 type segT struct {
@@ -150,7 +92,7 @@ type segT struct {
 	uy ptT
 }
 
-func processSegment(ly, uy float64, pts []ptT) string {
+func processSegment(ly, uy float64, pts []ptT) (string, error) {
 	var leftSeg *segT
 	var rightSeg *segT
 
@@ -177,17 +119,17 @@ func processSegment(ly, uy float64, pts []ptT) string {
 				leftSeg, rightSeg = rightSeg, leftSeg
 			}
 		} else {
-			log.Fatalf("concave polygon not yet supported: pts=%+v", pts)
+			return "", fmt.Errorf("concave polygon not yet supported: pts=%+v", pts)
 		}
 	}
 
 	if leftSeg == nil || rightSeg == nil {
-		return ""
+		return "", nil
 	}
 
 	return fmt.Sprintf("if (xyz.y >= float(%v) && xyz.y <= float(%v)) { return testTwoLineSegments(vec2(%v,%v),vec2(%v,%v),vec2(%v,%v),vec2(%v,%v),xyz.xy); }",
 		ly, uy,
 		leftSeg.ly.x, leftSeg.ly.y, leftSeg.uy.x, leftSeg.uy.y,
 		rightSeg.ly.x, rightSeg.ly.y, rightSeg.uy.x, rightSeg.uy.y,
-	)
+	), nil
 }
@@ -41,7 +41,10 @@ func TestProcessSimplyPolygonPrimitive(t *testing.T) {
 				t.Fatalf("ParseProgram: %v", strings.Join(errs, "\n"))
 			}
 
-			shader := New(program, tt.center)
+			shader, err := New(program, tt.center)
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
 			if !reflect.DeepEqual(shader.Functions, tt.want) {
 				t.Errorf("functions = %#v, want %#v", shader.Functions, tt.want)
 			}
@@ -0,0 +1,60 @@
+package irmf
+
+import (
+	"strconv"
+
+	"github.com/gmlewis/go-csg/ast"
+	"github.com/gmlewis/go-csg/dims"
+	"github.com/gmlewis/go-csg/token"
+)
+
+// topLevel returns the top-level statements of the program, where
+// each 2D object is extruded to the preview thickness (if any).
+// Otherwise, the 2D objects remain infinite prisms along Z.
+func (s *Shader) topLevel(program *ast.Program, info *dims.Info) []ast.Statement {
+	result := make([]ast.Statement, 0, len(program.Statements))
+	var warned bool
+	for _, stmt := range program.Statements {
+		if info.Of(stmt) != dims.TwoD {
+			result = append(result, stmt)
+			continue
+		}
+		if s.previewThickness <= 0 {
+			if !warned {
+				s.warnf("top-level 2D objects extend infinitely along Z; use a preview thickness to render them like OpenSCAD.")
+				warned = true
+			}
+			result = append(result, stmt)
+			continue
+		}
+		result = append(result, &ast.ExpressionStatement{
+			Token:      token.Token{Type: token.LINEAR_EXTRUDE, Literal: "linear_extrude"},
+			Expression: previewExtrude(s.previewThickness, stmt),
+		})
+	}
+	return result
+}
+
+// previewExtrude returns the linear_extrude block that gives the 2D
+// object of stmt the thickness h, centered on Z=0 like OpenSCAD's preview.
+func previewExtrude(h float64, stmt ast.Statement) *ast.LinearExtrudeBlockPrimitive {
+	named := func(name string, value ast.Expression) ast.Expression {
+		return &ast.NamedArgument{
+			Token: token.Token{Type: token.ASSIGN, Literal: "="},
+			Name:  &ast.Identifier{Token: token.Token{Type: token.IDENT, Literal: name}, Value: name},
+			Value: value,
+		}
+	}
+	literal := strconv.FormatFloat(h, 'f', -1, 64)
+	return &ast.LinearExtrudeBlockPrimitive{
+		Token: token.Token{Type: token.LINEAR_EXTRUDE, Literal: "linear_extrude"},
+		Arguments: []ast.Expression{
+			named("height", &ast.FloatLiteral{Token: token.Token{Type: token.FLOAT, Literal: literal}, Value: h}),
+			named("center", &ast.BooleanLiteral{Token: token.Token{Type: token.TRUE, Literal: "true"}, Value: true}),
+		},
+		Body: &ast.BlockStatement{
+			Token:      token.Token{Type: token.LBRACE, Literal: "{"},
+			Statements: []ast.Statement{stmt},
+		},
+	}
+}
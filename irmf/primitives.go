@@ -2,12 +2,12 @@ package irmf
 
 import (
 	"fmt"
-	"log"
 	"math"
 	"strconv"
 	"strings"
 
 	"github.com/gmlewis/go-csg/ast"
+	"github.com/gmlewis/go-csg/params"
 )
 
 var primitives = map[string]string{
@@ -34,6 +34,42 @@ var primitives = map[string]string{
 	float radius = mix(r1, r2, z);
 	return r <= radius ? 1.0 : 0.0;
 }
+`,
+
+	"facet": `float facet(in float radius, in float n, in vec2 xy) {
+	float sector = 2.0*3.1415926535897932384626433832795/n;
+	float a = mod(atan(xy.y, xy.x), sector) - 0.5*sector;
+	return length(xy)*cos(a) <= radius*cos(0.5*sector) ? 1.0 : 0.0;
+}
+`,
+
+	"facetedCircle": `float facetedCircle(in float radius, in float n, in vec3 xyz) {
+	return facet(radius, n, xyz.xy);
+}
+`,
+
+	"facetedCylinder": `float facetedCylinder(in float h, in float r1, in float r2, in bool center, in float n, in vec3 xyz) {
+	xyz.z /= h;
+	float z = xyz.z;
+	if (center) { z += 0.5; } else { xyz.z -= 0.5; }
+	if (abs(xyz.z) > 0.5) { return 0.0; }
+	return facet(mix(r1, r2, z), n, xyz.xy);
+}
+`,
+
+	"facetedSphere": `float facetedSphere(in float radius, in float n, in vec3 xyz) {
+	float pi = 3.1415926535897932384626433832795;
+	float rings = floor(0.5*(n + 1.0));
+	float ztop = radius*cos(0.5*pi/rings);
+	if (abs(xyz.z) > ztop) { return 0.0; }
+	float i = clamp(floor(acos(clamp(xyz.z/radius, -1.0, 1.0))*rings/pi - 0.5), 0.0, rings - 2.0);
+	float phi0 = (i + 0.5)*pi/rings;
+	float phi1 = (i + 1.5)*pi/rings;
+	float z0 = radius*cos(phi0);
+	float z1 = radius*cos(phi1);
+	float r = mix(radius*sin(phi0), radius*sin(phi1), (z0 - xyz.z)/(z0 - z1));
+	return facet(r, n, xyz.xy);
+}
 `,
 
 	"polygon": `float polygon(in vec3 xyz) {
@@ -48,20 +84,17 @@ var primitives = map[string]string{
 }
 `,
 
-	"rotAxis": `mat3 rotAxis(vec3 axis, float a) {
-  // This is from: http://www.neilmendoza.com/glsl-rotation-about-an-arbitrary-axis/
-  float s = sin(a);
-  float c = cos(a);
-  float oc = 1.0 - c;
-  vec3 as = axis * s;
-  mat3 p = mat3(axis.x * axis, axis.y * axis, axis.z * axis);
-  mat3 q = mat3(c, - as.z, as.y, as.z, c, - as.x, - as.y, as.x, c);
-  return p * oc + q;
+	"linearExtrudeLayer": `mat2 linearExtrudeLayer(in float twist, in vec2 scale, in float t) {
+	float a = radians(-twist*t);
+	vec2 s = mix(vec2(1), scale, t);
+	return mat2(s.x*cos(a), s.y*sin(a), -s.x*sin(a), s.y*cos(a));
 }
 `,
 
-	"rotZ": `mat4 rotZ(float angle) {
-  return mat4(rotAxis(vec3(0, 0, 1), angle));
+	"linearExtrudeSlice": `mat2 linearExtrudeSlice(in float twist, in vec2 scale, in float slices, in float t) {
+	float k = min(floor(t*slices), slices - 1.0);
+	float u = t*slices - k;
+	return linearExtrudeLayer(twist, scale, k/slices)*(1.0-u) + linearExtrudeLayer(twist, scale, (k+1.0)/slices)*u;
 }
 `,
 
@@ -100,7 +133,7 @@ func (s *Shader) getArgs(exps []ast.Expression, names ...string) []string {
 			result[count] = exp.String()
 			count++
 		default:
-			log.Fatalf("getArgs: unhandled type %T (%+v)", exp, exp)
+			s.errorf("getArgs: unhandled type %T (%+v)", exp, exp)
 		}
 	}
 
@@ -119,7 +152,7 @@ func (s *Shader) getMat4Args(exps []ast.Expression) []string {
 		case *ast.ArrayLiteral:
 			// Split up array into 4 expressions (each arrays).
 			if len(exp.Elements) != 4 {
-				log.Fatalf("getMat4Args: unhandled elements!=4 %T (%+v)", exp, exp)
+				s.errorf("getMat4Args: unhandled elements!=4 %T (%+v)", exp, exp)
 			}
 			for _, e := range exp.Elements {
 				switch e := e.(type) {
@@ -129,11 +162,11 @@ func (s *Shader) getMat4Args(exps []ast.Expression) []string {
 					val = strings.ReplaceAll(val, ")", "")
 					result = append(result, val)
 				default:
-					log.Fatalf("getMat4Args: unhandled element type %T (%+v)", e, e)
+					s.errorf("getMat4Args: unhandled element type %T (%+v)", e, e)
 				}
 			}
 		default:
-			log.Fatalf("getMat4Args: unhandled type %T (%+v)", exp, exp)
+			s.errorf("getMat4Args: unhandled type %T (%+v)", exp, exp)
 		}
 	}
 	return result
@@ -246,11 +279,16 @@ func (s *Shader) processCubePrimitive(exps []ast.Expression) (string, *MBB) {
 
 	vec3, err := parseVec3(size)
 	if err != nil {
-		log.Printf("error parsing cube size=%q, setting to 1", size)
+		s.warnf("error parsing cube size=%q, setting to 1", size)
 		size = "1"
 		vec3 = []float64{1, 1, 1}
 	}
 
+	if vec3[0] <= 0 || vec3[1] <= 0 || vec3[2] <= 0 {
+		s.removef("removed zero-size cube(size = [%v])", size)
+		return "", nil
+	}
+
 	var mbb *MBB
 
 	center := args[1]
@@ -265,7 +303,6 @@ func (s *Shader) processCubePrimitive(exps []ast.Expression) (string, *MBB) {
 }
 
 func (s *Shader) processSpherePrimitive(exps []ast.Expression) (string, *MBB) {
-	s.Primitives["sphere"] = true
 	args := s.getArgs(exps, "r", "d")
 
 	radius := args[0]
@@ -281,18 +318,29 @@ func (s *Shader) processSpherePrimitive(exps []ast.Expression) (string, *MBB) {
 
 	vec3, err := parseVec3(radius)
 	if err != nil {
-		log.Printf("error parsing sphere radius=%q, setting to 1", radius)
+		s.warnf("error parsing sphere radius=%q, setting to 1", radius)
 		radius = "1"
 		vec3 = []float64{1, 1, 1}
 	}
 
-	mbb := &MBB{XMin: -vec3[0], YMin: -vec3[1], ZMin: -vec3[2], XMax: vec3[0], YMax: vec3[1], ZMax: vec3[2]}
+	if vec3[0] <= 0 {
+		s.removef("removed zero-size sphere(r = %v)", radius)
+		return "", nil
+	}
+
+	mbb := &MBB{XMin: -vec3[0], YMin: -vec3[1], ZMin: -vec3[2], XMax: vec3[0], YMax: vec3[1], ZMax: vec3[2], hull: []piece{ball(vec3[0])}}
+
+	if n := s.circleFragments(exps); n > 0 {
+		s.Primitives["facet"] = true
+		s.Primitives["facetedSphere"] = true
+		return fmt.Sprintf("facetedSphere(float(%v), %v.0, xyz)", radius, n), mbb
+	}
 
+	s.Primitives["sphere"] = true
 	return fmt.Sprintf("sphere(float(%v), xyz)", radius), mbb
 }
 
 func (s *Shader) processCylinderPrimitive(exps []ast.Expression) (string, *MBB) {
-	s.Primitives["cylinder"] = true
 	args := s.getArgs(exps, "h", "r1", "r2", "center", "r", "d", "d1", "d2")
 
 	h := args[0]
@@ -340,7 +388,7 @@ func (s *Shader) processCylinderPrimitive(exps []ast.Expression) (string, *MBB)
 	params := fmt.Sprintf("%v,%v,%v", h, r1, r2)
 	vec3, err := parseVec3(params)
 	if err != nil {
-		log.Printf("error parsing cylinder params %q, setting to 1", params)
+		s.warnf("error parsing cylinder params %q, setting to 1", params)
 		vec3 = []float64{1, 1, 1}
 	}
 
@@ -348,6 +396,10 @@ func (s *Shader) processCylinderPrimitive(exps []ast.Expression) (string, *MBB)
 	if vec3[2] > radius {
 		radius = vec3[2]
 	}
+	if vec3[0] <= 0 || radius <= 0 {
+		s.removef("removed zero-size cylinder(h = %v, r1 = %v, r2 = %v)", h, r1, r2)
+		return "", nil
+	}
 
 	mbb := &MBB{XMin: -radius, YMin: -radius, ZMin: -0.5 * vec3[0], XMax: radius, YMax: radius, ZMax: 0.5 * vec3[0]}
 	if center != "true" {
@@ -355,7 +407,15 @@ func (s *Shader) processCylinderPrimitive(exps []ast.Expression) (string, *MBB)
 		mbb.ZMin = 0
 		mbb.ZMax = vec3[0]
 	}
+	mbb.hull = []piece{disc(0, 0, mbb.ZMin, vec3[1]), disc(0, 0, mbb.ZMax, vec3[2])}
 
+	if n := s.cylinderFragments(exps); n > 0 {
+		s.Primitives["facet"] = true
+		s.Primitives["facetedCylinder"] = true
+		return fmt.Sprintf("facetedCylinder(float(%v), float(%v), float(%v), %v, %v.0, xyz)", h, r1, r2, center, n), mbb
+	}
+
+	s.Primitives["cylinder"] = true
 	return fmt.Sprintf("cylinder(float(%v), float(%v), float(%v), %v, xyz)", h, r1, r2, center), mbb
 }
 
@@ -370,26 +430,30 @@ func (s *Shader) processSquarePrimitive(exps []ast.Expression) (string, *MBB) {
 
 	vec2, err := parseVec2(size)
 	if err != nil {
-		log.Printf("error parsing square size=%q, setting to 1", size)
+		s.warnf("error parsing square size=%q, setting to 1", size)
 		size = "1"
 		vec2 = []float64{1, 1}
 	}
 
+	if vec2[0] <= 0 || vec2[1] <= 0 {
+		s.removef("removed zero-size square(size = [%v])", size)
+		return "", nil
+	}
+
 	var mbb *MBB
 
 	center := args[1]
 	if center == "true" {
-		mbb = &MBB{XMin: -0.5 * vec2[0], YMin: -0.5 * vec2[1], XMax: 0.5 * vec2[0], YMax: 0.5 * vec2[1]}
+		mbb = &MBB{XMin: -0.5 * vec2[0], YMin: -0.5 * vec2[1], XMax: 0.5 * vec2[0], YMax: 0.5 * vec2[1], flat: true}
 	} else {
 		center = "false"
-		mbb = &MBB{XMax: vec2[0], YMax: vec2[1]}
+		mbb = &MBB{XMax: vec2[0], YMax: vec2[1], flat: true}
 	}
 
 	return fmt.Sprintf("square(vec2(%v), %v, xyz)", size, center), mbb
 }
 
 func (s *Shader) processCirclePrimitive(exps []ast.Expression) (string, *MBB) {
-	s.Primitives["circle"] = true
 	args := s.getArgs(exps, "r", "d")
 
 	radius := args[0]
@@ -405,13 +469,25 @@ func (s *Shader) processCirclePrimitive(exps []ast.Expression) (string, *MBB) {
 
 	vec3, err := parseVec3(radius)
 	if err != nil {
-		log.Printf("error parsing circle radius=%q, setting to 1", radius)
+		s.warnf("error parsing circle radius=%q, setting to 1", radius)
 		radius = "1"
 		vec3 = []float64{1, 1, 1}
 	}
 
-	mbb := &MBB{XMin: -vec3[0], YMin: -vec3[1], XMax: vec3[0], YMax: vec3[1]}
+	if vec3[0] <= 0 {
+		s.removef("removed zero-size circle(r = %v)", radius)
+		return "", nil
+	}
 
+	mbb := &MBB{XMin: -vec3[0], YMin: -vec3[1], XMax: vec3[0], YMax: vec3[1], hull: []piece{disc(0, 0, 0, vec3[0])}, flat: true}
+
+	if n := s.circleFragments(exps); n > 0 {
+		s.Primitives["facet"] = true
+		s.Primitives["facetedCircle"] = true
+		return fmt.Sprintf("facetedCircle(float(%v), %v.0, xyz)", radius, n), mbb
+	}
+
+	s.Primitives["circle"] = true
 	return fmt.Sprintf("circle(float(%v), xyz)", radius), mbb
 }
 
@@ -421,38 +497,49 @@ func (s *Shader) processMultmatrixBlockPrimitive(args []ast.Expression, exps []a
 		return "", nil
 	}
 
-	fNum := len(s.Functions)
-	fName := fmt.Sprintf("multimatrixBlock%v", fNum)
 	vec4s := s.getMat4Args(args)
+	if len(vec4s) != 4 {
+		s.errorf("multmatrix: expected a 4x4 matrix, got %v rows", len(vec4s))
+		return "", nil
+	}
 
 	vec0, err := parseVec4(vec4s[0])
 	if err != nil {
-		log.Fatalf("vec0: %v", err)
+		s.errorf("multmatrix: vec0: %v", err)
 	}
 	vec1, err := parseVec4(vec4s[1])
 	if err != nil {
-		log.Fatalf("vec1: %v", err)
+		s.errorf("multmatrix: vec1: %v", err)
 	}
 	vec2, err := parseVec4(vec4s[2])
 	if err != nil {
-		log.Fatalf("vec2: %v", err)
+		s.errorf("multmatrix: vec2: %v", err)
 	}
 	vec3, err := parseVec4(vec4s[3])
 	if err != nil {
-		log.Fatalf("vec3: %v", err)
+		s.errorf("multmatrix: vec3: %v", err)
+	}
+
+	inv0, inv1, inv2, inv3, err := matrixInverse(vec0, vec1, vec2, vec3)
+	if err != nil {
+		s.errorf("multmatrix: %v", err)
 	}
 
-	inv0, inv1, inv2, inv3 := matrixInverse(vec0, vec1, vec2, vec3)
+	newMBB := matrixMult(mbb, vec0, vec1, vec2, vec3)
+	xfm := fmt.Sprintf("mat4(vec4(%v), vec4(%v), vec4(%v), vec4(%v))", vs(inv0), vs(inv1), vs(inv2), vs(inv3))
+
+	// An instance calls its (shared) child with transformed coordinates
+	// instead of wrapping it in a new function.
+	if s.instancing && len(calls) == 1 && strings.Count(calls[0], "xyz") == 1 {
+		return strings.Replace(calls[0], "xyz", fmt.Sprintf("(vec4(xyz, 1.0) * %v).xyz", xfm), 1), newMBB
+	}
 
-	newFunc := fmt.Sprintf(`float %v(in vec3 xyz) {
-	mat4 xfm = mat4(vec4(%v), vec4(%v), vec4(%v), vec4(%v));
+	fName := s.addFunction("multimatrixBlock", `float %v(in vec3 xyz) {
+%v	mat4 xfm = %v;
 	xyz = (vec4(xyz, 1.0) * xfm).xyz;
 	return %v;
 }
-`, fName, vs(inv0), vs(inv1), vs(inv2), vs(inv3), strings.Join(calls, " + "))
-	s.Functions = append(s.Functions, newFunc)
-
-	newMBB := matrixMult(mbb, vec0, vec1, vec2, vec3)
+`, s.earlyOut(newMBB), xfm, strings.Join(calls, " + "))
 
 	return fmt.Sprintf("%v(xyz)", fName), newMBB
 }
@@ -463,47 +550,88 @@ func (s *Shader) processUnionBlockPrimitive(exps []ast.Statement) (string, *MBB)
 		return "", nil
 	}
 
-	fNum := len(s.Functions)
-	fName := fmt.Sprintf("union%v", fNum)
-	newFunc := fmt.Sprintf(`float %v(in vec3 xyz) {
-	return clamp(%v, 0.0, 1.0);
+	fName := s.addFunction("union", `float %v(in vec3 xyz) {
+%v	return clamp(%v, 0.0, 1.0);
 }
-`, fName, strings.Join(calls, " + "))
-	s.Functions = append(s.Functions, newFunc)
+`, s.earlyOut(mbb), strings.Join(calls, " + "))
 
 	return fmt.Sprintf("%v(xyz)", fName), mbb
 }
 
 func (s *Shader) processDifferenceBlockPrimitive(exps []ast.Statement) (string, *MBB) {
-	calls, mbb := s.getCalls(exps)
-	if len(calls) == 0 || mbb == nil {
+	children := s.getChildren(exps)
+	if len(children) == 0 {
+		return "", nil
+	}
+	minuend := children[0]
+	if minuend.call == "" {
+		if len(children) > 1 {
+			s.removef("removed difference with an empty minuend")
+		}
 		return "", nil
 	}
 
-	fNum := len(s.Functions)
-	fName := fmt.Sprintf("difference%v", fNum)
-	newFunc := fmt.Sprintf(`float %v(in vec3 xyz) {
-	return clamp(%v, 0.0, 1.0);
+	calls := []string{minuend.call}
+	mbb := minuend.mbb
+	for _, c := range children[1:] {
+		if c.call == "" {
+			continue
+		}
+		if known(minuend.mbb) && known(c.mbb) && !minuend.mbb.overlaps(c.mbb) {
+			s.removef("removed difference subtrahend %v (outside of the minuend's MBB)", c.call)
+			continue
+		}
+		calls = append(calls, c.call)
+		if minuend.mbb == nil {
+			if mbb == nil {
+				mbb = c.mbb
+			} else {
+				mbb.update(c.mbb)
+			}
+		}
+	}
+	if mbb == nil {
+		return "", nil
+	}
+	if minuend.mbb == nil {
+		// The subtrahends do not bound the result.
+		mbb.partial = true
+	}
+
+	fName := s.addFunction("difference", `float %v(in vec3 xyz) {
+%v	return clamp(%v, 0.0, 1.0);
 }
-`, fName, strings.Join(calls, " - "))
-	s.Functions = append(s.Functions, newFunc)
+`, s.earlyOut(mbb), strings.Join(calls, " - "))
 
 	return fmt.Sprintf("%v(xyz)", fName), mbb
 }
 
 func (s *Shader) processIntersectionBlockPrimitive(exps []ast.Statement) (string, *MBB) {
-	calls, mbb := s.getCalls(exps)
+	children := s.getChildren(exps)
+	var calls []string
+	var mbb *MBB
+	for _, c := range children {
+		if c.call == "" {
+			if len(children) > 1 {
+				s.removef("removed intersection with an empty child")
+			}
+			return "", nil
+		}
+		calls = append(calls, c.call)
+		mbb = intersect(mbb, c.mbb)
+	}
 	if len(calls) == 0 || mbb == nil {
 		return "", nil
 	}
+	if known(mbb) && mbb.empty() {
+		s.removef("removed intersection of %v (the children's MBBs do not overlap)", strings.Join(calls, ", "))
+		return "", nil
+	}
 
-	fNum := len(s.Functions)
-	fName := fmt.Sprintf("intersection%v", fNum)
-	newFunc := fmt.Sprintf(`float %v(in vec3 xyz) {
-	return clamp(%v, 0.0, 1.0);
+	fName := s.addFunction("intersection", `float %v(in vec3 xyz) {
+%v	return clamp(%v, 0.0, 1.0);
 }
-`, fName, strings.Join(calls, " * "))
-	s.Functions = append(s.Functions, newFunc)
+`, s.earlyOut(mbb), strings.Join(calls, " * "))
 
 	return fmt.Sprintf("%v(xyz)", fName), mbb
 }
@@ -514,119 +642,100 @@ func (s *Shader) processLinearExtrudeBlockPrimitive(args []ast.Expression, exps
 		return "", nil
 	}
 
-	argVals := s.getArgs(args, "height", "center", "twist", "scale")
-
-	height, err := strconv.ParseFloat(argVals[0], 64)
+	le, err := params.LinearExtrude(args)
 	if err != nil {
-		log.Fatalf("unable to parse height %q: %v", argVals[0], err)
+		s.errorf("linear_extrude: %v", err)
 	}
-
-	if argVals[1] == "true" {
-		mbb.ZMin = -0.5 * height
-		mbb.ZMax = 0.5 * height
-	} else {
-		argVals[1] = "false"
-		mbb.ZMin = 0.0
-		mbb.ZMax = height
+	// The extrusion follows the vector d, starting at o.
+	d := [3]float64{le.Height * le.V[0], le.Height * le.V[1], le.Height * le.V[2]}
+	if d[2] <= 0 {
+		s.removef("removed linear_extrude with a non-positive height")
+		return "", nil
+	}
+	var o [3]float64
+	if le.Center {
+		o = [3]float64{-0.5 * d[0], -0.5 * d[1], -0.5 * d[2]}
+	}
+	slices := le.Slices
+	if le.Twist == 0 {
+		// Without a twist, every layer is a linear interpolation
+		// of the bottom and top layers.
+		slices = 1
+	}
+
+	s.Primitives["linearExtrudeLayer"] = true
+	s.Primitives["linearExtrudeSlice"] = true
+
+	// Within each slice, the profile's points move linearly between the
+	// layers, so the extrusion is within the layers' bounds.
+	var pieces []piece
+	for k := 0; k <= slices; k++ {
+		t := float64(k) / float64(slices)
+		m := linearExtrudeLayer(le.Twist, le.Scale, t)
+		for _, p := range mbb.pieces() {
+			q := piece{c: [3]float64{
+				m[0][0]*p.c[0] + m[0][1]*p.c[1] + o[0] + t*d[0],
+				m[1][0]*p.c[0] + m[1][1]*p.c[1] + o[1] + t*d[1],
+				o[2] + t*d[2],
+			}}
+			for _, v := range p.a {
+				q.a = append(q.a, [3]float64{m[0][0]*v[0] + m[0][1]*v[1], m[1][0]*v[0] + m[1][1]*v[1], 0})
+			}
+			pieces = append(pieces, q)
+		}
 	}
+	partial := mbb.partial
+	mbb = hullMBB(pieces)
+	mbb.partial = partial
 
-	argVals[2] = strings.Trim(argVals[2], "()")
-	argVals[3] = strings.Trim(argVals[3], "[]")
-	scaleVec, err := parseVec2(argVals[3])
-	if err != nil {
-		log.Fatalf("unable to parse linear_extrude scale %q: %v", argVals[3], err)
+	var shift string
+	if d[0] != 0 || d[1] != 0 {
+		shift = fmt.Sprintf("\txyz.xy -= vec2(%v) + t*vec2(%v);\n", vs(o[:2]), vs(d[:2]))
 	}
 
-	fNum := len(s.Functions)
-	fName := fmt.Sprintf("linearExtrudeBlock%v", fNum)
-	var newFunc string
-	if argVals[2] == "" { // No twist.
-		newFunc = fmt.Sprintf(`float %v(in vec3 xyz) {
-	xyz.z /= float(%v);
-	float z = xyz.z;
-	if (%v) { z += 0.5; } else { xyz.z -= 0.5; }
-	if (abs(xyz.z) > 0.5) { return 0.0; }
-	vec2 s = mix(vec2(1),vec2(%v,%v),z);
-	xyz.xy /= s;
+	fName := s.addFunction("linearExtrudeBlock", `float %v(in vec3 xyz) {
+%v	float t = (xyz.z - float(%v)) / float(%v);
+	if (t < 0.0 || t > 1.0) { return 0.0; }
+%v	mat2 m = linearExtrudeSlice(float(%v), vec2(%v), float(%v), t);
+	if (determinant(m) == 0.0) { return 0.0; }
+	xyz.xy = inverse(m) * xyz.xy;
 	return %v;
 }
-`, fName, argVals[0], argVals[1], scaleVec[0], scaleVec[1], strings.Join(calls, " + "))
-		// Modify MBB based on scale.
-		if scaleVec[0] > 1.0 {
-			cx := 0.5 * (mbb.XMax + mbb.XMin)
-			dx := 0.5 * (mbb.XMax - mbb.XMin)
-			mbb.XMin = cx - scaleVec[0]*dx
-			mbb.XMax = cx + scaleVec[0]*dx
-		}
-		if scaleVec[1] > 1.0 {
-			cy := 0.5 * (mbb.YMax + mbb.YMin)
-			dy := 0.5 * (mbb.YMax - mbb.YMin)
-			mbb.YMin = cy - scaleVec[1]*dy
-			mbb.YMax = cy + scaleVec[1]*dy
-		}
-	} else {
-		// With twist.
-		s.Primitives["rotAxis"] = true
-		s.Primitives["rotZ"] = true
+`, s.earlyOut(mbb), o[2], d[2], shift, le.Twist, vs(le.Scale[:]), slices, strings.Join(calls, " + "))
 
-		newFunc = fmt.Sprintf(`float %v(in vec3 xyz) {
-	xyz.z /= float(%v);
-	float z = xyz.z;
-	if (%v) { z += 0.5; } else { xyz.z -= 0.5; }
-	if (abs(xyz.z) > 0.5) { return 0.0; }
-	float angle = mix(0.0, float(%v)*3.1415926535897932384626433832795/180.0, z);
-	vec2 s = mix(vec2(1),vec2(%v,%v),z);
-	xyz.xy /= s;
-	xyz = (vec4(xyz, 1) * rotZ(angle)).xyz;
-	return %v;
+	return fmt.Sprintf("%v(xyz)", fName), mbb
 }
-`, fName, argVals[0], argVals[1], argVals[2], scaleVec[0], scaleVec[1], strings.Join(calls, " + "))
-
-		// Modify MBB based on twist and scale.
-		twist, err := strconv.ParseFloat(argVals[2], 64)
-		if err != nil {
-			log.Fatalf("unable to parse twist %q: %v", argVals[2], err)
-		}
 
-		cx := 0.5 * (mbb.XMax + mbb.XMin)
-		dx := 0.5 * (mbb.XMax - mbb.XMin)
-		cy := 0.5 * (mbb.YMax + mbb.YMin)
-		dy := 0.5 * (mbb.YMax - mbb.YMin)
-
-		const vertSteps = 33
-		for t := 0.0; t <= 1.0; t += (1.0 / vertSteps) {
-			sx := lerp(1.0, scaleVec[0], t)
-			xmin := cx - sx*dx
-			xmax := cx + sx*dx
-			sy := lerp(1.0, scaleVec[1], t)
-			ymin := cy - sy*dy
-			ymax := cy + sy*dy
-			rot := lerp(0.0, -twist*math.Pi/180.0, t)
-
-			s := math.Sin(rot)
-			c := math.Cos(rot)
-
-			x1 := xmin*c - ymin*s
-			y1 := xmin*s + ymin*c
-			x2 := xmax*c - ymax*s
-			y2 := xmax*s + ymax*c
-			if x1 > x2 {
-				x1, x2 = x2, x1
+// linearExtrudeLayer returns the transform of the profile at the
+// fraction t of the height: the twist is applied before the scale.
+func linearExtrudeLayer(twist float64, scale [2]float64, t float64) [2][2]float64 {
+	sin, cos := math.Sincos(-twist * t * math.Pi / 180)
+	sx, sy := 1+(scale[0]-1)*t, 1+(scale[1]-1)*t
+	return [2][2]float64{{sx * cos, -sx * sin}, {sy * sin, sy * cos}}
+}
+
+// rotatedMBB returns the XY bounds swept by the corners of mbb
+// while rotating about the Z axis from 0 to angle (in radians).
+func rotatedMBB(mbb *MBB, angle float64) *MBB {
+	a0, a1 := math.Min(0, angle), math.Max(0, angle)
+	result := &MBB{XMin: math.Inf(1), YMin: math.Inf(1), XMax: math.Inf(-1), YMax: math.Inf(-1)}
+	for _, x := range []float64{mbb.XMin, mbb.XMax} {
+		for _, y := range []float64{mbb.YMin, mbb.YMax} {
+			r := math.Hypot(x, y)
+			phi := math.Atan2(y, x)
+			// The extremes are at the ends of the arc, and wherever
+			// the arc crosses a multiple of 90 degrees.
+			angles := []float64{phi + a0, phi + a1}
+			for k := math.Ceil((phi + a0) / (0.5 * math.Pi)); k*0.5*math.Pi <= phi+a1; k++ {
+				angles = append(angles, k*0.5*math.Pi)
 			}
-			if y1 > y2 {
-				y1, y2 = y2, y1
+			for _, a := range angles {
+				px, py := r*math.Cos(a), r*math.Sin(a)
+				result.update(&MBB{XMin: px, YMin: py, XMax: px, YMax: py})
 			}
-			m := &MBB{XMin: x1, YMin: y1, XMax: x2, YMax: y2}
-			mbb.update(m)
 		}
 	}
-	s.Functions = append(s.Functions, newFunc)
-
-	return fmt.Sprintf("%v(xyz)", fName), mbb
-}
-
-func lerp(a, b, t float64) float64 {
-	return (1.0-t)*a + t*b
+	return result
 }
 
 func (s *Shader) processRotateExtrudeBlockPrimitive(args []ast.Expression, exps []ast.Statement) (string, *MBB) {
@@ -635,38 +744,76 @@ func (s *Shader) processRotateExtrudeBlockPrimitive(args []ast.Expression, exps
 		return "", nil
 	}
 
-	s.Primitives["rotAxis"] = true
-	s.Primitives["rotZ"] = true
+	angle, err := params.RotateExtrude(args)
+	if err != nil {
+		s.errorf("rotate_extrude: %v", err)
+	}
+	if angle == 0 {
+		s.removef("removed rotate_extrude(angle = 0)")
+		return "", nil
+	}
+
+	// The profile's X is the radius and its Y is Z. Like OpenSCAD,
+	// a profile at X <= 0 is revolved as its mirror image.
+	if !mbb.partial && mbb.XMin < 0 && mbb.XMax > 0 {
+		s.errorf("rotate_extrude: all points must have the same X coordinate sign (range is %.2f -> %.2f)", mbb.XMin, mbb.XMax)
+	}
+	mirrored := mbb.XMax <= 0 && mbb.XMin < 0
+	rmax := math.Max(math.Abs(mbb.XMin), math.Abs(mbb.XMax))
+	full := angle == 360
 
-	argVals := s.getArgs(args, "angle")
+	newMBB := &MBB{XMin: -rmax, YMin: -rmax, ZMin: mbb.YMin, XMax: rmax, YMax: rmax, ZMax: mbb.YMax, partial: mbb.partial}
+	if full {
+		newMBB.hull = []piece{disc(0, 0, mbb.YMin, rmax), disc(0, 0, mbb.YMax, rmax)}
+	} else {
+		// A partial revolution sweeps the profile from the +X axis,
+		// counterclockwise for a positive angle.
+		swept := rotatedMBB(&MBB{XMin: mbb.XMin, XMax: mbb.XMax}, angle*math.Pi/180.0)
+		newMBB.XMin, newMBB.YMin, newMBB.XMax, newMBB.YMax = swept.XMin, swept.YMin, swept.XMax, swept.YMax
+	}
+
+	// sweep finds a, the angle of xyz from the first ring of the
+	// revolution in the direction of the sweep. Like OpenSCAD, a full
+	// revolution starts on the -X axis (which only matters for facets)
+	// and a mirrored profile is half a turn away.
+	var sweep string
+	n := s.revolveFragments(args, rmax, angle)
+	if !full || n > 0 {
+		start := 0.0
+		if full {
+			start = math.Pi
+		}
+		if mirrored {
+			start = math.Mod(start+math.Pi, 2*math.Pi)
+		}
+		dir := 1
+		if angle < 0 {
+			dir = -1
+		}
+		sweep = fmt.Sprintf("\tfloat a = mod(float(%v)*(atan(xyz.y, xyz.x) - float(%v)), 2.0*3.1415926535897932384626433832795);\n", dir, start)
+		if !full {
+			sweep += fmt.Sprintf("\tif (a > float(%v)) { return 0.0; }\n", math.Abs(angle)*math.Pi/180.0)
+		}
+		if n > 0 {
+			// Within each sector, the faceted surface is the chord between
+			// two rings, so the profile radius grows by cos(a)/cos(sector/2)
+			// where a is the angle from the middle of the sector.
+			sweep += fmt.Sprintf("\tfloat sector = float(%v);\n\tr *= cos(a - (min(floor(a/sector), float(%v)) + 0.5)*sector)/cos(0.5*sector);\n",
+				math.Abs(angle)*math.Pi/180.0/float64(n), n-1)
+		}
+	}
 
-	argVals[0] = strings.Trim(argVals[0], "()")
-	if argVals[0] == "" {
-		argVals[0] = "360"
+	radius := "r"
+	if mirrored {
+		radius = "-r"
 	}
 
-	fNum := len(s.Functions)
-	fName := fmt.Sprintf("rotateExtrudeBlock%v", fNum)
-	newFunc := fmt.Sprintf(`float %v(in vec3 xyz) {
-	float angle = atan(xyz.y, xyz.x);
-	if (angle<0.) { angle+=(2.*3.1415926535897932384626433832795); }
-	if (angle>float(%v)*3.1415926535897932384626433832795/180.0) { return 0.0; }
-	vec3 slice=(vec4(xyz,1)*rotZ(-angle)).xyz;
-	xyz = slice.xzy;
+	fName := s.addFunction("rotateExtrudeBlock", `float %v(in vec3 xyz) {
+%v	float r = length(xyz.xy);
+%v	xyz = vec3(%v, xyz.z, 0.0);
 	return %v;
 }
-`, fName, argVals[0], strings.Join(calls, " + "))
-
-	s.Functions = append(s.Functions, newFunc)
-
-	// TODO: Sample rotation to get a more accurate MBB.
-	xmin := -mbb.XMax
-	xmax := mbb.XMax
-	ymin := -mbb.XMax
-	ymax := mbb.XMax
-	zmin := mbb.YMin
-	zmax := mbb.YMax
-	newMBB := &MBB{XMin: xmin, YMin: ymin, ZMin: zmin, XMax: xmax, YMax: ymax, ZMax: zmax}
+`, s.earlyOut(newMBB), sweep, radius, strings.Join(calls, " + "))
 
 	return fmt.Sprintf("%v(xyz)", fName), newMBB
 }
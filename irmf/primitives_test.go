@@ -128,7 +128,10 @@ func TestProcessCubePrimitive(t *testing.T) {
 				t.Fatalf("ParseProgram: %v", strings.Join(errs, "\n"))
 			}
 
-			shader := New(program, tt.center)
+			shader, err := New(program, tt.center)
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
 			if !reflect.DeepEqual(shader.Functions, tt.want) {
 				t.Errorf("functions = %+v, want %+v", shader.Functions, tt.want)
 			}
@@ -197,7 +200,10 @@ func TestProcessSpherePrimitive(t *testing.T) {
 				t.Fatalf("ParseProgram: %v", strings.Join(errs, "\n"))
 			}
 
-			shader := New(program, tt.center)
+			shader, err := New(program, tt.center)
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
 			if !reflect.DeepEqual(shader.Functions, tt.want) {
 				t.Errorf("functions = %+v, want %+v", shader.Functions, tt.want)
 			}
@@ -307,7 +313,10 @@ func TestProcessCylinderPrimitive(t *testing.T) {
 				t.Fatalf("ParseProgram: %v", strings.Join(errs, "\n"))
 			}
 
-			shader := New(program, tt.center)
+			shader, err := New(program, tt.center)
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
 			if !reflect.DeepEqual(shader.Functions, tt.want) {
 				t.Errorf("functions = %+v, want %+v", shader.Functions, tt.want)
 			}
@@ -386,7 +395,10 @@ func TestProcessSquarePrimitive(t *testing.T) {
 				t.Fatalf("ParseProgram: %v", strings.Join(errs, "\n"))
 			}
 
-			shader := New(program, tt.center)
+			shader, err := New(program, tt.center)
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
 			if !reflect.DeepEqual(shader.Functions, tt.want) {
 				t.Errorf("functions = %+v, want %+v", shader.Functions, tt.want)
 			}
@@ -428,7 +440,10 @@ func TestProcessMultmatrixBlockPrimitive(t *testing.T) {
 				t.Fatalf("ParseProgram: %v", strings.Join(errs, "\n"))
 			}
 
-			shader := New(program, tt.center)
+			shader, err := New(program, tt.center)
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
 			if !reflect.DeepEqual(shader.Functions, tt.want) {
 				t.Errorf("functions = %#v, want %#v", shader.Functions, tt.want)
 			}
@@ -477,7 +492,10 @@ func TestProcessUnionBlockPrimitive(t *testing.T) {
 				t.Fatalf("ParseProgram: %v", strings.Join(errs, "\n"))
 			}
 
-			shader := New(program, tt.center)
+			shader, err := New(program, tt.center)
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
 			if !reflect.DeepEqual(shader.Functions, tt.want) {
 				t.Errorf("functions = %#v, want %#v", shader.Functions, tt.want)
 			}
@@ -513,7 +531,7 @@ func TestProcessDifferenceBlockPrimitive(t *testing.T) {
 				"float difference1(in vec3 xyz) {\n\treturn clamp(sphere(float(1), xyz) - multimatrixBlock0(xyz), 0.0, 1.0);\n}\n",
 				fmt.Sprintf(mainBodyFmt, "difference1(xyz)"),
 			},
-			mbb: &MBB{XMin: -1, XMax: 4, YMin: -2, YMax: 2, ZMin: -2, ZMax: 2},
+			mbb: &MBB{XMin: -1, XMax: 1, YMin: -1, YMax: 1, ZMin: -1, ZMax: 1},
 		},
 	}
 
@@ -526,7 +544,10 @@ func TestProcessDifferenceBlockPrimitive(t *testing.T) {
 				t.Fatalf("ParseProgram: %v", strings.Join(errs, "\n"))
 			}
 
-			shader := New(program, tt.center)
+			shader, err := New(program, tt.center)
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
 			if !reflect.DeepEqual(shader.Functions, tt.want) {
 				t.Errorf("functions = %#v, want %#v", shader.Functions, tt.want)
 			}
@@ -562,7 +583,7 @@ func TestProcessIntersectionBlockPrimitive(t *testing.T) {
 				"float intersection1(in vec3 xyz) {\n\treturn clamp(sphere(float(1), xyz) * multimatrixBlock0(xyz), 0.0, 1.0);\n}\n",
 				fmt.Sprintf(mainBodyFmt, "intersection1(xyz)"),
 			},
-			mbb: &MBB{XMin: -1, XMax: 4, YMin: -2, YMax: 2, ZMin: -2, ZMax: 2},
+			mbb: &MBB{XMin: 0, XMax: 1, YMin: -1, YMax: 1, ZMin: -1, ZMax: 1},
 		},
 	}
 
@@ -575,7 +596,10 @@ func TestProcessIntersectionBlockPrimitive(t *testing.T) {
 				t.Fatalf("ParseProgram: %v", strings.Join(errs, "\n"))
 			}
 
-			shader := New(program, tt.center)
+			shader, err := New(program, tt.center)
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
 			if !reflect.DeepEqual(shader.Functions, tt.want) {
 				t.Errorf("functions = %#v, want %#v", shader.Functions, tt.want)
 			}
@@ -607,12 +631,11 @@ func TestProcessLinearExtrudeBlockPrimitive(t *testing.T) {
 }
 `,
 				`float linearExtrudeBlock1(in vec3 xyz) {
-	xyz.z /= float(10);
-	float z = xyz.z;
-	if (false) { z += 0.5; } else { xyz.z -= 0.5; }
-	if (abs(xyz.z) > 0.5) { return 0.0; }
-	vec2 s = mix(vec2(1),vec2(1,1),z);
-	xyz.xy /= s;
+	float t = (xyz.z - float(0)) / float(10);
+	if (t < 0.0 || t > 1.0) { return 0.0; }
+	mat2 m = linearExtrudeSlice(float(0), vec2(1, 1), float(1), t);
+	if (determinant(m) == 0.0) { return 0.0; }
+	xyz.xy = inverse(m) * xyz.xy;
 	return multimatrixBlock0(xyz);
 }
 `,
@@ -634,12 +657,11 @@ func TestProcessLinearExtrudeBlockPrimitive(t *testing.T) {
 }
 `,
 				`float linearExtrudeBlock1(in vec3 xyz) {
-	xyz.z /= float(10);
-	float z = xyz.z;
-	if (true) { z += 0.5; } else { xyz.z -= 0.5; }
-	if (abs(xyz.z) > 0.5) { return 0.0; }
-	vec2 s = mix(vec2(1),vec2(1,1),z);
-	xyz.xy /= s;
+	float t = (xyz.z - float(-5)) / float(10);
+	if (t < 0.0 || t > 1.0) { return 0.0; }
+	mat2 m = linearExtrudeSlice(float(0), vec2(1, 1), float(1), t);
+	if (determinant(m) == 0.0) { return 0.0; }
+	xyz.xy = inverse(m) * xyz.xy;
 	return multimatrixBlock0(xyz);
 }
 `,
@@ -661,14 +683,11 @@ func TestProcessLinearExtrudeBlockPrimitive(t *testing.T) {
 }
 `,
 				`float linearExtrudeBlock1(in vec3 xyz) {
-	xyz.z /= float(10);
-	float z = xyz.z;
-	if (false) { z += 0.5; } else { xyz.z -= 0.5; }
-	if (abs(xyz.z) > 0.5) { return 0.0; }
-	float angle = mix(0.0, float(90)*3.1415926535897932384626433832795/180.0, z);
-	vec2 s = mix(vec2(1),vec2(1,1),z);
-	xyz.xy /= s;
-	xyz = (vec4(xyz, 1) * rotZ(angle)).xyz;
+	float t = (xyz.z - float(0)) / float(10);
+	if (t < 0.0 || t > 1.0) { return 0.0; }
+	mat2 m = linearExtrudeSlice(float(90), vec2(1, 1), float(7), t);
+	if (determinant(m) == 0.0) { return 0.0; }
+	xyz.xy = inverse(m) * xyz.xy;
 	return multimatrixBlock0(xyz);
 }
 `,
@@ -676,6 +695,33 @@ func TestProcessLinearExtrudeBlockPrimitive(t *testing.T) {
 			},
 			mbb: &MBB{XMin: -1, XMax: 2, YMin: -2, YMax: 1, ZMin: 0, ZMax: 10},
 		},
+		{
+			src: `linear_extrude(height = 10, center = true, v = [0, 3, 4], scale = 0) {
+	multmatrix([[1, 0, 0, 1], [0, 1, 0, 0], [0, 0, 1, 0], [0, 0, 0, 1]]) {
+		circle($fn = 100, $fa = 12, $fs = 2, r = 1);
+	}
+}`,
+			want: []string{
+				`float multimatrixBlock0(in vec3 xyz) {
+	mat4 xfm = mat4(vec4(1, 0, 0, -1), vec4(0, 1, 0, 0), vec4(0, 0, 1, 0), vec4(0, 0, 0, 1));
+	xyz = (vec4(xyz, 1.0) * xfm).xyz;
+	return circle(float(1), xyz);
+}
+`,
+				`float linearExtrudeBlock1(in vec3 xyz) {
+	float t = (xyz.z - float(-4)) / float(8);
+	if (t < 0.0 || t > 1.0) { return 0.0; }
+	xyz.xy -= vec2(0, -3) + t*vec2(0, 6);
+	mat2 m = linearExtrudeSlice(float(0), vec2(0, 0), float(1), t);
+	if (determinant(m) == 0.0) { return 0.0; }
+	xyz.xy = inverse(m) * xyz.xy;
+	return multimatrixBlock0(xyz);
+}
+`,
+				fmt.Sprintf(mainBodyFmt, "linearExtrudeBlock1(xyz)"),
+			},
+			mbb: &MBB{XMin: 0, XMax: 2, YMin: -4, YMax: 3, ZMin: -4, ZMax: 4},
+		},
 	}
 
 	for i, tt := range tests {
@@ -687,7 +733,10 @@ func TestProcessLinearExtrudeBlockPrimitive(t *testing.T) {
 				t.Fatalf("ParseProgram: %v", strings.Join(errs, "\n"))
 			}
 
-			shader := New(program, tt.center)
+			shader, err := New(program, tt.center)
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
 			if !reflect.DeepEqual(shader.Functions, tt.want) {
 				t.Errorf("functions = %#v, want %#v", shader.Functions, tt.want)
 			}
@@ -711,6 +760,7 @@ func TestProcessRotateExtrudeBlockPrimitive(t *testing.T) {
 	tests := []struct {
 		src    string
 		center bool
+		opts   []Option
 		want   []string
 		mbb    *MBB
 	}{
@@ -728,11 +778,8 @@ func TestProcessRotateExtrudeBlockPrimitive(t *testing.T) {
 }
 `,
 				`float rotateExtrudeBlock1(in vec3 xyz) {
-	float angle = atan(xyz.y, xyz.x);
-	if (angle<0.) { angle+=(2.*3.1415926535897932384626433832795); }
-	if (angle>float(360)*3.1415926535897932384626433832795/180.0) { return 0.0; }
-	vec3 slice=(vec4(xyz,1)*rotZ(-angle)).xyz;
-	xyz = slice.xzy;
+	float r = length(xyz.xy);
+	xyz = vec3(r, xyz.z, 0.0);
 	return multimatrixBlock0(xyz);
 }
 `,
@@ -757,11 +804,8 @@ func TestProcessRotateExtrudeBlockPrimitive(t *testing.T) {
 }
 `,
 				`float rotateExtrudeBlock1(in vec3 xyz) {
-	float angle = atan(xyz.y, xyz.x);
-	if (angle<0.) { angle+=(2.*3.1415926535897932384626433832795); }
-	if (angle>float(360)*3.1415926535897932384626433832795/180.0) { return 0.0; }
-	vec3 slice=(vec4(xyz,1)*rotZ(-angle)).xyz;
-	xyz = slice.xzy;
+	float r = length(xyz.xy);
+	xyz = vec3(r, xyz.z, 0.0);
 	return simplePolygon0(xyz);
 }
 `,
@@ -769,6 +813,59 @@ func TestProcessRotateExtrudeBlockPrimitive(t *testing.T) {
 			},
 			mbb: &MBB{XMin: -3, XMax: 3, YMin: -3, YMax: 3, ZMin: 0, ZMax: 5},
 		},
+		{
+			src: `rotate_extrude(angle = -90, convexity = 2, $fn = 0, $fa = 12, $fs = 2) {
+	multmatrix([[1, 0, 0, -3], [0, 1, 0, 0], [0, 0, 1, 0], [0, 0, 0, 1]]) {
+		circle($fn = 0, $fa = 12, $fs = 2, r = 1);
+	}
+}`,
+			want: []string{
+				`float multimatrixBlock0(in vec3 xyz) {
+	mat4 xfm = mat4(vec4(1, 0, 0, 3), vec4(0, 1, 0, 0), vec4(0, 0, 1, 0), vec4(0, 0, 0, 1));
+	xyz = (vec4(xyz, 1.0) * xfm).xyz;
+	return circle(float(1), xyz);
+}
+`,
+				`float rotateExtrudeBlock1(in vec3 xyz) {
+	float r = length(xyz.xy);
+	float a = mod(float(-1)*(atan(xyz.y, xyz.x) - float(3.141592653589793)), 2.0*3.1415926535897932384626433832795);
+	if (a > float(1.5707963267948966)) { return 0.0; }
+	xyz = vec3(-r, xyz.z, 0.0);
+	return multimatrixBlock0(xyz);
+}
+`,
+				fmt.Sprintf(mainBodyFmt, "rotateExtrudeBlock1(xyz)"),
+			},
+			mbb: &MBB{XMin: -4, XMax: 0, YMin: 0, YMax: 4, ZMin: -1, ZMax: 1},
+		},
+		{
+			src: `rotate_extrude(angle = 90, convexity = 2, $fn = 8, $fa = 12, $fs = 2) {
+	multmatrix([[1, 0, 0, 3], [0, 1, 0, 0], [0, 0, 1, 0], [0, 0, 0, 1]]) {
+		circle($fn = 0, $fa = 12, $fs = 2, r = 1);
+	}
+}`,
+			opts: []Option{WithFacets()},
+			want: []string{
+				`float multimatrixBlock0(in vec3 xyz) {
+	mat4 xfm = mat4(vec4(1, 0, 0, -3), vec4(0, 1, 0, 0), vec4(0, 0, 1, 0), vec4(0, 0, 0, 1));
+	xyz = (vec4(xyz, 1.0) * xfm).xyz;
+	return facetedCircle(float(1), 5.0, xyz);
+}
+`,
+				`float rotateExtrudeBlock1(in vec3 xyz) {
+	float r = length(xyz.xy);
+	float a = mod(float(1)*(atan(xyz.y, xyz.x) - float(0)), 2.0*3.1415926535897932384626433832795);
+	if (a > float(1.5707963267948966)) { return 0.0; }
+	float sector = float(0.7853981633974483);
+	r *= cos(a - (min(floor(a/sector), float(1)) + 0.5)*sector)/cos(0.5*sector);
+	xyz = vec3(r, xyz.z, 0.0);
+	return multimatrixBlock0(xyz);
+}
+`,
+				fmt.Sprintf(mainBodyFmt, "rotateExtrudeBlock1(xyz)"),
+			},
+			mbb: &MBB{XMin: 0, XMax: 4, YMin: 0, YMax: 4, ZMin: -1, ZMax: 1},
+		},
 	}
 
 	for i, tt := range tests {
@@ -780,7 +877,10 @@ func TestProcessRotateExtrudeBlockPrimitive(t *testing.T) {
 				t.Fatalf("ParseProgram: %v", strings.Join(errs, "\n"))
 			}
 
-			shader := New(program, tt.center)
+			shader, err := New(program, tt.center, tt.opts...)
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
 			if !reflect.DeepEqual(shader.Functions, tt.want) {
 				t.Errorf("functions = %#v, want %#v", shader.Functions, tt.want)
 			}
@@ -11,16 +11,22 @@ type Lexer struct {
 	position     int  // current position in input (points to current char)
 	readPosition int  // current reading position in input (after current char)
 	ch           byte // current char under examination
+	line         int  // line of the current char (starting at 1)
+	lineStart    int  // position of the first char of the current line
 }
 
 // New returns a new Lexer.
 func New(input string) *Lexer {
-	le := &Lexer{input: input}
+	le := &Lexer{input: input, line: 1}
 	le.readChar()
 	return le
 }
 
 func (le *Lexer) readChar() {
+	if le.ch == '\n' {
+		le.line++
+		le.lineStart = le.readPosition
+	}
 	if le.readPosition >= len(le.input) {
 		le.ch = 0
 	} else {
@@ -30,11 +36,17 @@ func (le *Lexer) readChar() {
 	le.readPosition++
 }
 
-// NextToken returns the next token.
+// NextToken returns the next token, with its position.
 func (le *Lexer) NextToken() token.Token {
-	var tok token.Token
-
 	le.skipWhitespace()
+	pos := token.Position{Offset: le.position, Line: le.line, Column: le.position - le.lineStart + 1}
+	tok := le.nextToken()
+	tok.Pos = pos
+	return tok
+}
+
+func (le *Lexer) nextToken() token.Token {
+	var tok token.Token
 
 	switch le.ch {
 	case '=':
@@ -73,6 +85,10 @@ func (le *Lexer) NextToken() token.Token {
 		}
 	case '*':
 		tok = newToken(token.ASTERISK, le.ch)
+	case '#':
+		tok = newToken(token.POUND, le.ch)
+	case '%':
+		tok = newToken(token.PERCENT, le.ch)
 	case '<':
 		tok = newToken(token.LT, le.ch)
 	case '>':
@@ -50,6 +50,7 @@ if (5 < 10) {
 "foo bar"
 [1, 2];
 {"foo": "bar"}
+*cube(); #sphere(); %cylinder();
 `
 
 	tests := []struct {
@@ -218,6 +219,21 @@ if (5 < 10) {
 		{token.COLON, ":"},
 		{token.STRING, "bar"},
 		{token.RBRACE, "}"},
+		{token.ASTERISK, "*"},
+		{token.CUBE, "cube"},
+		{token.LPAREN, "("},
+		{token.RPAREN, ")"},
+		{token.SEMICOLON, ";"},
+		{token.POUND, "#"},
+		{token.SPHERE, "sphere"},
+		{token.LPAREN, "("},
+		{token.RPAREN, ")"},
+		{token.SEMICOLON, ";"},
+		{token.PERCENT, "%"},
+		{token.CYLINDER, "cylinder"},
+		{token.LPAREN, "("},
+		{token.RPAREN, ")"},
+		{token.SEMICOLON, ";"},
 		{token.EOF, ""},
 	}
 
@@ -236,3 +252,40 @@ if (5 < 10) {
 		})
 	}
 }
+
+func TestNextToken_Positions(t *testing.T) {
+	input := "cube();\n  // comment\n\tsphere(r = 10);"
+
+	tests := []struct {
+		literal string
+		want    token.Position
+	}{
+		{"cube", token.Position{Offset: 0, Line: 1, Column: 1}},
+		{"(", token.Position{Offset: 4, Line: 1, Column: 5}},
+		{")", token.Position{Offset: 5, Line: 1, Column: 6}},
+		{";", token.Position{Offset: 6, Line: 1, Column: 7}},
+		{" comment", token.Position{Offset: 10, Line: 2, Column: 3}},
+		{"sphere", token.Position{Offset: 22, Line: 3, Column: 2}},
+		{"(", token.Position{Offset: 28, Line: 3, Column: 8}},
+		{"r", token.Position{Offset: 29, Line: 3, Column: 9}},
+		{"=", token.Position{Offset: 31, Line: 3, Column: 11}},
+		{"10", token.Position{Offset: 33, Line: 3, Column: 13}},
+		{")", token.Position{Offset: 35, Line: 3, Column: 15}},
+		{";", token.Position{Offset: 36, Line: 3, Column: 16}},
+		{"", token.Position{Offset: 37, Line: 3, Column: 17}},
+	}
+
+	le := New(input)
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test #%v", i), func(t *testing.T) {
+			tok := le.NextToken()
+			if tok.Literal != tt.literal {
+				t.Fatalf("literal = %q, want %q", tok.Literal, tt.literal)
+			}
+			if tok.Pos != tt.want {
+				t.Errorf("pos = %#v, want %#v", tok.Pos, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+// Package lint checks CSG programs for common modeling mistakes, such
+// as coplanar faces in a difference (which cause z-fighting), zero or
+// negative sizes, singular matrices and floating point noise. Some
+// issues can be fixed automatically when the fix does not change the
+// intended geometry.
+package lint
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/gmlewis/go-csg/ast"
+	"github.com/gmlewis/go-csg/token"
+)
+
+// These constants are the defaults of the Options.
+const (
+	DefaultMaxFn   = 360
+	DefaultNoise   = 1e-9
+	DefaultEpsilon = 0.01
+)
+
+// Options represents the options of Check.
+type Options struct {
+	// Disabled holds the names of the rules that are not checked.
+	Disabled map[string]bool
+	// MaxFn is the largest $fn that is not reported.
+	MaxFn float64
+	// Noise is the largest difference from an integer of a matrix
+	// entry that is considered floating point noise.
+	Noise float64
+	// Epsilon is the distance by which a fix extends a subtrahend
+	// past a coplanar face.
+	Epsilon float64
+}
+
+// Issue represents a problem found by a rule.
+type Issue struct {
+	Rule    string         `json:"rule"`
+	Pos     token.Position `json:"pos"`
+	Msg     string         `json:"message"`
+	Fixable bool           `json:"fixable"`
+
+	fix func()
+}
+
+// String returns the string representation of the Issue.
+func (i *Issue) String() string {
+	s := fmt.Sprintf("%v: %v (%v)", i.Pos, i.Msg, i.Rule)
+	if i.Fixable {
+		s += " [fixable]"
+	}
+	return s
+}
+
+// Check returns the issues of the program, sorted by position.
+func Check(program *ast.Program, opts *Options) []*Issue {
+	l := &linter{opts: Options{MaxFn: DefaultMaxFn, Noise: DefaultNoise, Epsilon: DefaultEpsilon}}
+	if opts != nil {
+		l.opts.Disabled = opts.Disabled
+		if opts.MaxFn > 0 {
+			l.opts.MaxFn = opts.MaxFn
+		}
+		if opts.Noise > 0 {
+			l.opts.Noise = opts.Noise
+		}
+		if opts.Epsilon > 0 {
+			l.opts.Epsilon = opts.Epsilon
+		}
+	}
+
+	for _, rule := range Rules {
+		if l.opts.Disabled[rule.Name] {
+			continue
+		}
+		l.rule = rule
+		if rule.program != nil {
+			rule.program(l, program)
+		}
+		if rule.node != nil {
+			l.statements(&program.Statements, nil, false)
+		}
+	}
+
+	sort.SliceStable(l.issues, func(i, j int) bool {
+		return l.issues[i].Pos.Offset < l.issues[j].Pos.Offset
+	})
+	return l.issues
+}
+
+// Fix applies the fixes of the fixable issues, which must have been
+// returned by Check for the same program, and returns the number of
+// issues fixed. The program is modified in place.
+func Fix(issues []*Issue) int {
+	var n int
+	for _, issue := range issues {
+		if issue.fix != nil {
+			issue.fix()
+			n++
+		}
+	}
+	return n
+}
+
+type linter struct {
+	opts   Options
+	rule   *Rule
+	issues []*Issue
+}
+
+// context represents a geometric node visited by a rule.
+type context struct {
+	stmt *ast.ExpressionStatement
+	// exp is the node without its modifiers.
+	exp ast.Expression
+	// siblings is the statement list containing stmt.
+	siblings *[]ast.Statement
+	// parent is the block containing stmt, or nil at the top level.
+	parent ast.Expression
+	// subtracted reports whether the node is within a subtrahend of
+	// a difference.
+	subtracted bool
+}
+
+// set replaces the node, keeping its modifiers.
+func (c *context) set(exp ast.Expression) {
+	if me, ok := c.stmt.Expression.(*ast.ModifierExpression); ok {
+		for {
+			inner, ok := me.Right.(*ast.ModifierExpression)
+			if !ok {
+				break
+			}
+			me = inner
+		}
+		me.Right = exp
+		return
+	}
+	c.stmt.Expression = exp
+}
+
+// remove removes the statement from its siblings.
+func (c *context) remove() {
+	var stmts []ast.Statement
+	for _, stmt := range *c.siblings {
+		if stmt != c.stmt {
+			stmts = append(stmts, stmt)
+		}
+	}
+	*c.siblings = stmts
+}
+
+func (l *linter) report(node ast.Node, fix func(), format string, args ...interface{}) {
+	l.issues = append(l.issues, &Issue{
+		Rule:    l.rule.Name,
+		Pos:     ast.Pos(node),
+		Msg:     fmt.Sprintf(format, args...),
+		Fixable: fix != nil,
+		fix:     fix,
+	})
+}
+
+func (l *linter) statements(stmts *[]ast.Statement, parent ast.Expression, subtracted bool) {
+	_, isDifference := parent.(*ast.DifferenceBlockPrimitive)
+	var index int // index of the next geometric child
+	for _, stmt := range *stmts {
+		es, ok := stmt.(*ast.ExpressionStatement)
+		if !ok {
+			continue
+		}
+		exp := unmodified(es.Expression)
+		if _, ok := exp.(*ast.LineComment); ok {
+			continue
+		}
+		c := &context{stmt: es, exp: exp, siblings: stmts, parent: parent, subtracted: subtracted || (isDifference && index > 0)}
+		l.rule.node(l, c)
+		if _, b, _ := ast.Parts(exp); b != nil {
+			l.statements(&b.Statements, exp, c.subtracted)
+		}
+		index++
+	}
+}
+
+func unmodified(exp ast.Expression) ast.Expression {
+	for {
+		me, ok := exp.(*ast.ModifierExpression)
+		if !ok {
+			return exp
+		}
+		exp = me.Right
+	}
+}
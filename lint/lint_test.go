@@ -0,0 +1,161 @@
+package lint
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/gmlewis/go-csg/ast"
+	"github.com/gmlewis/go-csg/lexer"
+	"github.com/gmlewis/go-csg/parser"
+	"github.com/gmlewis/go-csg/printer"
+)
+
+func parse(t *testing.T, src string) *ast.Program {
+	t.Helper()
+	le := lexer.New(src)
+	p := parser.New(le)
+	program := p.ParseProgram()
+	if errs := p.Errors(); len(errs) != 0 {
+		t.Fatalf("ParseProgram: %v", strings.Join(errs, "\n"))
+	}
+	return program
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		src  string
+		opts *Options
+		want []string
+	}{
+		{
+			src: `difference() {
+	cube(size = [10, 10, 10], center = false);
+	translate([2, 2, 0]) { cylinder(h = 10, r1 = 1, r2 = 1, center = false); }
+}`,
+			want: []string{"3:2: translate shares its -z, +z face(s) with the first child of the difference (coplanar-difference) [fixable]"},
+		},
+		{
+			src: `difference() {
+	cube(size = [10, 10, 10], center = false);
+	translate([2, 2, -1]) { cylinder(h = 12, r1 = 1, r2 = 1, center = false); }
+	translate([20, 0, 0]) { cube(size = 1); }
+}`,
+			want: []string{"4:2: translate does not overlap the first child of the difference (non-overlapping-difference) [fixable]"},
+		},
+		{
+			src: "cube(size = [1, 0, 1]);\nsphere(r = -1);\ncylinder(h = 1, r1 = 0, r2 = 0);\nsphere(r = 1, $fn = 1000);",
+			want: []string{
+				"1:1: cube(size = [1, 0, 1]) has a zero or negative size (non-positive-size)",
+				"2:1: sphere(r = -1) has a zero or negative size (non-positive-size)",
+				"3:1: cylinder(h = 1, r1 = 0, r2 = 0) has a zero or negative size (non-positive-size)",
+				"4:1: $fn = 1000 exceeds 360 (large-fn)",
+			},
+		},
+		{
+			src:  "sphere(r = 1, $fn = 1000);",
+			opts: &Options{MaxFn: 1000},
+		},
+		{
+			src: "scale([1, 0, 1]) { cube(size = 1); }\nmultmatrix([[1, 0, 0, 0], [0, 1, 0, 2.22045e-16], [0, 0, 1, 0], [0, 0, 0, 1]]) { cube(size = 1); }",
+			want: []string{
+				"1:1: scale has a singular matrix (determinant 0), which collapses its children (singular-matrix)",
+				"2:1: multmatrix has floating point noise (matrix-noise) [fixable]",
+			},
+		},
+		{
+			src: "multmatrix([[1, 0, 0, 0], [0, 1, 0, 0], [0, 0, 1e-12, 0], [0, 0, 0, 1]]) { cube(size = 1); }",
+		},
+		{
+			src:  "multmatrix([[1, 0, 0, 1e-12], [0, 1, 0, 0], [0, 0, 1e-12, 0], [0, 0, 0, 1]]) { cube(size = 1); }",
+			want: []string{"1:1: multmatrix has floating point noise (matrix-noise) [fixable]"},
+		},
+		{
+			src:  "scale([1, 0, 1]) { cube(size = 1); }",
+			opts: &Options{Disabled: map[string]bool{"singular-matrix": true}},
+		},
+		{
+			src: "union() { cube(size = 1); square(size = 1); }",
+			want: []string{
+				"1:1: mixing 2D and 3D objects is not supported: cube is 3D but square is 2D (mixed-dimensions)",
+			},
+		},
+		{
+			src: `color([1, 0, 0, 1]) { }
+color([1, 0, 0, 1]) { color([0, 1, 0, 1]) { cube(size = 1); } }
+difference() { cube(size = 2); color([0, 0, 1, 1]) { sphere(r = 1); } }`,
+			want: []string{
+				"1:1: color is empty (unused-color) [fixable]",
+				"2:1: color is overridden by the colors of all of its children (unused-color) [fixable]",
+				"3:32: color is within a subtrahend of a difference (unused-color) [fixable]",
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test #%v", i), func(t *testing.T) {
+			var got []string
+			for _, issue := range Check(parse(t, tt.src), tt.opts) {
+				got = append(got, issue.String())
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("Check =\n%v\nwant\n%v", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestFix(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{
+			src: `difference() {
+	cube(size = [10, 10, 10], center = false);
+	%cylinder(h = 10, r1 = 1, r2 = 1, center = false);
+	translate([20, 0, 0]) { cube(size = 1); }
+}`,
+			want: `difference() {
+	cube(size = [10, 10, 10], center = false);
+	%multmatrix([[1, 0, 0, 0], [0, 1, 0, 0], [0, 0, 1.002, -0.01], [0, 0, 0, 1]]) {
+		cylinder(h = 10, r1 = 1, r2 = 1, center = false);
+	}
+}
+`,
+		},
+		{
+			src: "multmatrix([[1, 0, 0, 0], [0, 1, 0, 2.22045e-16], [0, 0, 1, 0], [0, 0, 0, 1]]) { cube(size = 1); }\nmultmatrix([[1, 0, 0, 1e-12], [0, 1, 0, 0], [0, 0, 1e-12, 0], [0, 0, 0, 1]]) { cube(size = 1); }\ncolor([1, 0, 0, 1]) { color([0, 1, 0, 1]) { cube(size = 1); } }",
+			want: `multmatrix([[1, 0, 0, 0], [0, 1, 0, 0], [0, 0, 1, 0], [0, 0, 0, 1]]) {
+	cube(size = 1);
+}
+multmatrix([[1, 0, 0, 0], [0, 1, 0, 0], [0, 0, 1e-12, 0], [0, 0, 0, 1]]) {
+	cube(size = 1);
+}
+group() {
+	color([0, 1, 0, 1]) {
+		cube(size = 1);
+	}
+}
+`,
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test #%v", i), func(t *testing.T) {
+			program := parse(t, tt.src)
+			issues := Check(program, nil)
+			Fix(issues)
+			if issues := Check(program, nil); len(issues) != 0 {
+				t.Errorf("Check after Fix = %v, want none", issues)
+			}
+			got, err := printer.String(program)
+			if err != nil {
+				t.Fatalf("printer.String: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Fix =\n%v\nwant\n%v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,456 @@
+package lint
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/gmlewis/go-csg/ast"
+	"github.com/gmlewis/go-csg/dims"
+	"github.com/gmlewis/go-csg/params"
+	"github.com/gmlewis/go-csg/printer"
+	"github.com/gmlewis/go-csg/solid"
+	"github.com/gmlewis/go-csg/token"
+	"github.com/gmlewis/go-csg/transform"
+)
+
+// Rule represents a check of the linter.
+type Rule struct {
+	Name string
+	Doc  string
+
+	// node is called for each geometric node of the program,
+	// and program once for the whole program.
+	node    func(l *linter, c *context)
+	program func(l *linter, program *ast.Program)
+}
+
+// Rules lists the rules of the linter.
+var Rules = []*Rule{
+	{
+		Name: "coplanar-difference",
+		Doc:  "A subtrahend of a difference shares a face with the first child, which causes z-fighting. Fixed by extending the subtrahend past the face.",
+		node: coplanarDifference,
+	},
+	{
+		Name: "non-positive-size",
+		Doc:  "A primitive or extrusion has a zero or negative size.",
+		node: nonPositiveSize,
+	},
+	{
+		Name: "singular-matrix",
+		Doc:  "A multmatrix or scale has a singular matrix, which collapses its children.",
+		node: singularMatrix,
+	},
+	{
+		Name: "non-overlapping-difference",
+		Doc:  "A subtrahend of a difference does not overlap the first child, so it has no effect. Fixed by removing it.",
+		node: nonOverlappingDifference,
+	},
+	{
+		Name: "large-fn",
+		Doc:  "A $fn is larger than the maximum, which makes rendering slow.",
+		node: largeFn,
+	},
+	{
+		Name:    "mixed-dimensions",
+		Doc:     "A node mixes 2D and 3D objects, which OpenSCAD does not support.",
+		program: mixedDimensions,
+	},
+	{
+		Name: "unused-color",
+		Doc:  "A color has no visible effect: it is empty, within a subtrahend of a difference, or overridden by the colors of all of its children. Fixed by replacing it with a group.",
+		node: unusedColor,
+	},
+	{
+		Name: "matrix-noise",
+		Doc:  "A multmatrix has entries that differ from an integer by floating point noise (e.g. 2.22045e-16). Fixed by rounding them.",
+		node: matrixNoise,
+	},
+}
+
+var (
+	multmatrixToken = token.Token{Type: token.MULTMATRIX, Literal: "multmatrix"}
+	lbraceToken     = token.Token{Type: token.LBRACE, Literal: "{"}
+)
+
+// children returns the geometric children of a block.
+func children(exp ast.Expression) []*ast.ExpressionStatement {
+	_, b, _ := ast.Parts(exp)
+	if b == nil {
+		return nil
+	}
+	var result []*ast.ExpressionStatement
+	for _, stmt := range b.Statements {
+		es, ok := stmt.(*ast.ExpressionStatement)
+		if !ok {
+			continue
+		}
+		if _, ok := es.Expression.(*ast.LineComment); ok {
+			continue
+		}
+		result = append(result, es)
+	}
+	return result
+}
+
+// header returns the name and arguments of a node.
+func header(exp ast.Expression) string {
+	var result []string
+	args, _, _ := ast.Parts(exp)
+	for _, arg := range args {
+		s, err := printer.String(arg)
+		if err != nil {
+			s = arg.String()
+		}
+		result = append(result, s)
+	}
+	return fmt.Sprintf("%v(%v)", exp.TokenLiteral(), strings.Join(result, ", "))
+}
+
+// faces represents the bounding box of a node, and which of the
+// faces of the box are planar faces of the node. planar[i][0] is the
+// face at min[i] and planar[i][1] the face at max[i].
+type faces struct {
+	box    solid.Box
+	planar [3][2]bool
+}
+
+// facesOf returns the faces of a cube or cylinder, possibly within
+// axis-aligned transforms, or false for any other node.
+func facesOf(exp ast.Expression) (*faces, bool) {
+	switch n := unmodified(exp).(type) {
+	case *ast.CubePrimitive:
+		size, center, err := params.Cube(n.Arguments)
+		if err != nil {
+			return nil, false
+		}
+		f := &faces{}
+		for i := 0; i < 3; i++ {
+			f.box.Max[i] = size[i]
+			if center {
+				f.box.Min[i], f.box.Max[i] = -size[i]/2, size[i]/2
+			}
+			f.planar[i] = [2]bool{true, true}
+		}
+		return f, true
+	case *ast.CylinderPrimitive:
+		c, err := params.Cylinder(n.Arguments)
+		if err != nil {
+			return nil, false
+		}
+		r := math.Max(c.R1, c.R2)
+		f := &faces{box: solid.Box{Min: solid.Vec3{-r, -r, 0}, Max: solid.Vec3{r, r, c.H}}}
+		if c.Center {
+			f.box.Min[2], f.box.Max[2] = -c.H/2, c.H/2
+		}
+		f.planar[2] = [2]bool{c.R1 > 0, c.R2 > 0}
+		return f, true
+	case *ast.GroupBlockPrimitive, *ast.UnionBlockPrimitive, *ast.ColorBlockPrimitive:
+		kids := children(n)
+		if len(kids) != 1 {
+			return nil, false
+		}
+		return facesOf(kids[0].Expression)
+	case *ast.MultmatrixBlockPrimitive, *ast.TranslateBlockPrimitive, *ast.ScaleBlockPrimitive, *ast.MirrorBlockPrimitive:
+		kids := children(n)
+		if len(kids) != 1 {
+			return nil, false
+		}
+		m, err := transform.Of(n, nil)
+		if err != nil {
+			return nil, false
+		}
+		for i := 0; i < 3; i++ {
+			for j := 0; j < 3; j++ {
+				if (i == j) == (m[i][j] == 0) {
+					return nil, false // not axis-aligned, or singular
+				}
+			}
+		}
+		inner, ok := facesOf(kids[0].Expression)
+		if !ok {
+			return nil, false
+		}
+		f := &faces{}
+		for i := 0; i < 3; i++ {
+			lo := m[i][i]*inner.box.Min[i] + m[i][3]
+			hi := m[i][i]*inner.box.Max[i] + m[i][3]
+			f.planar[i] = inner.planar[i]
+			if m[i][i] < 0 {
+				lo, hi = hi, lo
+				f.planar[i] = [2]bool{inner.planar[i][1], inner.planar[i][0]}
+			}
+			f.box.Min[i], f.box.Max[i] = lo, hi
+		}
+		return f, true
+	}
+	return nil, false
+}
+
+var axes = [3]string{"x", "y", "z"}
+
+func coplanarDifference(l *linter, c *context) {
+	if _, ok := c.exp.(*ast.DifferenceBlockPrimitive); !ok {
+		return
+	}
+	kids := children(c.exp)
+	if len(kids) < 2 {
+		return
+	}
+	a, ok := facesOf(kids[0].Expression)
+	if !ok {
+		return
+	}
+	const tolerance = 1e-9
+	for _, kid := range kids[1:] {
+		b, ok := facesOf(kid.Expression)
+		if !ok {
+			continue
+		}
+		var coplanar [3][2]bool
+		var names []string
+		for i := 0; i < 3; i++ {
+			// The boxes must overlap along the other axes.
+			overlap := true
+			for j := 0; j < 3; j++ {
+				if j != i && math.Min(a.box.Max[j], b.box.Max[j])-math.Max(a.box.Min[j], b.box.Min[j]) <= tolerance {
+					overlap = false
+				}
+			}
+			if !overlap {
+				continue
+			}
+			if a.planar[i][0] && b.planar[i][0] && math.Abs(a.box.Min[i]-b.box.Min[i]) <= tolerance {
+				coplanar[i][0] = true
+				names = append(names, "-"+axes[i])
+			}
+			if a.planar[i][1] && b.planar[i][1] && math.Abs(a.box.Max[i]-b.box.Max[i]) <= tolerance {
+				coplanar[i][1] = true
+				names = append(names, "+"+axes[i])
+			}
+		}
+		if len(names) == 0 {
+			continue
+		}
+		kid, box, epsilon := kid, b.box, l.opts.Epsilon
+		fix := func() {
+			extend(kid, box, coplanar, epsilon)
+		}
+		l.report(kid, fix, "%v shares its %v face(s) with the first child of the difference", unmodified(kid.Expression).TokenLiteral(), strings.Join(names, ", "))
+	}
+}
+
+// extend wraps the node of the statement in a multmatrix that moves
+// the given faces of its bounding box outward by epsilon.
+func extend(stmt *ast.ExpressionStatement, box solid.Box, coplanar [3][2]bool, epsilon float64) {
+	m := transform.Identity
+	for i := 0; i < 3; i++ {
+		lo, hi := box.Min[i], box.Max[i]
+		if coplanar[i][0] {
+			lo -= epsilon
+		}
+		if coplanar[i][1] {
+			hi += epsilon
+		}
+		if size := box.Max[i] - box.Min[i]; size > 0 {
+			m[i][i] = (hi - lo) / size
+			m[i][3] = lo - box.Min[i]*m[i][i]
+		}
+	}
+	c := &context{stmt: stmt}
+	inner := &ast.ExpressionStatement{Token: stmt.Token, Expression: unmodified(stmt.Expression)}
+	c.set(&ast.MultmatrixBlockPrimitive{
+		Token:     multmatrixToken,
+		Arguments: []ast.Expression{transform.Literal(m)},
+		Body:      &ast.BlockStatement{Token: lbraceToken, Statements: []ast.Statement{inner}},
+	})
+}
+
+func nonPositiveSize(l *linter, c *context) {
+	var bad bool
+	switch n := c.exp.(type) {
+	case *ast.CubePrimitive:
+		size, _, err := params.Cube(n.Arguments)
+		bad = err == nil && (size[0] <= 0 || size[1] <= 0 || size[2] <= 0)
+	case *ast.SquarePrimitive:
+		size, _, err := params.Square(n.Arguments)
+		bad = err == nil && (size[0] <= 0 || size[1] <= 0)
+	case *ast.SpherePrimitive:
+		r, _, err := params.Sphere(n.Arguments)
+		bad = err == nil && r <= 0
+	case *ast.CirclePrimitive:
+		r, _, err := params.Circle(n.Arguments)
+		bad = err == nil && r <= 0
+	case *ast.CylinderPrimitive:
+		p, err := params.Cylinder(n.Arguments)
+		bad = err == nil && (p.H <= 0 || p.R1 < 0 || p.R2 < 0 || (p.R1 == 0 && p.R2 == 0))
+	case *ast.LinearExtrudeBlockPrimitive:
+		p, err := params.LinearExtrude(n.Arguments)
+		bad = err == nil && p.Height <= 0
+	}
+	if bad {
+		l.report(c.stmt, nil, "%v has a zero or negative size", header(c.exp))
+	}
+}
+
+func singularMatrix(l *linter, c *context) {
+	switch c.exp.(type) {
+	case *ast.MultmatrixBlockPrimitive, *ast.ScaleBlockPrimitive:
+	default:
+		return
+	}
+	m, err := transform.Of(c.exp, nil)
+	if err != nil {
+		return
+	}
+	if det(m) == 0 {
+		l.report(c.stmt, nil, "%v has a singular matrix (determinant 0), which collapses its children", c.exp.TokenLiteral())
+	}
+}
+
+// det returns the determinant of the linear part of the transform.
+func det(m transform.Matrix) float64 {
+	return m[0][0]*(m[1][1]*m[2][2]-m[1][2]*m[2][1]) -
+		m[0][1]*(m[1][0]*m[2][2]-m[1][2]*m[2][0]) +
+		m[0][2]*(m[1][0]*m[2][1]-m[1][1]*m[2][0])
+}
+
+func nonOverlappingDifference(l *linter, c *context) {
+	d, ok := c.exp.(*ast.DifferenceBlockPrimitive)
+	if !ok {
+		return
+	}
+	kids := children(c.exp)
+	if len(kids) < 2 {
+		return
+	}
+	a, ok := bounds(kids[0])
+	if !ok {
+		return
+	}
+	for _, kid := range kids[1:] {
+		b, ok := bounds(kid)
+		if !ok {
+			continue
+		}
+		overlap := !b.Empty()
+		for i := 0; i < 3; i++ {
+			if math.Min(a.Max[i], b.Max[i]) <= math.Max(a.Min[i], b.Min[i]) {
+				overlap = false
+			}
+		}
+		if overlap {
+			continue
+		}
+		kid := kid
+		fix := (&context{stmt: kid, siblings: &d.Body.Statements}).remove
+		l.report(kid, fix, "%v does not overlap the first child of the difference", unmodified(kid.Expression).TokenLiteral())
+	}
+}
+
+// bounds returns the bounding box of the node of a statement.
+func bounds(stmt *ast.ExpressionStatement) (solid.Box, bool) {
+	model, err := solid.New(&ast.Program{Statements: []ast.Statement{stmt}})
+	if err != nil {
+		return solid.Box{}, false
+	}
+	for i := 0; i < 3; i++ {
+		if math.IsInf(model.Bounds.Min[i], 0) || math.IsInf(model.Bounds.Max[i], 0) {
+			return solid.Box{}, false
+		}
+	}
+	return model.Bounds, true
+}
+
+func largeFn(l *linter, c *context) {
+	args, _, _ := ast.Parts(c.exp)
+	if len(args) == 0 {
+		return
+	}
+	a, err := params.Parse(args)
+	if err != nil {
+		return
+	}
+	obj, ok := a.Named["$fn"]
+	if !ok {
+		return
+	}
+	if fn, ok := params.ToFloat(obj); ok && fn > l.opts.MaxFn {
+		l.report(c.stmt, nil, "$fn = %v exceeds %v", fn, l.opts.MaxFn)
+	}
+}
+
+func mixedDimensions(l *linter, program *ast.Program) {
+	for _, err := range dims.Check(program).Errors {
+		if e, ok := err.(*dims.Error); ok {
+			l.report(e.Node, nil, "%v", e.Msg)
+		}
+	}
+}
+
+func unusedColor(l *linter, c *context) {
+	color, ok := c.exp.(*ast.ColorBlockPrimitive)
+	if !ok {
+		return
+	}
+	kids := children(color)
+	var reason string
+	switch {
+	case len(kids) == 0:
+		reason = "is empty"
+	case c.subtracted:
+		reason = "is within a subtrahend of a difference"
+	default:
+		reason = "is overridden by the colors of all of its children"
+		for _, kid := range kids {
+			if _, ok := unmodified(kid.Expression).(*ast.ColorBlockPrimitive); !ok {
+				reason = ""
+			}
+		}
+	}
+	if reason == "" {
+		return
+	}
+	b := color.Body
+	if b == nil {
+		b = &ast.BlockStatement{Token: lbraceToken}
+	}
+	fix := func() {
+		c.set(ast.NewGroup(b))
+	}
+	l.report(c.stmt, fix, "color %v", reason)
+}
+
+func matrixNoise(l *linter, c *context) {
+	n, ok := c.exp.(*ast.MultmatrixBlockPrimitive)
+	if !ok {
+		return
+	}
+	m, err := transform.Of(n, nil)
+	if err != nil {
+		return
+	}
+	var noisy bool
+	for i := range m {
+		for j := range m[i] {
+			v := m[i][j]
+			if r := math.Round(v); r != v && math.Abs(v-r) <= l.opts.Noise {
+				// A tiny scale is not noise if rounding it makes the
+				// matrix singular.
+				if m[i][j] = r; det(m) == 0 {
+					m[i][j] = v
+					continue
+				}
+				noisy = true
+			}
+		}
+	}
+	if !noisy {
+		return
+	}
+	fix := func() {
+		n.Arguments = []ast.Expression{transform.Literal(m)}
+	}
+	l.report(c.stmt, fix, "multmatrix has floating point noise")
+}
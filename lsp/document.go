@@ -0,0 +1,166 @@
+package lsp
+
+import (
+	"sort"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/gmlewis/go-csg/ast"
+	"github.com/gmlewis/go-csg/lexer"
+	"github.com/gmlewis/go-csg/parser"
+	"github.com/gmlewis/go-csg/token"
+)
+
+// document represents an open text document and its parsed program.
+type document struct {
+	uri     string
+	version int
+	text    string
+	program *ast.Program
+	errors  []parser.Error
+
+	tokens     []token.Token
+	index      map[int]int // token index by offset
+	lineStarts []int
+}
+
+func newDocument(uri string, version int, text string) *document {
+	le := lexer.New(text)
+	p := parser.New(le)
+	d := &document{
+		uri:     uri,
+		version: version,
+		text:    text,
+		program: p.ParseProgram(),
+		errors:  p.Diagnostics(),
+		index:   map[int]int{},
+	}
+
+	// The parser does not keep the positions of closing brackets
+	// and semicolons, so the tokens are read again to find where
+	// each node ends.
+	le = lexer.New(text)
+	for {
+		tok := le.NextToken()
+		if tok.Type == token.EOF {
+			break
+		}
+		d.index[tok.Pos.Offset] = len(d.tokens)
+		d.tokens = append(d.tokens, tok)
+	}
+
+	d.lineStarts = []int{0}
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\n' {
+			d.lineStarts = append(d.lineStarts, i+1)
+		}
+	}
+	return d
+}
+
+// position returns the LSP position of a byte offset.
+func (d *document) position(offset int) Position {
+	if offset > len(d.text) {
+		offset = len(d.text)
+	}
+	line := sort.SearchInts(d.lineStarts, offset+1) - 1
+	s := d.text[d.lineStarts[line]:offset]
+	var character int
+	for _, r := range s {
+		character += len(utf16.Encode([]rune{r}))
+	}
+	return Position{Line: line, Character: character}
+}
+
+// offset returns the byte offset of an LSP position.
+func (d *document) offset(pos Position) int {
+	if pos.Line < 0 {
+		return 0
+	}
+	if pos.Line >= len(d.lineStarts) {
+		return len(d.text)
+	}
+	offset := d.lineStarts[pos.Line]
+	for character := 0; character < pos.Character && offset < len(d.text) && d.text[offset] != '\n'; {
+		r, size := utf8.DecodeRuneInString(d.text[offset:])
+		character += len(utf16.Encode([]rune{r}))
+		offset += size
+	}
+	return offset
+}
+
+func (d *document) rangeOf(start, end int) Range {
+	return Range{Start: d.position(start), End: d.position(end)}
+}
+
+// tokenEnd returns the offset just past the token.
+func tokenEnd(tok token.Token) int {
+	if tok.Type == token.STRING {
+		return tok.Pos.Offset + len(tok.Literal) + 2 // quotes
+	}
+	return tok.Pos.Offset + len(tok.Literal)
+}
+
+// tokenAt returns the token containing the offset, if any.
+func (d *document) tokenAt(offset int) (token.Token, bool) {
+	i := sort.Search(len(d.tokens), func(i int) bool { return tokenEnd(d.tokens[i]) > offset })
+	if i < len(d.tokens) && d.tokens[i].Pos.Offset <= offset {
+		return d.tokens[i], true
+	}
+	return token.Token{}, false
+}
+
+// span returns the byte offsets of the start and end of a node whose
+// token is its first token (e.g. a keyword), which extends to its
+// last closing bracket before the end of its statement.
+func (d *document) span(node ast.Node) (start, end int, ok bool) {
+	p := ast.Pos(node)
+	i, ok := d.index[p.Offset]
+	if !p.IsValid() || !ok {
+		return 0, 0, false
+	}
+	start, end = p.Offset, tokenEnd(d.tokens[i])
+	var depth int
+	for _, tok := range d.tokens[i:] {
+		switch tok.Type {
+		case token.LPAREN, token.LBRACKET, token.LBRACE:
+			depth++
+		case token.RPAREN, token.RBRACKET, token.RBRACE:
+			depth--
+		case token.SEMICOLON, token.COMMA:
+			if depth == 0 {
+				return start, end, true
+			}
+		}
+		if depth < 0 {
+			break
+		}
+		end = tokenEnd(tok)
+		if depth == 0 && tok.Type == token.RBRACE {
+			break
+		}
+	}
+	return start, end, true
+}
+
+// pairs returns the tokens of each matching pair of brackets,
+// in the order of their closing brackets.
+func (d *document) pairs() [][2]token.Token {
+	var result [][2]token.Token
+	var stack []token.Token
+	closers := map[token.T]token.T{token.RPAREN: token.LPAREN, token.RBRACKET: token.LBRACKET, token.RBRACE: token.LBRACE}
+	for _, tok := range d.tokens {
+		switch tok.Type {
+		case token.LPAREN, token.LBRACKET, token.LBRACE:
+			stack = append(stack, tok)
+		case token.RPAREN, token.RBRACKET, token.RBRACE:
+			n := len(stack)
+			if n == 0 || stack[n-1].Type != closers[tok.Type] {
+				continue
+			}
+			result = append(result, [2]token.Token{stack[n-1], tok})
+			stack = stack[:n-1]
+		}
+	}
+	return result
+}
@@ -0,0 +1,272 @@
+package lsp
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gmlewis/go-csg/ast"
+	"github.com/gmlewis/go-csg/dims"
+	"github.com/gmlewis/go-csg/irmf"
+	"github.com/gmlewis/go-csg/params"
+	"github.com/gmlewis/go-csg/printer"
+	"github.com/gmlewis/go-csg/token"
+)
+
+// diagnostics returns the parse errors of the document or, if it
+// parses, the errors and warnings of its conversion to IRMF.
+func (d *document) diagnostics() []*Diagnostic {
+	result := []*Diagnostic{}
+	if len(d.errors) > 0 {
+		for _, e := range d.errors {
+			start := e.Pos.Offset
+			end := start
+			if tok, ok := d.tokenAt(start); ok {
+				end = tokenEnd(tok)
+			}
+			result = append(result, &Diagnostic{
+				Range:    d.rangeOf(start, end),
+				Severity: SeverityError,
+				Source:   "parser",
+				Message:  e.Msg,
+			})
+		}
+		return result
+	}
+
+	if info := dims.Check(d.program); len(info.Errors) > 0 {
+		for _, err := range info.Errors {
+			var r Range
+			if e, ok := err.(*dims.Error); ok {
+				if start, end, ok := d.span(e.Node); ok {
+					r = d.rangeOf(start, end)
+				}
+			}
+			result = append(result, &Diagnostic{Range: r, Severity: SeverityError, Source: "dims", Message: err.Error()})
+		}
+		return result
+	}
+
+	shader, err := irmf.New(d.program, false)
+	if err != nil {
+		return append(result, &Diagnostic{Severity: SeverityError, Source: "irmf", Message: err.Error()})
+	}
+	for _, w := range shader.Warnings {
+		result = append(result, &Diagnostic{Severity: SeverityWarning, Source: "irmf", Message: w})
+	}
+	return result
+}
+
+// nodeAt returns the innermost CSG primitive containing the offset.
+func (d *document) nodeAt(offset int) (exp ast.Expression, start, end int, ok bool) {
+	ast.Inspect(d.program, func(node ast.Node) bool {
+		e, isExp := node.(ast.Expression)
+		if !isExp {
+			return true
+		}
+		if _, _, isGeometry := ast.Parts(e); !isGeometry {
+			return true
+		}
+		s, en, found := d.span(e)
+		if !found || offset < s || offset > en {
+			return true
+		}
+		exp, start, end, ok = e, s, en, true
+		return true
+	})
+	return exp, start, end, ok
+}
+
+// hover returns the evaluated parameters and the MBB (within its
+// parent's coordinates) of the CSG primitive at the offset.
+func (d *document) hover(offset int) *Hover {
+	exp, start, end, ok := d.nodeAt(offset)
+	if !ok {
+		return nil
+	}
+	name := exp.TokenLiteral()
+	args, _, _ := ast.Parts(exp)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "**%v**\n\n", name)
+	if a, err := params.Parse(args); err != nil {
+		fmt.Fprintf(&buf, "Parameters: %v\n\n", err)
+	} else if len(a.Positional) > 0 || len(a.Named) > 0 {
+		buf.WriteString("```\n")
+		for i, obj := range a.Positional {
+			fmt.Fprintf(&buf, "%v: %v\n", i, obj.Inspect())
+		}
+		var names []string
+		for k := range a.Named {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+		for _, k := range names {
+			fmt.Fprintf(&buf, "%v = %v\n", k, a.Named[k].Inspect())
+		}
+		buf.WriteString("```\n\n")
+	}
+
+	program := &ast.Program{Statements: []ast.Statement{&ast.ExpressionStatement{Expression: exp}}}
+	shader, err := irmf.New(program, false)
+	switch {
+	case err != nil:
+		fmt.Fprintf(&buf, "MBB: %v\n", err)
+	case shader.MBB != nil:
+		mbb := shader.MBB
+		fmt.Fprintf(&buf, "MBB: [%v, %v, %v] to [%v, %v, %v]\n", mbb.XMin, mbb.YMin, mbb.ZMin, mbb.XMax, mbb.YMax, mbb.ZMax)
+	}
+
+	r := d.rangeOf(start, end)
+	return &Hover{Contents: MarkupContent{Kind: "markdown", Value: buf.String()}, Range: &r}
+}
+
+// symbols returns the outline of the statements.
+func (d *document) symbols(stmts []ast.Statement) []*DocumentSymbol {
+	result := []*DocumentSymbol{}
+	for _, stmt := range stmts {
+		switch stmt := stmt.(type) {
+		case *ast.ExpressionStatement:
+			if sym := d.symbol(stmt.Expression, ""); sym != nil {
+				result = append(result, sym)
+			}
+		case *ast.LetStatement:
+			start, end, ok := d.span(stmt)
+			if !ok || stmt.Name == nil {
+				continue
+			}
+			kind := SymbolKindVariable
+			if _, ok := stmt.Value.(*ast.FunctionLiteral); ok {
+				kind = SymbolKindFunction
+			}
+			result = append(result, &DocumentSymbol{
+				Name:           stmt.Name.Value,
+				Kind:           kind,
+				Range:          d.rangeOf(start, end),
+				SelectionRange: d.identRange(stmt.Name),
+			})
+		}
+	}
+	return result
+}
+
+func (d *document) symbol(exp ast.Expression, modifier string) *DocumentSymbol {
+	if me, ok := exp.(*ast.ModifierExpression); ok {
+		return d.symbol(me.Right, modifier+me.Modifier)
+	}
+	args, body, ok := ast.Parts(exp)
+	if !ok {
+		return nil
+	}
+	name := exp.TokenLiteral()
+	start, end, ok := d.span(exp)
+	if !ok {
+		return nil
+	}
+	var detail []string
+	for _, arg := range args {
+		detail = append(detail, arg.String())
+	}
+	sym := &DocumentSymbol{
+		Name:           modifier + name,
+		Detail:         strings.Join(detail, ", "),
+		Kind:           SymbolKindObject,
+		Range:          d.rangeOf(start, end),
+		SelectionRange: d.rangeOf(start, start+len(exp.TokenLiteral())),
+	}
+	if body != nil {
+		sym.Kind = SymbolKindStruct
+		sym.Children = d.symbols(body.Statements)
+	}
+	return sym
+}
+
+func (d *document) identRange(ident *ast.Identifier) Range {
+	start := ident.Token.Pos.Offset
+	return d.rangeOf(start, start+len(ident.Value))
+}
+
+// foldingRanges returns the bracketed ranges that span multiple
+// lines, keeping the widest range that starts on each line.
+func (d *document) foldingRanges() []*FoldingRange {
+	ends := map[int]int{}
+	for _, pair := range d.pairs() {
+		start, end := pair[0].Pos.Line-1, pair[1].Pos.Line-2 // keep the closing line visible
+		if end > start && end > ends[start] {
+			ends[start] = end
+		}
+	}
+	result := []*FoldingRange{}
+	for start, end := range ends {
+		result = append(result, &FoldingRange{StartLine: start, EndLine: end})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].StartLine < result[j].StartLine })
+	return result
+}
+
+// definition returns the location of the function parameter or let
+// statement that defines the identifier at the offset.
+func (d *document) definition(offset int) *Location {
+	tok, ok := d.tokenAt(offset)
+	if !ok || tok.Type != token.IDENT {
+		return nil
+	}
+
+	var param *ast.Identifier
+	var before, after *ast.Identifier
+	ast.Inspect(d.program, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.FunctionLiteral:
+			if start, end, ok := d.span(n); ok && start <= offset && offset <= end {
+				for _, p := range n.Parameters {
+					if p.Value == tok.Literal {
+						param = p
+					}
+				}
+			}
+		case *ast.LetStatement:
+			if n.Name == nil || n.Name.Value != tok.Literal {
+				break
+			}
+			if n.Name.Token.Pos.Offset <= offset {
+				before = n.Name
+			} else if after == nil {
+				after = n.Name
+			}
+		}
+		return true
+	})
+
+	ident := param
+	if ident == nil {
+		ident = before
+	}
+	if ident == nil {
+		ident = after
+	}
+	if ident == nil {
+		return nil
+	}
+	return &Location{URI: d.uri, Range: d.identRange(ident)}
+}
+
+// format returns the edits that print the document with the CSG
+// printer, or nil if it does not parse.
+func (d *document) format(tabSize int, insertSpaces bool) ([]*TextEdit, error) {
+	if len(d.errors) > 0 {
+		return nil, nil
+	}
+	config := &printer.Config{Indent: "\t", Precision: printer.DefaultConfig.Precision}
+	if insertSpaces {
+		config.Indent = strings.Repeat(" ", tabSize)
+	}
+	var buf bytes.Buffer
+	if err := config.Fprint(&buf, d.program); err != nil {
+		return nil, err
+	}
+	if buf.String() == d.text {
+		return []*TextEdit{}, nil
+	}
+	return []*TextEdit{{Range: d.rangeOf(0, len(d.text)), NewText: buf.String()}}, nil
+}
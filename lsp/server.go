@@ -0,0 +1,201 @@
+// Package lsp implements a Language Server Protocol server for CSG
+// files, which provides diagnostics, hover information, document
+// symbols, folding ranges, go-to-definition and formatting to editors.
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrNoShutdown is returned by Serve when the client
+// exits without first requesting a shutdown.
+var ErrNoShutdown = errors.New("exit without shutdown")
+
+// Server represents a language server that communicates
+// over a stream (e.g. standard input and output).
+type Server struct {
+	in  *bufio.Reader
+	out io.Writer
+
+	docs     map[string]*document
+	shutdown bool
+	exited   bool
+}
+
+// NewServer returns a new Server that reads messages
+// from in and writes messages to out.
+func NewServer(in io.Reader, out io.Writer) *Server {
+	return &Server{
+		in:   bufio.NewReader(in),
+		out:  out,
+		docs: map[string]*document{},
+	}
+}
+
+// Serve handles messages until the client exits or the input ends.
+func (s *Server) Serve() error {
+	for !s.exited {
+		body, err := readMessage(s.in)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var req request
+		if err := json.Unmarshal(body, &req); err != nil {
+			if err := s.reply(nil, nil, &responseError{Code: codeParseError, Message: err.Error()}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		result, err := s.handle(&req)
+		if req.ID == nil { // notification
+			if _, ok := err.(*responseError); err != nil && !ok {
+				return err
+			}
+			continue
+		}
+		if err := s.reply(req.ID, result, err); err != nil {
+			return err
+		}
+	}
+	if !s.shutdown {
+		return ErrNoShutdown
+	}
+	return nil
+}
+
+func (s *Server) reply(id *json.RawMessage, result interface{}, err error) error {
+	if err == nil {
+		return writeMessage(s.out, &response{JSONRPC: "2.0", ID: id, Result: result})
+	}
+	re, ok := err.(*responseError)
+	if !ok {
+		re = &responseError{Code: codeInternalError, Message: err.Error()}
+	}
+	return writeMessage(s.out, &errorResponse{JSONRPC: "2.0", ID: id, Error: re})
+}
+
+func (s *Server) notify(method string, params interface{}) error {
+	return writeMessage(s.out, &notification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+var capabilities = map[string]interface{}{
+	"capabilities": map[string]interface{}{
+		"textDocumentSync":           1, // full
+		"hoverProvider":              true,
+		"documentSymbolProvider":     true,
+		"foldingRangeProvider":       true,
+		"definitionProvider":         true,
+		"documentFormattingProvider": true,
+	},
+	"serverInfo": map[string]string{"name": "csg-lsp"},
+}
+
+func (s *Server) handle(req *request) (interface{}, error) {
+	switch req.Method {
+	case "initialize":
+		return capabilities, nil
+	case "initialized":
+		return nil, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "exit":
+		s.exited = true
+		return nil, nil
+
+	case "textDocument/didOpen":
+		var p didOpenParams
+		if err := unmarshal(req.Params, &p); err != nil {
+			return nil, err
+		}
+		return nil, s.update(newDocument(p.TextDocument.URI, p.TextDocument.Version, p.TextDocument.Text))
+	case "textDocument/didChange":
+		var p didChangeParams
+		if err := unmarshal(req.Params, &p); err != nil {
+			return nil, err
+		}
+		if n := len(p.ContentChanges); n > 0 {
+			return nil, s.update(newDocument(p.TextDocument.URI, p.TextDocument.Version, p.ContentChanges[n-1].Text))
+		}
+		return nil, nil
+	case "textDocument/didClose":
+		var p didCloseParams
+		if err := unmarshal(req.Params, &p); err != nil {
+			return nil, err
+		}
+		delete(s.docs, p.TextDocument.URI)
+		return nil, s.notify("textDocument/publishDiagnostics", &publishDiagnosticsParams{URI: p.TextDocument.URI, Diagnostics: []*Diagnostic{}})
+
+	case "textDocument/hover":
+		var p textDocumentPositionParams
+		d, err := s.document(req.Params, &p, &p.TextDocument)
+		if err != nil {
+			return nil, err
+		}
+		return d.hover(d.offset(p.Position)), nil
+	case "textDocument/definition":
+		var p textDocumentPositionParams
+		d, err := s.document(req.Params, &p, &p.TextDocument)
+		if err != nil {
+			return nil, err
+		}
+		return d.definition(d.offset(p.Position)), nil
+	case "textDocument/documentSymbol":
+		var p documentParams
+		d, err := s.document(req.Params, &p, &p.TextDocument)
+		if err != nil {
+			return nil, err
+		}
+		return d.symbols(d.program.Statements), nil
+	case "textDocument/foldingRange":
+		var p documentParams
+		d, err := s.document(req.Params, &p, &p.TextDocument)
+		if err != nil {
+			return nil, err
+		}
+		return d.foldingRanges(), nil
+	case "textDocument/formatting":
+		var p formattingParams
+		d, err := s.document(req.Params, &p, &p.TextDocument)
+		if err != nil {
+			return nil, err
+		}
+		return d.format(p.Options.TabSize, p.Options.InsertSpaces)
+	}
+	return nil, &responseError{Code: codeMethodNotFound, Message: fmt.Sprintf("method not found: %v", req.Method)}
+}
+
+func unmarshal(params json.RawMessage, v interface{}) error {
+	if err := json.Unmarshal(params, v); err != nil {
+		return &responseError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+// document unmarshals the params into v and returns the open
+// document that they identify.
+func (s *Server) document(params json.RawMessage, v interface{}, id *textDocumentIdentifier) (*document, error) {
+	if err := unmarshal(params, v); err != nil {
+		return nil, err
+	}
+	d, ok := s.docs[id.URI]
+	if !ok {
+		return nil, &responseError{Code: codeInvalidParams, Message: fmt.Sprintf("unknown document: %v", id.URI)}
+	}
+	return d, nil
+}
+
+// update replaces the document and publishes its diagnostics.
+func (s *Server) update(d *document) error {
+	s.docs[d.uri] = d
+	return s.notify("textDocument/publishDiagnostics", &publishDiagnosticsParams{URI: d.uri, Diagnostics: d.diagnostics()})
+}
@@ -0,0 +1,288 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+const uri = "file:///test.csg"
+
+const src = `group() {
+	multmatrix([[1, 0, 0, 5], [0, 1, 0, 0], [0, 0, 1, 0], [0, 0, 0, 1]]) {
+		cube(size = [1, 2, 3], center = false);
+	}
+	%sphere(r = 1);
+}
+`
+
+func serve(t *testing.T, msgs ...string) ([]map[string]interface{}, error) {
+	t.Helper()
+	var in bytes.Buffer
+	for _, msg := range msgs {
+		fmt.Fprintf(&in, "Content-Length: %v\r\n\r\n%v", len(msg), msg)
+	}
+	var out bytes.Buffer
+	err := NewServer(&in, &out).Serve()
+
+	var got []map[string]interface{}
+	r := bufio.NewReader(&out)
+	for {
+		body, err := readMessage(r)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("readMessage: %v", err)
+		}
+		var m map[string]interface{}
+		if err := json.Unmarshal(body, &m); err != nil {
+			t.Fatalf("Unmarshal: %v", err)
+		}
+		got = append(got, m)
+	}
+	return got, err
+}
+
+func didOpen(text string) string {
+	b, _ := json.Marshal(text)
+	return fmt.Sprintf(`{"jsonrpc":"2.0","method":"textDocument/didOpen","params":{"textDocument":{"uri":%q,"version":1,"text":%s}}}`, uri, b)
+}
+
+func TestServe(t *testing.T) {
+	tests := []struct {
+		msgs    []string
+		want    []string // JSON of each message written by the server
+		wantErr error
+	}{
+		{
+			msgs: []string{
+				didOpen("cube(size = 1);\nsquare(size = );\n"),
+				`{"jsonrpc":"2.0","id":1,"method":"shutdown"}`,
+				`{"jsonrpc":"2.0","method":"exit"}`,
+			},
+			want: []string{
+				`{"jsonrpc":"2.0","method":"textDocument/publishDiagnostics","params":{"diagnostics":[{"message":"no prefix parse function for ) found","range":{"end":{"character":15,"line":1},"start":{"character":14,"line":1}},"severity":1,"source":"parser"},{"message":"expected next token to be ), got ;","range":{"end":{"character":16,"line":1},"start":{"character":15,"line":1}},"severity":1,"source":"parser"}],"uri":"file:///test.csg"}}`,
+				`{"id":1,"jsonrpc":"2.0","result":null}`,
+			},
+		},
+		{
+			msgs: []string{
+				didOpen("circle(r = 1);\ncube(size = 1);\n"),
+				`{"jsonrpc":"2.0","id":"a","method":"textDocument/unknown"}`,
+				`{"jsonrpc":"2.0","method":"exit"}`,
+			},
+			want: []string{
+				`{"jsonrpc":"2.0","method":"textDocument/publishDiagnostics","params":{"diagnostics":[{"message":"top level: mixing 2D and 3D objects is not supported: circle is 2D but cube is 3D","range":{"end":{"character":0,"line":0},"start":{"character":0,"line":0}},"severity":1,"source":"dims"}],"uri":"file:///test.csg"}}`,
+				`{"error":{"code":-32601,"message":"method not found: textDocument/unknown"},"id":"a","jsonrpc":"2.0"}`,
+			},
+			wantErr: ErrNoShutdown,
+		},
+		{
+			msgs: []string{
+				didOpen("multmatrix() {\n\tcube(size = 1);\n}\n"),
+				`{"jsonrpc":"2.0","method":"exit"}`,
+			},
+			want: []string{
+				`{"jsonrpc":"2.0","method":"textDocument/publishDiagnostics","params":{"diagnostics":[{"message":"multmatrix: expected a 4x4 matrix, got 0 rows","range":{"end":{"character":0,"line":0},"start":{"character":0,"line":0}},"severity":1,"source":"irmf"}],"uri":"file:///test.csg"}}`,
+			},
+			wantErr: ErrNoShutdown,
+		},
+		{
+			msgs: []string{
+				didOpen(src),
+				`{"jsonrpc":"2.0","id":2,"method":"textDocument/definition","params":{"textDocument":{"uri":"file:///other.csg"},"position":{"line":0,"character":0}}}`,
+			},
+			want: []string{
+				`{"jsonrpc":"2.0","method":"textDocument/publishDiagnostics","params":{"diagnostics":[],"uri":"file:///test.csg"}}`,
+				`{"error":{"code":-32602,"message":"unknown document: file:///other.csg"},"id":2,"jsonrpc":"2.0"}`,
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test #%v", i), func(t *testing.T) {
+			msgs, err := serve(t, tt.msgs...)
+			if err != tt.wantErr {
+				t.Errorf("Serve = %v, want %v", err, tt.wantErr)
+			}
+			var got []string
+			for _, m := range msgs {
+				b, _ := json.Marshal(m)
+				got = append(got, string(b))
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("messages =\n%v\nwant:\n%v", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestHover(t *testing.T) {
+	tests := []struct {
+		pos  Position
+		want string
+	}{
+		{
+			pos:  Position{Line: 2, Character: 4},
+			want: "**cube**\n\n```\ncenter = false\nsize = [1, 2, 3]\n```\n\nMBB: [0, 0, 0] to [1, 2, 3]\n",
+		},
+		{
+			pos:  Position{Line: 3, Character: 1},
+			want: "**multmatrix**\n\n```\n0: [[1, 0, 0, 5], [0, 1, 0, 0], [0, 0, 1, 0], [0, 0, 0, 1]]\n```\n\nMBB: [5, 0, 0] to [6, 2, 3]\n",
+		},
+		{
+			pos: Position{Line: 6, Character: 0},
+		},
+	}
+
+	d := newDocument(uri, 1, src)
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test #%v", i), func(t *testing.T) {
+			var got string
+			if h := d.hover(d.offset(tt.pos)); h != nil {
+				got = h.Contents.Value
+			}
+			if got != tt.want {
+				t.Errorf("hover =\n%v\nwant:\n%v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSymbols(t *testing.T) {
+	d := newDocument(uri, 1, "let r = 2;\n"+src)
+
+	var lines []string
+	var walk func(syms []*DocumentSymbol, indent string)
+	walk = func(syms []*DocumentSymbol, indent string) {
+		for _, sym := range syms {
+			lines = append(lines, fmt.Sprintf("%v%v (%v) %v:%v-%v:%v", indent, sym.Name, sym.Kind,
+				sym.Range.Start.Line, sym.Range.Start.Character, sym.Range.End.Line, sym.Range.End.Character))
+			walk(sym.Children, indent+"  ")
+		}
+	}
+	walk(d.symbols(d.program.Statements), "")
+
+	want := []string{
+		"r (13) 0:0-0:9",
+		"group (23) 1:0-6:1",
+		"  multmatrix (23) 2:1-4:2",
+		"    cube (19) 3:2-3:40",
+		"  %sphere (19) 5:2-5:15",
+	}
+	if got := strings.Join(lines, "\n"); got != strings.Join(want, "\n") {
+		t.Errorf("symbols =\n%v\nwant:\n%v", got, strings.Join(want, "\n"))
+	}
+}
+
+func TestFoldingRanges(t *testing.T) {
+	d := newDocument(uri, 1, src+"polygon(points = [\n\t[0, 0],\n\t[1, 0],\n\t[0, 1]\n]);\n")
+	var got []string
+	for _, fr := range d.foldingRanges() {
+		got = append(got, fmt.Sprintf("%v-%v", fr.StartLine, fr.EndLine))
+	}
+	if want := "0-4 1-2 6-9"; strings.Join(got, " ") != want {
+		t.Errorf("foldingRanges = %v, want %v", strings.Join(got, " "), want)
+	}
+}
+
+func TestDefinition(t *testing.T) {
+	text := `let r = 1;
+let f = function(r) { r * 2; };
+let r = 2;
+sphere(r = f(r));
+`
+	tests := []struct {
+		pos  Position
+		want string
+	}{
+		{pos: Position{Line: 3, Character: 13}, want: "2:4-2:5"},   // r, after its second let
+		{pos: Position{Line: 3, Character: 11}, want: "1:4-1:5"},   // f
+		{pos: Position{Line: 1, Character: 22}, want: "1:17-1:18"}, // r, within f
+		{pos: Position{Line: 0, Character: 4}, want: "0:4-0:5"},    // r, at its first let
+		{pos: Position{Line: 3, Character: 0}},                     // sphere
+	}
+
+	d := newDocument(uri, 1, text)
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test #%v", i), func(t *testing.T) {
+			var got string
+			if loc := d.definition(d.offset(tt.pos)); loc != nil {
+				r := loc.Range
+				got = fmt.Sprintf("%v:%v-%v:%v", r.Start.Line, r.Start.Character, r.End.Line, r.End.Character)
+			}
+			if got != tt.want {
+				t.Errorf("definition = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		text         string
+		insertSpaces bool
+		want         []*TextEdit
+	}{
+		{
+			text: src,
+			want: []*TextEdit{},
+		},
+		{
+			text:         "group(){cube(size=1);}",
+			insertSpaces: true,
+			want: []*TextEdit{{
+				Range:   Range{End: Position{Character: 22}},
+				NewText: "group() {\n  cube(size = 1);\n}\n",
+			}},
+		},
+		{
+			text: "cube(size = );",
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test #%v", i), func(t *testing.T) {
+			d := newDocument(uri, 1, tt.text)
+			got, err := d.format(2, tt.insertSpaces)
+			if err != nil {
+				t.Fatalf("format: %v", err)
+			}
+			gotJSON, _ := json.Marshal(got)
+			wantJSON, _ := json.Marshal(tt.want)
+			if string(gotJSON) != string(wantJSON) {
+				t.Errorf("format = %s, want %s", gotJSON, wantJSON)
+			}
+		})
+	}
+}
+
+func TestPosition(t *testing.T) {
+	d := newDocument(uri, 1, "// héllo 𝄞\ncube(size = 1);\n")
+	tests := []struct {
+		offset int
+		want   Position
+	}{
+		{offset: 0, want: Position{}},
+		{offset: 10, want: Position{Line: 0, Character: 9}},
+		{offset: 14, want: Position{Line: 0, Character: 11}},
+		{offset: 15, want: Position{Line: 1, Character: 0}},
+		{offset: 19, want: Position{Line: 1, Character: 4}},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test #%v", i), func(t *testing.T) {
+			if got := d.position(tt.offset); got != tt.want {
+				t.Errorf("position(%v) = %+v, want %+v", tt.offset, got, tt.want)
+			}
+			if got := d.offset(tt.want); got != tt.offset {
+				t.Errorf("offset(%+v) = %v, want %v", tt.want, got, tt.offset)
+			}
+		})
+	}
+}
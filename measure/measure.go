@@ -0,0 +1,309 @@
+// Package measure computes the physical properties of a CSG model
+// (volume, surface area, mass, center of mass, moments of inertia and
+// bounding box) for each of its materials, by integrating over a
+// solid.Model, along with statistics of its CSG tree.
+package measure
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+
+	"github.com/gmlewis/go-csg/ast"
+	"github.com/gmlewis/go-csg/mesh"
+	"github.com/gmlewis/go-csg/solid"
+)
+
+// DefaultCells is the approximate number of voxels used when
+// Options.Resolution is zero.
+const DefaultCells = 1000000
+
+// Options represents the options of Measure.
+type Options struct {
+	// Resolution is the size (in model units) of the voxels, and of the
+	// cells of the tessellation that measures the surface area. Zero
+	// divides the bounding box into about DefaultCells voxels.
+	Resolution float64
+	// Samples selects Monte Carlo integration with this many random
+	// points instead of voxel integration.
+	Samples int
+	// Seed seeds the random points of Monte Carlo integration.
+	Seed int64
+	// Density is the density (in mass per cubic model unit) of each
+	// material, by name. The mass of a material without a density is
+	// unknown, and its moments of inertia are per unit density.
+	Density map[string]float64
+}
+
+// Properties represents the physical properties of a material,
+// or of the whole model.
+type Properties struct {
+	Material string     `json:"material,omitempty"`
+	Color    [4]float64 `json:"color,omitempty"`
+	Volume   float64    `json:"volume"`
+	// VolumeError is the standard error of the volume of Monte Carlo
+	// integration.
+	VolumeError  float64    `json:"volumeError,omitempty"`
+	Area         float64    `json:"area"`
+	Mass         float64    `json:"mass,omitempty"`
+	CenterOfMass [3]float64 `json:"centerOfMass"`
+	// Inertia is the inertia tensor about the center of mass.
+	Inertia [3][3]float64 `json:"inertia"`
+	Min     [3]float64    `json:"min"`
+	Max     [3]float64    `json:"max"`
+}
+
+// Report represents the measurements of a model.
+type Report struct {
+	Method     string        `json:"method"` // "voxel" or "monte-carlo"
+	Resolution float64       `json:"resolution"`
+	Samples    int           `json:"samples"`
+	Min        [3]float64    `json:"min"`
+	Max        [3]float64    `json:"max"`
+	Materials  []*Properties `json:"materials"`
+	Total      *Properties   `json:"total"`
+	Tree       *Tree         `json:"tree"`
+}
+
+// Measure measures the program.
+func Measure(program *ast.Program, opts *Options) (*Report, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	if opts.Resolution < 0 || opts.Samples < 0 {
+		return nil, errors.New("resolution and samples must not be negative")
+	}
+	model, err := solid.New(program)
+	if err != nil {
+		return nil, err
+	}
+	box := model.Bounds
+	if box.Empty() {
+		return nil, errors.New("the model is empty")
+	}
+	size := solid.Vec3{box.Max[0] - box.Min[0], box.Max[1] - box.Min[1], box.Max[2] - box.Min[2]}
+	for _, v := range size {
+		if math.IsInf(v, 0) {
+			return nil, errors.New("the model is unbounded")
+		}
+	}
+
+	r := &Report{
+		Method:     "voxel",
+		Resolution: opts.Resolution,
+		Min:        box.Min,
+		Max:        box.Max,
+		Tree:       TreeOf(program),
+	}
+	if r.Resolution == 0 {
+		r.Resolution = box.CellSize(DefaultCells)
+	}
+
+	var ms []*moments
+	if opts.Samples > 0 {
+		r.Method, r.Samples = "monte-carlo", opts.Samples
+		ms = monteCarlo(model, opts.Samples, opts.Seed)
+	} else {
+		var n int
+		ms, n = voxels(model, r.Resolution)
+		r.Samples = n
+	}
+
+	m, err := mesh.Tessellate(model, r.Resolution)
+	if err != nil {
+		return nil, err
+	}
+	areas := map[string]float64{}
+	for _, obj := range m.Objects {
+		areas[obj.Name] = obj.Area()
+	}
+
+	boxVolume := size[0] * size[1] * size[2]
+	total := newMoments()
+	var totalHits int
+	totalMass, allMasses := 0.0, true
+	for i, mat := range model.Materials {
+		mo := ms[i]
+		if mo.hits == 0 {
+			continue
+		}
+		density, ok := opts.Density[mat.Name]
+		if !ok {
+			density, allMasses = 1, false
+		}
+		mo = mo.scaled(density)
+		p := mo.properties()
+		p.Material, p.Color, p.Area = mat.Name, mat.Color, areas[mat.Name]
+		if ok {
+			p.Mass = mo.mass
+			totalMass += mo.mass
+		}
+		if opts.Samples > 0 {
+			p.VolumeError = standardError(boxVolume, mo.hits, opts.Samples)
+		}
+		r.Materials = append(r.Materials, p)
+		total.add(mo)
+		totalHits += mo.hits
+	}
+
+	r.Total = total.properties()
+	for _, p := range r.Materials {
+		r.Total.Area += p.Area
+	}
+	if allMasses {
+		r.Total.Mass = totalMass
+	}
+	if opts.Samples > 0 {
+		r.Total.VolumeError = standardError(boxVolume, totalHits, opts.Samples)
+	}
+	return r, nil
+}
+
+// standardError returns the standard error of the volume of hits of
+// n random points within a box.
+func standardError(boxVolume float64, hits, n int) float64 {
+	p := float64(hits) / float64(n)
+	return boxVolume * math.Sqrt(p*(1-p)/float64(n))
+}
+
+// moments represents the integrals over the volume of a material,
+// weighted by its density.
+type moments struct {
+	hits   int
+	volume float64       // integral of 1
+	mass   float64       // integral of density
+	first  [3]float64    // integral of density * x_i
+	second [3][3]float64 // integral of density * x_i * x_j
+	min    [3]float64
+	max    [3]float64
+}
+
+func newMoments() *moments {
+	inf := math.Inf(1)
+	return &moments{min: [3]float64{inf, inf, inf}, max: [3]float64{-inf, -inf, -inf}}
+}
+
+// sample adds a cell of volume v centered at p, whose size
+// (for voxels) is d.
+func (m *moments) sample(p solid.Vec3, v float64, d [3]float64) {
+	m.hits++
+	m.volume += v
+	m.mass += v
+	for i := 0; i < 3; i++ {
+		m.first[i] += v * p[i]
+		for j := 0; j < 3; j++ {
+			m.second[i][j] += v * p[i] * p[j]
+		}
+		m.second[i][i] += v * d[i] * d[i] / 12
+		m.min[i] = math.Min(m.min[i], p[i]-d[i]/2)
+		m.max[i] = math.Max(m.max[i], p[i]+d[i]/2)
+	}
+}
+
+func (m *moments) add(o *moments) {
+	m.hits += o.hits
+	m.volume += o.volume
+	m.mass += o.mass
+	for i := 0; i < 3; i++ {
+		m.first[i] += o.first[i]
+		for j := 0; j < 3; j++ {
+			m.second[i][j] += o.second[i][j]
+		}
+		m.min[i] = math.Min(m.min[i], o.min[i])
+		m.max[i] = math.Max(m.max[i], o.max[i])
+	}
+}
+
+// scaled returns the moments of the material with the density.
+func (m *moments) scaled(density float64) *moments {
+	s := *m
+	s.mass *= density
+	for i := 0; i < 3; i++ {
+		s.first[i] *= density
+		for j := 0; j < 3; j++ {
+			s.second[i][j] *= density
+		}
+	}
+	return &s
+}
+
+func (m *moments) properties() *Properties {
+	p := &Properties{Volume: m.volume, Min: m.min, Max: m.max}
+	if m.mass == 0 {
+		return p
+	}
+	var c [3][3]float64 // central second moments
+	for i := 0; i < 3; i++ {
+		p.CenterOfMass[i] = m.first[i] / m.mass
+	}
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			c[i][j] = m.second[i][j] - m.mass*p.CenterOfMass[i]*p.CenterOfMass[j]
+		}
+	}
+	trace := c[0][0] + c[1][1] + c[2][2]
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			p.Inertia[i][j] = -c[i][j]
+		}
+		p.Inertia[i][i] = trace - c[i][i]
+	}
+	return p
+}
+
+// voxels integrates the model over a grid of cells of about the given
+// size, and returns the moments of each material and the number of cells.
+func voxels(model *solid.Model, size float64) ([]*moments, int) {
+	g := solid.NewGrid(model.Bounds, size)
+	v := g.Size[0] * g.Size[1] * g.Size[2]
+
+	layers := make([][]*moments, g.N[2])
+	for k := range layers {
+		layers[k] = make([]*moments, len(model.Materials))
+		for i := range layers[k] {
+			layers[k][i] = newMoments()
+		}
+	}
+	g.Sample(func(k int, p solid.Vec3) {
+		if mat := model.At(p); mat >= 0 {
+			layers[k][mat].sample(p, v, g.Size)
+		}
+	})
+
+	result := make([]*moments, len(model.Materials))
+	for i := range result {
+		result[i] = newMoments()
+	}
+	for _, ms := range layers {
+		for i, m := range ms {
+			result[i].add(m)
+		}
+	}
+	return result, g.Cells()
+}
+
+// monteCarlo integrates the model over n random points within its bounds.
+func monteCarlo(model *solid.Model, n int, seed int64) []*moments {
+	box := model.Bounds
+	var size [3]float64
+	for i := 0; i < 3; i++ {
+		size[i] = box.Max[i] - box.Min[i]
+	}
+	v := size[0] * size[1] * size[2] / float64(n)
+
+	result := make([]*moments, len(model.Materials))
+	for i := range result {
+		result[i] = newMoments()
+	}
+	rnd := rand.New(rand.NewSource(seed))
+	for s := 0; s < n; s++ {
+		var p solid.Vec3
+		for i := 0; i < 3; i++ {
+			p[i] = box.Min[i] + rnd.Float64()*size[i]
+		}
+		if mat := model.At(p); mat >= 0 {
+			result[mat].sample(p, v, [3]float64{})
+		}
+	}
+	return result
+}
@@ -0,0 +1,161 @@
+package measure
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/gmlewis/go-csg/ast"
+	"github.com/gmlewis/go-csg/lexer"
+	"github.com/gmlewis/go-csg/parser"
+)
+
+func parse(t *testing.T, src string) *ast.Program {
+	t.Helper()
+	le := lexer.New(src)
+	p := parser.New(le)
+	program := p.ParseProgram()
+	if errs := p.Errors(); len(errs) != 0 {
+		t.Fatalf("ParseProgram: %v", strings.Join(errs, "\n"))
+	}
+	return program
+}
+
+func near(got, want, tolerance float64) bool {
+	return math.Abs(got-want) <= tolerance*math.Max(1, math.Abs(want))
+}
+
+func TestMeasure(t *testing.T) {
+	const twoCubes = `color([1, 0, 0, 1]) { cube(size = [10, 10, 10], center = false); }
+color([0, 0, 1, 1]) { translate([10, 0, 0]) { cube(size = [10, 10, 10], center = false); } }`
+
+	tests := []struct {
+		src       string
+		opts      *Options
+		tolerance float64
+		want      []*Properties // the materials, then the total
+	}{
+		{
+			src:       "cube(size = [10, 10, 10], center = false);",
+			opts:      &Options{Resolution: 0.5, Density: map[string]float64{"PLA": 0.00124}},
+			tolerance: 0.03,
+			want: []*Properties{
+				{Material: "PLA", Volume: 1000, Area: 600, Mass: 1.24, CenterOfMass: [3]float64{5, 5, 5},
+					Inertia: [3][3]float64{{20.67, 0, 0}, {0, 20.67, 0}, {0, 0, 20.67}}, Max: [3]float64{10, 10, 10}},
+				{Volume: 1000, Area: 600, Mass: 1.24, CenterOfMass: [3]float64{5, 5, 5},
+					Inertia: [3][3]float64{{20.67, 0, 0}, {0, 20.67, 0}, {0, 0, 20.67}}, Max: [3]float64{10, 10, 10}},
+			},
+		},
+		{
+			src:       twoCubes,
+			opts:      &Options{Resolution: 0.5, Density: map[string]float64{"color_FF0000FF": 1}},
+			tolerance: 0.03,
+			want: []*Properties{
+				{Material: "color_FF0000FF", Volume: 1000, Area: 600, Mass: 1000, CenterOfMass: [3]float64{5, 5, 5},
+					Inertia: [3][3]float64{{16667, 0, 0}, {0, 16667, 0}, {0, 0, 16667}}, Max: [3]float64{10, 10, 10}},
+				{Material: "color_0000FFFF", Volume: 1000, Area: 600, CenterOfMass: [3]float64{15, 5, 5},
+					Inertia: [3][3]float64{{16667, 0, 0}, {0, 16667, 0}, {0, 0, 16667}}, Min: [3]float64{10, 0, 0}, Max: [3]float64{20, 10, 10}},
+				{Volume: 2000, Area: 1200, CenterOfMass: [3]float64{10, 5, 5},
+					Inertia: [3][3]float64{{33333, 0, 0}, {0, 83333, 0}, {0, 0, 83333}}, Max: [3]float64{20, 10, 10}},
+			},
+		},
+		{
+			src:       "sphere($fn = 0, $fa = 12, $fs = 2, r = 5);",
+			opts:      &Options{Samples: 200000, Seed: 1},
+			tolerance: 0.03,
+			want: []*Properties{
+				{Material: "PLA", Volume: 523.6, Area: 314.16, Inertia: [3][3]float64{{5236, 0, 0}, {0, 5236, 0}, {0, 0, 5236}},
+					Min: [3]float64{-5, -5, -5}, Max: [3]float64{5, 5, 5}},
+				{Volume: 523.6, Area: 314.16, Inertia: [3][3]float64{{5236, 0, 0}, {0, 5236, 0}, {0, 0, 5236}},
+					Min: [3]float64{-5, -5, -5}, Max: [3]float64{5, 5, 5}},
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test #%v", i), func(t *testing.T) {
+			r, err := Measure(parse(t, tt.src), tt.opts)
+			if err != nil {
+				t.Fatalf("Measure: %v", err)
+			}
+			got := append(r.Materials, r.Total)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v materials, want %v", len(r.Materials), len(tt.want)-1)
+			}
+			for j, want := range tt.want {
+				g := got[j]
+				if g.Material != want.Material {
+					t.Errorf("%v: material = %q, want %q", j, g.Material, want.Material)
+				}
+				check := func(name string, got, want, tolerance float64) {
+					// Compare small values relative to the size of the model.
+					if !near(got, want, tolerance) && math.Abs(got-want) > tolerance*10 {
+						t.Errorf("%v: %v = %v, want %v", j, name, got, want)
+					}
+				}
+				check("volume", g.Volume, want.Volume, tt.tolerance)
+				check("area", g.Area, want.Area, tt.tolerance)
+				check("mass", g.Mass, want.Mass, tt.tolerance)
+				for k := 0; k < 3; k++ {
+					check(fmt.Sprintf("centerOfMass[%v]", k), g.CenterOfMass[k], want.CenterOfMass[k], tt.tolerance)
+					check(fmt.Sprintf("min[%v]", k), g.Min[k], want.Min[k], tt.tolerance)
+					check(fmt.Sprintf("max[%v]", k), g.Max[k], want.Max[k], tt.tolerance)
+					for l := 0; l < 3; l++ {
+						// Off-diagonal products of inertia are compared to the diagonal.
+						check(fmt.Sprintf("inertia[%v][%v]", k, l), g.Inertia[k][l], want.Inertia[k][l], tt.tolerance*math.Max(1, want.Inertia[k][k]/10))
+					}
+				}
+			}
+			if tt.opts.Samples > 0 && (r.Total.VolumeError <= 0 || r.Total.VolumeError > 0.01*r.Total.Volume) {
+				t.Errorf("volumeError = %v, want a small positive error", r.Total.VolumeError)
+			}
+		})
+	}
+}
+
+func TestMeasure_Errors(t *testing.T) {
+	tests := []struct {
+		src  string
+		opts *Options
+		want string
+	}{
+		{src: "group();", want: "the model is empty"},
+		{src: "cube(size = 1);", opts: &Options{Samples: -1}, want: "resolution and samples must not be negative"},
+		{src: "hull() { cube(size = 1); }", want: "hull is not supported"},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test #%v", i), func(t *testing.T) {
+			_, err := Measure(parse(t, tt.src), tt.opts)
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("Measure = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestTreeOf(t *testing.T) {
+	src := `// comment
+difference() {
+	cube(size = 1);
+	#translate([1, 0, 0]) { rotate([0, 0, 45]) { cube(size = 1); } }
+	hull() { sphere(r = 1); }
+}
+sphere(r = 2);`
+
+	got := TreeOf(parse(t, src))
+	var nodes []string
+	for _, name := range []string{"CubePrimitive", "DifferenceBlockPrimitive", "HullBlockPrimitive", "ModifierExpression", "SpherePrimitive", "LineComment"} {
+		nodes = append(nodes, fmt.Sprintf("%v=%v", name, got.Nodes[name]))
+	}
+	if want := "CubePrimitive=2 DifferenceBlockPrimitive=1 HullBlockPrimitive=1 ModifierExpression=1 SpherePrimitive=2 LineComment=1"; strings.Join(nodes, " ") != want {
+		t.Errorf("nodes = %v, want %v", strings.Join(nodes, " "), want)
+	}
+	if got.Depth != 4 {
+		t.Errorf("depth = %v, want 4", got.Depth)
+	}
+	if want := "hull"; strings.Join(got.Unsupported, " ") != want {
+		t.Errorf("unsupported = %v, want %v", got.Unsupported, want)
+	}
+}
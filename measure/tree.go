@@ -0,0 +1,74 @@
+package measure
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gmlewis/go-csg/ast"
+	"github.com/gmlewis/go-csg/irmf"
+)
+
+// Tree represents statistics of the CSG tree of a program.
+type Tree struct {
+	// Nodes is the number of nodes of each AST type (e.g. "CubePrimitive").
+	Nodes map[string]int `json:"nodes"`
+	// Depth is the maximum nesting of the objects, where
+	// a top-level primitive has a depth of 1.
+	Depth int `json:"depth"`
+	// Unsupported lists the nodes that cannot be converted to IRMF.
+	Unsupported []string `json:"unsupported,omitempty"`
+}
+
+// TreeOf returns the statistics of the CSG tree of the program.
+func TreeOf(program *ast.Program) *Tree {
+	t := &Tree{Nodes: map[string]int{}}
+	ast.Inspect(program, func(node ast.Node) bool {
+		if node != nil {
+			t.Nodes[typeName(node)]++
+		}
+		return true
+	})
+	delete(t.Nodes, "Program")
+	t.Depth = depth(program.Statements)
+
+	if shader, err := irmf.New(program, false); err == nil {
+		t.Unsupported = append(t.Unsupported, shader.Unsupported...)
+		sort.Strings(t.Unsupported)
+	}
+	return t
+}
+
+func typeName(node ast.Node) string {
+	return strings.TrimPrefix(fmt.Sprintf("%T", node), "*ast.")
+}
+
+// depth returns the maximum nesting of the objects of the statements.
+func depth(stmts []ast.Statement) int {
+	var result int
+	for _, stmt := range stmts {
+		es, ok := stmt.(*ast.ExpressionStatement)
+		if !ok {
+			continue
+		}
+		exp := es.Expression
+		for {
+			me, ok := exp.(*ast.ModifierExpression)
+			if !ok {
+				break
+			}
+			exp = me.Right
+		}
+		if _, ok := exp.(*ast.LineComment); ok {
+			continue
+		}
+		d := 1
+		if _, b, _ := ast.Parts(exp); b != nil {
+			d += depth(b.Statements)
+		}
+		if d > result {
+			result = d
+		}
+	}
+	return result
+}
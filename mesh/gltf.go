@@ -0,0 +1,237 @@
+package mesh
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"math"
+)
+
+// glTF constants.
+const (
+	glbMagic        = 0x46546C67 // "glTF"
+	glbChunkJSON    = 0x4E4F534A // "JSON"
+	glbChunkBIN     = 0x004E4942 // "BIN\0"
+	gltfFloat       = 5126
+	gltfUnsignedInt = 5125
+	gltfArrayBuffer = 34962
+	gltfIndexBuffer = 34963
+	gltfTriangles   = 4
+)
+
+type gltfDoc struct {
+	Asset       gltfAsset        `json:"asset"`
+	Scene       int              `json:"scene"`
+	Scenes      []gltfScene      `json:"scenes"`
+	Nodes       []gltfNode       `json:"nodes"`
+	Meshes      []gltfMesh       `json:"meshes,omitempty"`
+	Materials   []gltfMaterial   `json:"materials,omitempty"`
+	Accessors   []gltfAccessor   `json:"accessors,omitempty"`
+	BufferViews []gltfBufferView `json:"bufferViews,omitempty"`
+	Buffers     []gltfBuffer     `json:"buffers,omitempty"`
+}
+
+type gltfAsset struct {
+	Version   string `json:"version"`
+	Generator string `json:"generator"`
+}
+
+type gltfScene struct {
+	Nodes []int `json:"nodes"`
+}
+
+type gltfNode struct {
+	Name     string     `json:"name,omitempty"`
+	Mesh     *int       `json:"mesh,omitempty"`
+	Children []int      `json:"children,omitempty"`
+	Rotation []float64  `json:"rotation,omitempty"`
+	Scale    []float64  `json:"scale,omitempty"`
+	Extras   *gltfExtra `json:"extras,omitempty"`
+}
+
+type gltfExtra struct {
+	Units string `json:"units"`
+}
+
+type gltfMesh struct {
+	Name       string          `json:"name,omitempty"`
+	Primitives []gltfPrimitive `json:"primitives"`
+}
+
+type gltfPrimitive struct {
+	Attributes map[string]int `json:"attributes"`
+	Indices    int            `json:"indices"`
+	Material   int            `json:"material"`
+	Mode       int            `json:"mode"`
+}
+
+type gltfMaterial struct {
+	Name                 string  `json:"name,omitempty"`
+	PBRMetallicRoughness gltfPBR `json:"pbrMetallicRoughness"`
+	AlphaMode            string  `json:"alphaMode,omitempty"`
+	DoubleSided          bool    `json:"doubleSided,omitempty"`
+}
+
+type gltfPBR struct {
+	BaseColorFactor []float64 `json:"baseColorFactor"`
+	MetallicFactor  float64   `json:"metallicFactor"`
+	RoughnessFactor float64   `json:"roughnessFactor"`
+}
+
+type gltfAccessor struct {
+	BufferView    int       `json:"bufferView"`
+	ComponentType int       `json:"componentType"`
+	Count         int       `json:"count"`
+	Type          string    `json:"type"`
+	Min           []float32 `json:"min,omitempty"`
+	Max           []float32 `json:"max,omitempty"`
+}
+
+type gltfBufferView struct {
+	Buffer     int `json:"buffer"`
+	ByteOffset int `json:"byteOffset"`
+	ByteLength int `json:"byteLength"`
+	Target     int `json:"target"`
+}
+
+type gltfBuffer struct {
+	ByteLength int `json:"byteLength"`
+}
+
+// WriteGLB writes the model as a binary glTF 2.0 file.
+// glTF uses meters with +Y up, so the model is placed under a root
+// node that scales millimeters to meters and rotates +Z up to +Y up.
+func (m *Model) WriteGLB(w io.Writer) error {
+	doc := &gltfDoc{
+		Asset:  gltfAsset{Version: "2.0", Generator: "go-csg"},
+		Scenes: []gltfScene{{Nodes: []int{0}}},
+	}
+	root := gltfNode{
+		Name:     "root",
+		Rotation: []float64{-math.Sqrt2 / 2, 0, 0, math.Sqrt2 / 2},
+		Scale:    unitScale(m.Units),
+		Extras:   &gltfExtra{Units: m.Units},
+	}
+
+	var bin bytes.Buffer
+	for i, obj := range m.Objects {
+		// Positions.
+		min := []float32{math.MaxFloat32, math.MaxFloat32, math.MaxFloat32}
+		max := []float32{-math.MaxFloat32, -math.MaxFloat32, -math.MaxFloat32}
+		offset := bin.Len()
+		for _, v := range obj.Vertices {
+			for j := 0; j < 3; j++ {
+				f := float32(v[j])
+				if f < min[j] {
+					min[j] = f
+				}
+				if f > max[j] {
+					max[j] = f
+				}
+				binary.Write(&bin, binary.LittleEndian, f)
+			}
+		}
+		doc.BufferViews = append(doc.BufferViews, gltfBufferView{ByteOffset: offset, ByteLength: bin.Len() - offset, Target: gltfArrayBuffer})
+		doc.Accessors = append(doc.Accessors, gltfAccessor{
+			BufferView:    len(doc.BufferViews) - 1,
+			ComponentType: gltfFloat,
+			Count:         len(obj.Vertices),
+			Type:          "VEC3",
+			Min:           min,
+			Max:           max,
+		})
+
+		// Indices.
+		offset = bin.Len()
+		for _, t := range obj.Triangles {
+			for _, v := range t {
+				binary.Write(&bin, binary.LittleEndian, uint32(v))
+			}
+		}
+		doc.BufferViews = append(doc.BufferViews, gltfBufferView{ByteOffset: offset, ByteLength: bin.Len() - offset, Target: gltfIndexBuffer})
+		doc.Accessors = append(doc.Accessors, gltfAccessor{
+			BufferView:    len(doc.BufferViews) - 1,
+			ComponentType: gltfUnsignedInt,
+			Count:         3 * len(obj.Triangles),
+			Type:          "SCALAR",
+		})
+
+		material := gltfMaterial{
+			Name: obj.Name,
+			PBRMetallicRoughness: gltfPBR{
+				BaseColorFactor: []float64{1, 1, 1, 1},
+				RoughnessFactor: 1,
+			},
+		}
+		if obj.Material != nil {
+			material.Name = obj.Material.Name
+			material.PBRMetallicRoughness.BaseColorFactor = obj.Material.Color[:]
+			if obj.Material.Color[3] < 1 {
+				material.AlphaMode = "BLEND"
+			}
+		}
+		doc.Materials = append(doc.Materials, material)
+
+		doc.Meshes = append(doc.Meshes, gltfMesh{
+			Name: obj.Name,
+			Primitives: []gltfPrimitive{{
+				Attributes: map[string]int{"POSITION": len(doc.Accessors) - 2},
+				Indices:    len(doc.Accessors) - 1,
+				Material:   i,
+				Mode:       gltfTriangles,
+			}},
+		})
+		meshIndex := i
+		root.Children = append(root.Children, i+1)
+		doc.Nodes = append(doc.Nodes, gltfNode{Name: obj.Name, Mesh: &meshIndex})
+	}
+	doc.Nodes = append([]gltfNode{root}, doc.Nodes...)
+	if bin.Len() > 0 {
+		doc.Buffers = []gltfBuffer{{ByteLength: bin.Len()}}
+	}
+
+	js, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	for len(js)%4 != 0 {
+		js = append(js, ' ')
+	}
+	for bin.Len()%4 != 0 {
+		bin.WriteByte(0)
+	}
+
+	length := 12 + 8 + len(js)
+	if bin.Len() > 0 {
+		length += 8 + bin.Len()
+	}
+	var out bytes.Buffer
+	binary.Write(&out, binary.LittleEndian, []uint32{glbMagic, 2, uint32(length)})
+	binary.Write(&out, binary.LittleEndian, []uint32{uint32(len(js)), glbChunkJSON})
+	out.Write(js)
+	if bin.Len() > 0 {
+		binary.Write(&out, binary.LittleEndian, []uint32{uint32(bin.Len()), glbChunkBIN})
+		out.Write(bin.Bytes())
+	}
+	_, err = w.Write(out.Bytes())
+	return err
+}
+
+// unitScale returns the scale that converts the units to meters.
+func unitScale(units string) []float64 {
+	s := 0.001
+	switch units {
+	case "cm":
+		s = 0.01
+	case "m":
+		s = 1
+	case "in":
+		s = 0.0254
+	case "ft":
+		s = 0.3048
+	case "um":
+		s = 1e-6
+	}
+	return []float64{s, s, s}
+}
@@ -0,0 +1,272 @@
+// Package mesh tessellates a solid.Model into triangle meshes (one per
+// material) and writes them out as OBJ (with MTL), 3MF, or binary glTF.
+// All the writers share the same tessellated Model.
+package mesh
+
+import (
+	"errors"
+	"math"
+
+	"github.com/gmlewis/go-csg/solid"
+)
+
+// Mesh represents an indexed triangle mesh. Triangles are
+// counterclockwise when viewed from outside.
+type Mesh struct {
+	Vertices  []solid.Vec3
+	Triangles [][3]int
+}
+
+// Object represents a single-material mesh.
+type Object struct {
+	Name     string
+	Material *solid.Material
+	Mesh
+}
+
+// Model represents a tessellated model.
+type Model struct {
+	Objects []*Object
+	Units   string // e.g. "mm", consistent with the IRMF header.
+}
+
+// bisectSteps is the number of bisections used to locate the surface
+// along each cell edge.
+const bisectSteps = 10
+
+// Tessellate samples the model on a grid of the given cell size (in
+// model units) and returns one closed mesh per material, using
+// marching tetrahedra. Surface vertices are refined by bisection.
+func Tessellate(model *solid.Model, cellSize float64) (*Model, error) {
+	if cellSize <= 0 {
+		return nil, errors.New("cell size must be positive")
+	}
+	result := &Model{Units: "mm"}
+	if model.Bounds.Empty() {
+		return result, nil
+	}
+
+	g := newGrid(model, cellSize)
+	for mat := range model.Materials {
+		m := g.mesh(mat)
+		if len(m.Triangles) == 0 {
+			continue
+		}
+		result.Objects = append(result.Objects, &Object{
+			Name:     model.Materials[mat].Name,
+			Material: model.Materials[mat],
+			Mesh:     *m,
+		})
+	}
+	return result, nil
+}
+
+// grid holds the material at each sample point. The grid extends
+// one cell beyond the model's bounds so that every mesh is closed.
+type grid struct {
+	model      *solid.Model
+	origin     solid.Vec3
+	cell       float64
+	nx, ny, nz int
+	samples    []int
+}
+
+func newGrid(model *solid.Model, cell float64) *grid {
+	b := model.Bounds
+	g := &grid{model: model, cell: cell}
+	n := [3]int{}
+	for i := 0; i < 3; i++ {
+		n[i] = int(math.Ceil((b.Max[i]-b.Min[i])/cell)) + 3
+		// Center the sample grid on the bounds.
+		g.origin[i] = 0.5*(b.Min[i]+b.Max[i]) - 0.5*float64(n[i]-1)*cell
+	}
+	g.nx, g.ny, g.nz = n[0], n[1], n[2]
+	g.samples = make([]int, g.nx*g.ny*g.nz)
+	for k := 0; k < g.nz; k++ {
+		for j := 0; j < g.ny; j++ {
+			for i := 0; i < g.nx; i++ {
+				g.samples[g.index(i, j, k)] = model.At(g.point(i, j, k))
+			}
+		}
+	}
+	return g
+}
+
+func (g *grid) index(i, j, k int) int { return i + g.nx*(j+g.ny*k) }
+
+func (g *grid) point(i, j, k int) solid.Vec3 {
+	return solid.Vec3{
+		g.origin[0] + float64(i)*g.cell,
+		g.origin[1] + float64(j)*g.cell,
+		g.origin[2] + float64(k)*g.cell,
+	}
+}
+
+// cubeTets splits a cell into 6 tetrahedra sharing the diagonal from
+// corner 0 to corner 7. Corner c is offset by (c&1, c>>1&1, c>>2&1).
+var cubeTets = [6][4]int{
+	{0, 1, 3, 7}, {0, 3, 2, 7}, {0, 2, 6, 7},
+	{0, 6, 4, 7}, {0, 4, 5, 7}, {0, 5, 1, 7},
+}
+
+type edgeKey struct {
+	a, b int
+}
+
+// mesher accumulates the mesh for a single material.
+type mesher struct {
+	g        *grid
+	material int
+	mesh     *Mesh
+	vertices map[edgeKey]int
+}
+
+func (g *grid) mesh(material int) *Mesh {
+	m := &mesher{g: g, material: material, mesh: &Mesh{}, vertices: map[edgeKey]int{}}
+	var ids [8]int
+	var inside [8]bool
+	for k := 0; k+1 < g.nz; k++ {
+		for j := 0; j+1 < g.ny; j++ {
+			for i := 0; i+1 < g.nx; i++ {
+				var count int
+				for c := 0; c < 8; c++ {
+					ids[c] = g.index(i+c&1, j+(c>>1)&1, k+(c>>2)&1)
+					inside[c] = g.samples[ids[c]] == material
+					if inside[c] {
+						count++
+					}
+				}
+				if count == 0 || count == 8 {
+					continue
+				}
+				for _, tet := range cubeTets {
+					m.tetrahedron(ids, inside, tet)
+				}
+			}
+		}
+	}
+	return m.mesh
+}
+
+func (m *mesher) tetrahedron(ids [8]int, inside [8]bool, tet [4]int) {
+	var ins, outs []int
+	for _, c := range tet {
+		if inside[c] {
+			ins = append(ins, ids[c])
+		} else {
+			outs = append(outs, ids[c])
+		}
+	}
+	switch len(ins) {
+	case 1:
+		m.triangle(ins, outs, m.vertex(ins[0], outs[0]), m.vertex(ins[0], outs[1]), m.vertex(ins[0], outs[2]))
+	case 2:
+		a, b := m.vertex(ins[0], outs[0]), m.vertex(ins[0], outs[1])
+		c, d := m.vertex(ins[1], outs[1]), m.vertex(ins[1], outs[0])
+		m.triangle(ins, outs, a, b, c)
+		m.triangle(ins, outs, a, c, d)
+	case 3:
+		m.triangle(ins, outs, m.vertex(ins[0], outs[0]), m.vertex(ins[1], outs[0]), m.vertex(ins[2], outs[0]))
+	}
+}
+
+// triangle adds a triangle, oriented so that its normal points
+// from the inside samples toward the outside samples.
+func (m *mesher) triangle(ins, outs []int, a, b, c int) {
+	if a == b || b == c || c == a {
+		return
+	}
+	vs := m.mesh.Vertices
+	n := cross(sub(vs[b], vs[a]), sub(vs[c], vs[a]))
+	if dot(n, n) == 0 {
+		return
+	}
+	dir := sub(m.centroid(outs), m.centroid(ins))
+	if dot(n, dir) < 0 {
+		b, c = c, b
+	}
+	m.mesh.Triangles = append(m.mesh.Triangles, [3]int{a, b, c})
+}
+
+func (m *mesher) centroid(ids []int) solid.Vec3 {
+	var sum solid.Vec3
+	for _, id := range ids {
+		p := m.g.pointOf(id)
+		for i := range sum {
+			sum[i] += p[i] / float64(len(ids))
+		}
+	}
+	return sum
+}
+
+func (g *grid) pointOf(id int) solid.Vec3 {
+	i := id % g.nx
+	j := (id / g.nx) % g.ny
+	k := id / (g.nx * g.ny)
+	return g.point(i, j, k)
+}
+
+// vertex returns the index of the surface vertex on the edge between
+// an inside sample and an outside sample.
+func (m *mesher) vertex(in, out int) int {
+	key := edgeKey{a: in, b: out}
+	if v, ok := m.vertices[key]; ok {
+		return v
+	}
+	a, b := m.g.pointOf(in), m.g.pointOf(out)
+	for step := 0; step < bisectSteps; step++ {
+		mid := lerp(a, b, 0.5)
+		if m.g.model.At(mid) == m.material {
+			a = mid
+		} else {
+			b = mid
+		}
+	}
+	v := len(m.mesh.Vertices)
+	m.mesh.Vertices = append(m.mesh.Vertices, lerp(a, b, 0.5))
+	m.vertices[key] = v
+	return v
+}
+
+// Volume returns the volume enclosed by the mesh.
+func (m *Mesh) Volume() float64 {
+	var sum float64
+	for _, t := range m.Triangles {
+		sum += dot(m.Vertices[t[0]], cross(m.Vertices[t[1]], m.Vertices[t[2]]))
+	}
+	return sum / 6
+}
+
+// Area returns the surface area of the mesh.
+func (m *Mesh) Area() float64 {
+	var sum float64
+	for _, t := range m.Triangles {
+		n := cross(sub(m.Vertices[t[1]], m.Vertices[t[0]]), sub(m.Vertices[t[2]], m.Vertices[t[0]]))
+		sum += math.Sqrt(dot(n, n))
+	}
+	return sum / 2
+}
+
+// Bounds returns the bounding box of the mesh.
+func (m *Mesh) Bounds() solid.Box {
+	box := solid.Box{
+		Min: solid.Vec3{math.Inf(1), math.Inf(1), math.Inf(1)},
+		Max: solid.Vec3{math.Inf(-1), math.Inf(-1), math.Inf(-1)},
+	}
+	for _, v := range m.Vertices {
+		for i := 0; i < 3; i++ {
+			box.Min[i] = math.Min(box.Min[i], v[i])
+			box.Max[i] = math.Max(box.Max[i], v[i])
+		}
+	}
+	return box
+}
+
+func sub(a, b solid.Vec3) solid.Vec3 { return solid.Vec3{a[0] - b[0], a[1] - b[1], a[2] - b[2]} }
+func dot(a, b solid.Vec3) float64    { return a[0]*b[0] + a[1]*b[1] + a[2]*b[2] }
+func cross(a, b solid.Vec3) solid.Vec3 {
+	return solid.Vec3{a[1]*b[2] - a[2]*b[1], a[2]*b[0] - a[0]*b[2], a[0]*b[1] - a[1]*b[0]}
+}
+func lerp(a, b solid.Vec3, t float64) solid.Vec3 {
+	return solid.Vec3{a[0] + t*(b[0]-a[0]), a[1] + t*(b[1]-a[1]), a[2] + t*(b[2]-a[2])}
+}
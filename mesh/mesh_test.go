@@ -0,0 +1,163 @@
+package mesh
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/gmlewis/go-csg/lexer"
+	"github.com/gmlewis/go-csg/parser"
+	"github.com/gmlewis/go-csg/solid"
+)
+
+func tessellate(t *testing.T, src string, cellSize float64) *Model {
+	t.Helper()
+	le := lexer.New(src)
+	p := parser.New(le)
+	program := p.ParseProgram()
+	if errs := p.Errors(); len(errs) != 0 {
+		t.Fatalf("ParseProgram: %v", strings.Join(errs, "\n"))
+	}
+	model, err := solid.New(program)
+	if err != nil {
+		t.Fatalf("solid.New: %v", err)
+	}
+	m, err := Tessellate(model, cellSize)
+	if err != nil {
+		t.Fatalf("Tessellate: %v", err)
+	}
+	return m
+}
+
+func TestTessellate(t *testing.T) {
+	tests := []struct {
+		src     string
+		volumes []float64
+		areas   []float64
+	}{
+		{
+			src:     "cube(size = [10, 10, 10], center = false);",
+			volumes: []float64{1000},
+			areas:   []float64{600},
+		},
+		{
+			src:     "sphere($fn = 0, $fa = 12, $fs = 2, r = 5);",
+			volumes: []float64{4.0 / 3.0 * math.Pi * 125},
+			areas:   []float64{4 * math.Pi * 25},
+		},
+		{
+			src: `color([1, 0, 0, 1]) { cube(size = [10, 10, 10], center = false); }
+color([0, 0, 1, 1]) { multmatrix([[1, 0, 0, 10], [0, 1, 0, 0], [0, 0, 1, 0], [0, 0, 0, 1]]) { cube(size = [10, 10, 10], center = false); } }`,
+			volumes: []float64{1000, 1000},
+			areas:   []float64{600, 600},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test #%v", i), func(t *testing.T) {
+			m := tessellate(t, tt.src, 0.5)
+			if len(m.Objects) != len(tt.volumes) {
+				t.Fatalf("objects = %v, want %v", len(m.Objects), len(tt.volumes))
+			}
+			for j, obj := range m.Objects {
+				if got := obj.Volume(); math.Abs(got-tt.volumes[j]) > 0.03*tt.volumes[j] {
+					t.Errorf("object %v volume = %v, want %v", j, got, tt.volumes[j])
+				}
+				if got := obj.Area(); math.Abs(got-tt.areas[j]) > 0.03*tt.areas[j] {
+					t.Errorf("object %v area = %v, want %v", j, got, tt.areas[j])
+				}
+				checkClosed(t, &obj.Mesh)
+			}
+		})
+	}
+}
+
+// checkClosed verifies that every directed edge is matched by
+// exactly one opposite edge.
+func checkClosed(t *testing.T, m *Mesh) {
+	t.Helper()
+	edges := map[[2]int]int{}
+	for _, tri := range m.Triangles {
+		for i := 0; i < 3; i++ {
+			edges[[2]int{tri[i], tri[(i+1)%3]}]++
+		}
+	}
+	for e, n := range edges {
+		if n != 1 || edges[[2]int{e[1], e[0]}] != 1 {
+			t.Fatalf("mesh is not closed at edge %v", e)
+		}
+	}
+}
+
+func TestWriters(t *testing.T) {
+	m := tessellate(t, "color([1, 0, 0, 0.5]) { cube(size = [2, 2, 2], center = true); }", 0.5)
+
+	var obj, mtl bytes.Buffer
+	if err := m.WriteOBJ(&obj, "cube.mtl"); err != nil {
+		t.Fatalf("WriteOBJ: %v", err)
+	}
+	if err := m.WriteMTL(&mtl); err != nil {
+		t.Fatalf("WriteMTL: %v", err)
+	}
+	for _, want := range []string{"mtllib cube.mtl\n", "usemtl color_FF000080\n", "\nf "} {
+		if !strings.Contains(obj.String(), want) {
+			t.Errorf("WriteOBJ missing %q", want)
+		}
+	}
+	if want := "newmtl color_FF000080\nKd 1 0 0\nd 0.5\n"; !strings.Contains(mtl.String(), want) {
+		t.Errorf("WriteMTL = %q, want %q", mtl.String(), want)
+	}
+
+	var tmf bytes.Buffer
+	if err := m.Write3MF(&tmf); err != nil {
+		t.Fatalf("Write3MF: %v", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(tmf.Bytes()), int64(tmf.Len()))
+	if err != nil {
+		t.Fatalf("zip.NewReader: %v", err)
+	}
+	var model string
+	for _, f := range zr.File {
+		if f.Name != "3D/3dmodel.model" {
+			continue
+		}
+		r, err := f.Open()
+		if err != nil {
+			t.Fatalf("Open: %v", err)
+		}
+		buf, err := ioutil.ReadAll(r)
+		if err != nil {
+			t.Fatalf("ReadAll: %v", err)
+		}
+		model = string(buf)
+	}
+	for _, want := range []string{`unit="millimeter"`, `<base name="color_FF000080" displaycolor="#FF000080"/>`, `pid="1" pindex="0"`, `<item objectid="2"/>`} {
+		if !strings.Contains(model, want) {
+			t.Errorf("3dmodel.model missing %q", want)
+		}
+	}
+
+	var glb bytes.Buffer
+	if err := m.WriteGLB(&glb); err != nil {
+		t.Fatalf("WriteGLB: %v", err)
+	}
+	b := glb.Bytes()
+	if got := binary.LittleEndian.Uint32(b[0:]); got != glbMagic {
+		t.Errorf("glb magic = %x, want %x", got, glbMagic)
+	}
+	if got := binary.LittleEndian.Uint32(b[8:]); int(got) != len(b) {
+		t.Errorf("glb length = %v, want %v", got, len(b))
+	}
+	jsonLen := binary.LittleEndian.Uint32(b[12:])
+	js := string(b[20 : 20+jsonLen])
+	for _, want := range []string{`"baseColorFactor":[1,0,0,0.5]`, `"alphaMode":"BLEND"`, `"units":"mm"`} {
+		if !strings.Contains(js, want) {
+			t.Errorf("glb JSON missing %q: %v", want, js)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package mesh
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// WriteOBJ writes the model as a Wavefront OBJ file that references
+// its materials from the named MTL file (see WriteMTL).
+func (m *Model) WriteOBJ(w io.Writer, mtlFilename string) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "# units: %v\n", m.Units)
+	if mtlFilename != "" {
+		fmt.Fprintf(bw, "mtllib %v\n", mtlFilename)
+	}
+	offset := 1
+	for _, obj := range m.Objects {
+		fmt.Fprintf(bw, "o %v\n", obj.Name)
+		if obj.Material != nil {
+			fmt.Fprintf(bw, "usemtl %v\n", obj.Material.Name)
+		}
+		for _, v := range obj.Vertices {
+			fmt.Fprintf(bw, "v %v %v %v\n", float32(v[0]), float32(v[1]), float32(v[2]))
+		}
+		for _, t := range obj.Triangles {
+			fmt.Fprintf(bw, "f %v %v %v\n", t[0]+offset, t[1]+offset, t[2]+offset)
+		}
+		offset += len(obj.Vertices)
+	}
+	return bw.Flush()
+}
+
+// WriteMTL writes the materials of the model as a Wavefront MTL file.
+func (m *Model) WriteMTL(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for _, obj := range m.Objects {
+		if obj.Material == nil {
+			continue
+		}
+		c := obj.Material.Color
+		fmt.Fprintf(bw, "newmtl %v\n", obj.Material.Name)
+		fmt.Fprintf(bw, "Kd %v %v %v\n", float32(c[0]), float32(c[1]), float32(c[2]))
+		fmt.Fprintf(bw, "d %v\n\n", float32(c[3]))
+	}
+	return bw.Flush()
+}
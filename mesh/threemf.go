@@ -0,0 +1,113 @@
+package mesh
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+)
+
+const (
+	contentTypes3MF = `<?xml version="1.0" encoding="UTF-8"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+  <Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+  <Default Extension="model" ContentType="application/vnd.ms-package.3dmanufacturing-3dmodel+xml"/>
+</Types>
+`
+	rels3MF = `<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Target="/3D/3dmodel.model" Id="rel0" Type="http://schemas.microsoft.com/3dmanufacturing/2013/01/3dmodel"/>
+</Relationships>
+`
+)
+
+// units3MF maps model units to 3MF unit names.
+var units3MF = map[string]string{
+	"":   "millimeter",
+	"mm": "millimeter",
+	"cm": "centimeter",
+	"m":  "meter",
+	"in": "inch",
+	"ft": "foot",
+	"um": "micron",
+}
+
+// Write3MF writes the model as a 3MF package. Each object references
+// its material (with its display color) in a basematerials group.
+func (m *Model) Write3MF(w io.Writer) error {
+	unit, ok := units3MF[m.Units]
+	if !ok {
+		return fmt.Errorf("unsupported 3MF units %q", m.Units)
+	}
+
+	zw := zip.NewWriter(w)
+	for _, f := range []struct{ name, body string }{
+		{"[Content_Types].xml", contentTypes3MF},
+		{"_rels/.rels", rels3MF},
+	} {
+		fw, err := zw.Create(f.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(fw, f.body); err != nil {
+			return err
+		}
+	}
+
+	fw, err := zw.Create("3D/3dmodel.model")
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(fw)
+	fmt.Fprintf(bw, `<?xml version="1.0" encoding="UTF-8"?>
+<model unit="%v" xml:lang="en-US" xmlns="http://schemas.microsoft.com/3dmanufacturing/core/2015/02">
+  <metadata name="Application">go-csg</metadata>
+  <resources>
+    <basematerials id="1">
+`, unit)
+	for _, obj := range m.Objects {
+		name, color := "default", [4]float64{1, 1, 1, 1}
+		if obj.Material != nil {
+			name, color = obj.Material.Name, obj.Material.Color
+		}
+		fmt.Fprintf(bw, "      <base name=\"%v\" displaycolor=\"#%02X%02X%02X%02X\"/>\n",
+			escape(name), channel(color[0]), channel(color[1]), channel(color[2]), channel(color[3]))
+	}
+	fmt.Fprintf(bw, "    </basematerials>\n")
+
+	for i, obj := range m.Objects {
+		fmt.Fprintf(bw, "    <object id=\"%v\" type=\"model\" name=\"%v\" pid=\"1\" pindex=\"%v\">\n", i+2, escape(obj.Name), i)
+		fmt.Fprintf(bw, "      <mesh>\n        <vertices>\n")
+		for _, v := range obj.Vertices {
+			fmt.Fprintf(bw, "          <vertex x=\"%v\" y=\"%v\" z=\"%v\"/>\n", float32(v[0]), float32(v[1]), float32(v[2]))
+		}
+		fmt.Fprintf(bw, "        </vertices>\n        <triangles>\n")
+		for _, t := range obj.Triangles {
+			fmt.Fprintf(bw, "          <triangle v1=\"%v\" v2=\"%v\" v3=\"%v\"/>\n", t[0], t[1], t[2])
+		}
+		fmt.Fprintf(bw, "        </triangles>\n      </mesh>\n    </object>\n")
+	}
+
+	fmt.Fprintf(bw, "  </resources>\n  <build>\n")
+	for i := range m.Objects {
+		fmt.Fprintf(bw, "    <item objectid=\"%v\"/>\n", i+2)
+	}
+	fmt.Fprintf(bw, "  </build>\n</model>\n")
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+func escape(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
+
+func channel(v float64) int {
+	return int(math.Round(255 * math.Max(0, math.Min(1, v))))
+}
@@ -6,29 +6,83 @@ import (
 	"strings"
 )
 
-// CubePrimitive represents an object of that type.
-type CubePrimitive struct {
-	Arguments []Object
+func inspectCall(name string, args []Object) string {
+	var out bytes.Buffer
+
+	var params []string
+	for _, p := range args {
+		params = append(params, p.Inspect())
+	}
+
+	out.WriteString(name)
+	out.WriteString("(")
+	out.WriteString(strings.Join(params, ", "))
+	out.WriteString(")")
+
+	return out.String()
 }
 
-// Inspect returns a representation of the object value.
-func (c *CubePrimitive) Inspect() string {
+func inspectBlock(name string, args, children []Object) string {
 	var out bytes.Buffer
 
-	var args []string
-	for _, p := range c.Arguments {
-		args = append(args, p.Inspect())
+	out.WriteString(inspectCall(name, args))
+	out.WriteString(" {\n")
+	for _, child := range children {
+		for _, line := range strings.Split(child.Inspect(), "\n") {
+			out.WriteString("\t" + line + "\n")
+		}
 	}
-
-	out.WriteString("cube(")
-	out.WriteString(strings.Join(args, ", "))
-	out.WriteString(");\n")
+	out.WriteString("}")
 
 	return out.String()
 }
 
+// LineComment represents an object of that type.
+type LineComment struct {
+	Value string
+}
+
+// Inspect returns a representation of the object value.
+func (c *LineComment) Inspect() string { return "//" + c.Value }
+
 // Type returns the type of the object.
-func (c *CubePrimitive) Type() T { return CubePrimitiveT }
+func (c *LineComment) Type() T { return LineCommentT }
+
+// NamedArgument represents an object of that type.
+type NamedArgument struct {
+	Name  string
+	Value Object
+}
+
+// Inspect returns a representation of the object value.
+func (n *NamedArgument) Inspect() string {
+	return fmt.Sprintf("%v = %v", n.Name, n.Value.Inspect())
+}
+
+// Type returns the type of the object.
+func (n *NamedArgument) Type() T { return NamedArgumentT }
+
+// CirclePrimitive represents an object of that type.
+type CirclePrimitive struct {
+	Arguments []Object
+}
+
+// Inspect returns a representation of the object value.
+func (p *CirclePrimitive) Inspect() string { return inspectCall("circle", p.Arguments) + ";" }
+
+// Type returns the type of the object.
+func (p *CirclePrimitive) Type() T { return CirclePrimitiveT }
+
+// CubePrimitive represents an object of that type.
+type CubePrimitive struct {
+	Arguments []Object
+}
+
+// Inspect returns a representation of the object value.
+func (p *CubePrimitive) Inspect() string { return inspectCall("cube", p.Arguments) + ";" }
+
+// Type returns the type of the object.
+func (p *CubePrimitive) Type() T { return CubePrimitiveT }
 
 // CylinderPrimitive represents an object of that type.
 type CylinderPrimitive struct {
@@ -36,171 +90,296 @@ type CylinderPrimitive struct {
 }
 
 // Inspect returns a representation of the object value.
-func (c *CylinderPrimitive) Inspect() string {
-	var out bytes.Buffer
+func (p *CylinderPrimitive) Inspect() string { return inspectCall("cylinder", p.Arguments) + ";" }
 
-	var args []string
-	for _, p := range c.Arguments {
-		args = append(args, p.Inspect())
-	}
+// Type returns the type of the object.
+func (p *CylinderPrimitive) Type() T { return CylinderPrimitiveT }
+
+// GroupPrimitive represents an object of that type.
+type GroupPrimitive struct {
+	Arguments []Object
+}
 
-	out.WriteString("cylinder(")
-	out.WriteString(strings.Join(args, ", "))
-	out.WriteString(");\n")
+// Inspect returns a representation of the object value.
+func (p *GroupPrimitive) Inspect() string { return inspectCall("group", p.Arguments) + ";" }
 
-	return out.String()
+// Type returns the type of the object.
+func (p *GroupPrimitive) Type() T { return GroupPrimitiveT }
+
+// PolygonPrimitive represents an object of that type.
+type PolygonPrimitive struct {
+	Arguments []Object
+}
+
+// Inspect returns a representation of the object value.
+func (p *PolygonPrimitive) Inspect() string { return inspectCall("polygon", p.Arguments) + ";" }
+
+// Type returns the type of the object.
+func (p *PolygonPrimitive) Type() T { return PolygonPrimitiveT }
+
+// PolyhedronPrimitive represents an object of that type.
+type PolyhedronPrimitive struct {
+	Arguments []Object
+}
+
+// Inspect returns a representation of the object value.
+func (p *PolyhedronPrimitive) Inspect() string { return inspectCall("polyhedron", p.Arguments) + ";" }
+
+// Type returns the type of the object.
+func (p *PolyhedronPrimitive) Type() T { return PolyhedronPrimitiveT }
+
+// SpherePrimitive represents an object of that type.
+type SpherePrimitive struct {
+	Arguments []Object
+}
+
+// Inspect returns a representation of the object value.
+func (p *SpherePrimitive) Inspect() string { return inspectCall("sphere", p.Arguments) + ";" }
+
+// Type returns the type of the object.
+func (p *SpherePrimitive) Type() T { return SpherePrimitiveT }
+
+// SquarePrimitive represents an object of that type.
+type SquarePrimitive struct {
+	Arguments []Object
+}
+
+// Inspect returns a representation of the object value.
+func (p *SquarePrimitive) Inspect() string { return inspectCall("square", p.Arguments) + ";" }
+
+// Type returns the type of the object.
+func (p *SquarePrimitive) Type() T { return SquarePrimitiveT }
+
+// TextPrimitive represents an object of that type.
+type TextPrimitive struct {
+	Arguments []Object
+}
+
+// Inspect returns a representation of the object value.
+func (p *TextPrimitive) Inspect() string { return inspectCall("text", p.Arguments) + ";" }
+
+// Type returns the type of the object.
+func (p *TextPrimitive) Type() T { return TextPrimitiveT }
+
+// ColorBlockPrimitive represents an object of that type.
+type ColorBlockPrimitive struct {
+	Arguments []Object
+	Children  []Object
+}
+
+// Inspect returns a representation of the object value.
+func (b *ColorBlockPrimitive) Inspect() string { return inspectBlock("color", b.Arguments, b.Children) }
+
+// Type returns the type of the object.
+func (b *ColorBlockPrimitive) Type() T { return ColorBlockPrimitiveT }
+
+// DifferenceBlockPrimitive represents an object of that type.
+type DifferenceBlockPrimitive struct {
+	Children []Object
+}
+
+// Inspect returns a representation of the object value.
+func (b *DifferenceBlockPrimitive) Inspect() string {
+	return inspectBlock("difference", nil, b.Children)
 }
 
 // Type returns the type of the object.
-func (c *CylinderPrimitive) Type() T { return CylinderPrimitiveT }
+func (b *DifferenceBlockPrimitive) Type() T { return DifferenceBlockPrimitiveT }
 
 // GroupBlockPrimitive represents an object of that type.
 type GroupBlockPrimitive struct {
-	Body Object
+	Children []Object
 }
 
 // Inspect returns a representation of the object value.
-func (g *GroupBlockPrimitive) Inspect() string {
-	var out bytes.Buffer
+func (b *GroupBlockPrimitive) Inspect() string { return inspectBlock("group", nil, b.Children) }
 
-	out.WriteString("group() {\n")
-	out.WriteString(g.Body.Inspect())
-	out.WriteString("\n}")
+// Type returns the type of the object.
+func (b *GroupBlockPrimitive) Type() T { return GroupBlockPrimitiveT }
 
-	return out.String()
+// HullBlockPrimitive represents an object of that type.
+type HullBlockPrimitive struct {
+	Children []Object
 }
 
+// Inspect returns a representation of the object value.
+func (b *HullBlockPrimitive) Inspect() string { return inspectBlock("hull", nil, b.Children) }
+
 // Type returns the type of the object.
-func (g *GroupBlockPrimitive) Type() T { return GroupBlockPrimitiveT }
+func (b *HullBlockPrimitive) Type() T { return HullBlockPrimitiveT }
 
-// MultmatrixBlockPrimitive represents an object of that type.
-type MultmatrixBlockPrimitive struct {
+// IntersectionBlockPrimitive represents an object of that type.
+type IntersectionBlockPrimitive struct {
+	Children []Object
+}
+
+// Inspect returns a representation of the object value.
+func (b *IntersectionBlockPrimitive) Inspect() string {
+	return inspectBlock("intersection", nil, b.Children)
+}
+
+// Type returns the type of the object.
+func (b *IntersectionBlockPrimitive) Type() T { return IntersectionBlockPrimitiveT }
+
+// LinearExtrudeBlockPrimitive represents an object of that type.
+type LinearExtrudeBlockPrimitive struct {
 	Arguments []Object
-	Body      Object
+	Children  []Object
 }
 
 // Inspect returns a representation of the object value.
-func (m *MultmatrixBlockPrimitive) Inspect() string {
-	var out bytes.Buffer
+func (b *LinearExtrudeBlockPrimitive) Inspect() string {
+	return inspectBlock("linear_extrude", b.Arguments, b.Children)
+}
 
-	var args []string
-	for _, p := range m.Arguments {
-		args = append(args, p.Inspect())
-	}
+// Type returns the type of the object.
+func (b *LinearExtrudeBlockPrimitive) Type() T { return LinearExtrudeBlockPrimitiveT }
 
-	out.WriteString("multmatrix(")
-	out.WriteString(strings.Join(args, ", "))
-	out.WriteString(") {\n")
-	out.WriteString(m.Body.Inspect())
-	out.WriteString("\n}")
+// MinkowskiBlockPrimitive represents an object of that type.
+type MinkowskiBlockPrimitive struct {
+	Arguments []Object
+	Children  []Object
+}
 
-	return out.String()
+// Inspect returns a representation of the object value.
+func (b *MinkowskiBlockPrimitive) Inspect() string {
+	return inspectBlock("minkowski", b.Arguments, b.Children)
 }
 
 // Type returns the type of the object.
-func (m *MultmatrixBlockPrimitive) Type() T { return MultmatrixBlockPrimitiveT }
+func (b *MinkowskiBlockPrimitive) Type() T { return MinkowskiBlockPrimitiveT }
 
-// NamedArgument represents an object of that type.
-type NamedArgument struct {
-	Name  string
-	Value Object
+// MirrorBlockPrimitive represents an object of that type.
+type MirrorBlockPrimitive struct {
+	Arguments []Object
+	Children  []Object
 }
 
 // Inspect returns a representation of the object value.
-func (n *NamedArgument) Inspect() string {
-	return fmt.Sprintf("%v = %v", n.Name, n.Value.Inspect())
+func (b *MirrorBlockPrimitive) Inspect() string {
+	return inspectBlock("mirror", b.Arguments, b.Children)
 }
 
 // Type returns the type of the object.
-func (n *NamedArgument) Type() T { return NamedArgumentT }
+func (b *MirrorBlockPrimitive) Type() T { return MirrorBlockPrimitiveT }
 
-// PolygonPrimitive represents an object of that type.
-type PolygonPrimitive struct {
+// MultmatrixBlockPrimitive represents an object of that type.
+type MultmatrixBlockPrimitive struct {
 	Arguments []Object
+	Children  []Object
 }
 
 // Inspect returns a representation of the object value.
-func (c *PolygonPrimitive) Inspect() string {
-	var out bytes.Buffer
+func (b *MultmatrixBlockPrimitive) Inspect() string {
+	return inspectBlock("multmatrix", b.Arguments, b.Children)
+}
 
-	var args []string
-	for _, p := range c.Arguments {
-		args = append(args, p.Inspect())
-	}
+// Type returns the type of the object.
+func (b *MultmatrixBlockPrimitive) Type() T { return MultmatrixBlockPrimitiveT }
 
-	out.WriteString("polygon(")
-	out.WriteString(strings.Join(args, ", "))
-	out.WriteString(");\n")
+// OffsetBlockPrimitive represents an object of that type.
+type OffsetBlockPrimitive struct {
+	Arguments []Object
+	Children  []Object
+}
 
-	return out.String()
+// Inspect returns a representation of the object value.
+func (b *OffsetBlockPrimitive) Inspect() string {
+	return inspectBlock("offset", b.Arguments, b.Children)
 }
 
 // Type returns the type of the object.
-func (c *PolygonPrimitive) Type() T { return PolygonPrimitiveT }
+func (b *OffsetBlockPrimitive) Type() T { return OffsetBlockPrimitiveT }
 
-// SpherePrimitive represents an object of that type.
-type SpherePrimitive struct {
+// ProjectionBlockPrimitive represents an object of that type.
+type ProjectionBlockPrimitive struct {
 	Arguments []Object
+	Children  []Object
 }
 
 // Inspect returns a representation of the object value.
-func (c *SpherePrimitive) Inspect() string {
-	var out bytes.Buffer
+func (b *ProjectionBlockPrimitive) Inspect() string {
+	return inspectBlock("projection", b.Arguments, b.Children)
+}
 
-	var args []string
-	for _, p := range c.Arguments {
-		args = append(args, p.Inspect())
-	}
+// Type returns the type of the object.
+func (b *ProjectionBlockPrimitive) Type() T { return ProjectionBlockPrimitiveT }
 
-	out.WriteString("sphere(")
-	out.WriteString(strings.Join(args, ", "))
-	out.WriteString(");\n")
+// ResizeBlockPrimitive represents an object of that type.
+type ResizeBlockPrimitive struct {
+	Arguments []Object
+	Children  []Object
+}
 
-	return out.String()
+// Inspect returns a representation of the object value.
+func (b *ResizeBlockPrimitive) Inspect() string {
+	return inspectBlock("resize", b.Arguments, b.Children)
 }
 
 // Type returns the type of the object.
-func (c *SpherePrimitive) Type() T { return SpherePrimitiveT }
+func (b *ResizeBlockPrimitive) Type() T { return ResizeBlockPrimitiveT }
 
-// SquarePrimitive represents an object of that type.
-type SquarePrimitive struct {
+// RotateBlockPrimitive represents an object of that type.
+type RotateBlockPrimitive struct {
 	Arguments []Object
+	Children  []Object
 }
 
 // Inspect returns a representation of the object value.
-func (c *SquarePrimitive) Inspect() string {
-	var out bytes.Buffer
+func (b *RotateBlockPrimitive) Inspect() string {
+	return inspectBlock("rotate", b.Arguments, b.Children)
+}
 
-	var args []string
-	for _, p := range c.Arguments {
-		args = append(args, p.Inspect())
-	}
+// Type returns the type of the object.
+func (b *RotateBlockPrimitive) Type() T { return RotateBlockPrimitiveT }
 
-	out.WriteString("square(")
-	out.WriteString(strings.Join(args, ", "))
-	out.WriteString(");\n")
+// RotateExtrudeBlockPrimitive represents an object of that type.
+type RotateExtrudeBlockPrimitive struct {
+	Arguments []Object
+	Children  []Object
+}
 
-	return out.String()
+// Inspect returns a representation of the object value.
+func (b *RotateExtrudeBlockPrimitive) Inspect() string {
+	return inspectBlock("rotate_extrude", b.Arguments, b.Children)
 }
 
 // Type returns the type of the object.
-func (c *SquarePrimitive) Type() T { return SquarePrimitiveT }
+func (b *RotateExtrudeBlockPrimitive) Type() T { return RotateExtrudeBlockPrimitiveT }
 
-// UnionBlockPrimitive represents an object of that type.
-type UnionBlockPrimitive struct {
-	Body Object
+// ScaleBlockPrimitive represents an object of that type.
+type ScaleBlockPrimitive struct {
+	Arguments []Object
+	Children  []Object
 }
 
 // Inspect returns a representation of the object value.
-func (g *UnionBlockPrimitive) Inspect() string {
-	var out bytes.Buffer
+func (b *ScaleBlockPrimitive) Inspect() string { return inspectBlock("scale", b.Arguments, b.Children) }
 
-	out.WriteString("union() {\n")
-	out.WriteString(g.Body.Inspect())
-	out.WriteString("\n}")
+// Type returns the type of the object.
+func (b *ScaleBlockPrimitive) Type() T { return ScaleBlockPrimitiveT }
 
-	return out.String()
+// TranslateBlockPrimitive represents an object of that type.
+type TranslateBlockPrimitive struct {
+	Arguments []Object
+	Children  []Object
 }
 
+// Inspect returns a representation of the object value.
+func (b *TranslateBlockPrimitive) Inspect() string {
+	return inspectBlock("translate", b.Arguments, b.Children)
+}
+
+// Type returns the type of the object.
+func (b *TranslateBlockPrimitive) Type() T { return TranslateBlockPrimitiveT }
+
+// UnionBlockPrimitive represents an object of that type.
+type UnionBlockPrimitive struct {
+	Children []Object
+}
+
+// Inspect returns a representation of the object value.
+func (b *UnionBlockPrimitive) Inspect() string { return inspectBlock("union", nil, b.Children) }
+
 // Type returns the type of the object.
-func (g *UnionBlockPrimitive) Type() T { return UnionBlockPrimitiveT }
+func (b *UnionBlockPrimitive) Type() T { return UnionBlockPrimitiveT }
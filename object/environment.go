@@ -1,5 +1,7 @@
 package object
 
+import "sort"
+
 // Environment represents a programming language environment.
 type Environment struct {
 	store map[string]Object
@@ -34,3 +36,20 @@ func (e *Environment) Set(name string, val Object) Object {
 	e.store[name] = val
 	return val
 }
+
+// Names returns the sorted names of the objects
+// in the environment and its outer environments.
+func (e *Environment) Names() []string {
+	seen := map[string]bool{}
+	var names []string
+	for env := e; env != nil; env = env.outer {
+		for name := range env.store {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
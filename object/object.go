@@ -28,15 +28,34 @@ const (
 	HashT        = "HASH"
 
 	// CSG
-	CubePrimitiveT            = "CUBE"
-	CylinderPrimitiveT        = "CYLINDER"
-	GroupBlockPrimitiveT      = "GROUP"
-	MultmatrixBlockPrimitiveT = "MULTMATRIX"
-	NamedArgumentT            = "NAMED_ARGUMENT"
-	PolygonPrimitiveT         = "POLYGON"
-	SpherePrimitiveT          = "SPHERE"
-	SquarePrimitiveT          = "SQUARE"
-	UnionBlockPrimitiveT      = "UNION"
+	CirclePrimitiveT             = "CIRCLE"
+	ColorBlockPrimitiveT         = "COLOR"
+	CubePrimitiveT               = "CUBE"
+	CylinderPrimitiveT           = "CYLINDER"
+	DifferenceBlockPrimitiveT    = "DIFFERENCE"
+	GroupBlockPrimitiveT         = "GROUP"
+	GroupPrimitiveT              = "GROUP_PRIMITIVE"
+	HullBlockPrimitiveT          = "HULL"
+	IntersectionBlockPrimitiveT  = "INTERSECTION"
+	LineCommentT                 = "LINE_COMMENT"
+	LinearExtrudeBlockPrimitiveT = "LINEAR_EXTRUDE"
+	MinkowskiBlockPrimitiveT     = "MINKOWSKI"
+	MirrorBlockPrimitiveT        = "MIRROR"
+	MultmatrixBlockPrimitiveT    = "MULTMATRIX"
+	NamedArgumentT               = "NAMED_ARGUMENT"
+	OffsetBlockPrimitiveT        = "OFFSET"
+	PolygonPrimitiveT            = "POLYGON"
+	PolyhedronPrimitiveT         = "POLYHEDRON"
+	ProjectionBlockPrimitiveT    = "PROJECTION"
+	ResizeBlockPrimitiveT        = "RESIZE"
+	RotateBlockPrimitiveT        = "ROTATE"
+	RotateExtrudeBlockPrimitiveT = "ROTATE_EXTRUDE"
+	ScaleBlockPrimitiveT         = "SCALE"
+	SpherePrimitiveT             = "SPHERE"
+	SquarePrimitiveT             = "SQUARE"
+	TextPrimitiveT               = "TEXT"
+	TranslateBlockPrimitiveT     = "TRANSLATE"
+	UnionBlockPrimitiveT         = "UNION"
 )
 
 // Object represents an object or value type within the language.
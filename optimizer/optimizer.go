@@ -0,0 +1,232 @@
+// Package optimizer simplifies a CSG ast.Program without changing its
+// geometry. It removes empty and single-child groups, lowers the
+// transforms to multmatrix blocks, composes nested multmatrix blocks
+// into a single matrix, drops identity matrices, and pushes transforms
+// into the primitives that can absorb them exactly.
+//
+// The result produces smaller, faster IRMF shaders.
+package optimizer
+
+import (
+	"github.com/gmlewis/go-csg/ast"
+	"github.com/gmlewis/go-csg/transform"
+)
+
+// Optimize rewrites the program in place and returns it.
+func Optimize(program *ast.Program) *ast.Program {
+	return ast.Rewrite(program, optimize).(*ast.Program)
+}
+
+func optimize(node ast.Node) ast.Node {
+	switch n := node.(type) {
+	case *ast.Program:
+		n.Statements = simplifyChildren(n.Statements, unionLike)
+	case *ast.ColorBlockPrimitive:
+		simplifyBody(n.Body, unionLike)
+	case *ast.DifferenceBlockPrimitive:
+		simplifyBody(n.Body, difference)
+	case *ast.GroupBlockPrimitive:
+		simplifyBody(n.Body, unionLike)
+	case *ast.HullBlockPrimitive:
+		simplifyBody(n.Body, unionLike)
+	case *ast.IntersectionBlockPrimitive:
+		simplifyBody(n.Body, ordered)
+	case *ast.LinearExtrudeBlockPrimitive:
+		simplifyBody(n.Body, unionLike)
+	case *ast.MinkowskiBlockPrimitive:
+		simplifyBody(n.Body, ordered)
+	case *ast.OffsetBlockPrimitive:
+		simplifyBody(n.Body, unionLike)
+	case *ast.ProjectionBlockPrimitive:
+		simplifyBody(n.Body, unionLike)
+	case *ast.RotateExtrudeBlockPrimitive:
+		simplifyBody(n.Body, unionLike)
+	case *ast.UnionBlockPrimitive:
+		simplifyBody(n.Body, unionLike)
+	case *ast.MultmatrixBlockPrimitive:
+		simplifyBody(n.Body, unionLike)
+		return optimizeMultmatrix(n)
+	case *ast.ResizeBlockPrimitive:
+		// The size of the children is unknown here.
+		simplifyBody(n.Body, unionLike)
+	case *ast.MirrorBlockPrimitive, *ast.RotateBlockPrimitive,
+		*ast.ScaleBlockPrimitive, *ast.TranslateBlockPrimitive:
+		mm, err := transform.Lower(n.(ast.Expression), nil)
+		if err != nil {
+			return node
+		}
+		return optimize(mm)
+	}
+	return node
+}
+
+// parentKind describes how a block combines its children, which
+// determines how its children may be simplified.
+type parentKind int
+
+const (
+	// unionLike parents (e.g. group, union, multmatrix) implicitly union
+	// their children, so groups may be spliced and empty children removed.
+	unionLike parentKind = iota
+	// difference parents treat their first child specially.
+	difference
+	// ordered parents (intersection, minkowski) combine every child,
+	// so even empty children are significant.
+	ordered
+)
+
+func simplifyBody(body *ast.BlockStatement, kind parentKind) {
+	if body != nil {
+		body.Statements = simplifyChildren(body.Statements, kind)
+	}
+}
+
+// simplifyChildren removes empty children and splices the bodies of
+// groups (and identity multmatrix blocks) into the statement list
+// when that does not change the geometry.
+func simplifyChildren(stmts []ast.Statement, kind parentKind) []ast.Statement {
+	var result []ast.Statement
+	var index int // index of the next geometric child
+	for _, stmt := range stmts {
+		exp := expression(stmt)
+		if exp == nil || isComment(exp) {
+			result = append(result, stmt)
+			continue
+		}
+
+		body, isGroup := groupBody(exp)
+		removable := kind == unionLike || (kind == difference && index > 0)
+		switch {
+		case isEmpty(exp) && removable:
+			continue
+		case isGroup && body != nil && len(geometry(body.Statements)) == 1:
+			result = append(result, body.Statements...)
+		case isGroup && body != nil && kind == unionLike:
+			result = append(result, body.Statements...)
+		case isGroup && !isGroupNode(exp):
+			// An identity multmatrix with several children is a group.
+			group := ast.NewGroup(body)
+			result = append(result, &ast.ExpressionStatement{Token: group.Token, Expression: group})
+		default:
+			result = append(result, stmt)
+		}
+		index++
+	}
+	return result
+}
+
+// groupBody returns the body of a node that merely groups its children:
+// group, union, or a multmatrix with the identity matrix.
+func groupBody(exp ast.Expression) (*ast.BlockStatement, bool) {
+	switch n := exp.(type) {
+	case *ast.GroupBlockPrimitive:
+		return n.Body, true
+	case *ast.UnionBlockPrimitive:
+		return n.Body, true
+	case *ast.MultmatrixBlockPrimitive:
+		if m, ok := matrix(n); ok && m.IsIdentity() {
+			return n.Body, true
+		}
+	}
+	return nil, false
+}
+
+func isGroupNode(exp ast.Expression) bool {
+	switch exp.(type) {
+	case *ast.GroupBlockPrimitive, *ast.UnionBlockPrimitive:
+		return true
+	}
+	return false
+}
+
+// isEmpty reports whether the node is a block primitive without any
+// geometric children (whose result is always empty).
+func isEmpty(exp ast.Expression) bool {
+	switch exp.(type) {
+	case *ast.GroupPrimitive:
+		return true
+	case *ast.CirclePrimitive, *ast.CubePrimitive, *ast.CylinderPrimitive, *ast.PolygonPrimitive,
+		*ast.PolyhedronPrimitive, *ast.SpherePrimitive, *ast.SquarePrimitive, *ast.TextPrimitive:
+		return false // Leaves have no body.
+	}
+	_, body, ok := ast.Parts(exp)
+	return ok && (body == nil || len(geometry(body.Statements)) == 0)
+}
+
+func expression(stmt ast.Statement) ast.Expression {
+	if es, ok := stmt.(*ast.ExpressionStatement); ok {
+		return es.Expression
+	}
+	return nil
+}
+
+func isComment(exp ast.Expression) bool {
+	_, ok := exp.(*ast.LineComment)
+	return ok
+}
+
+// geometry returns the non-comment expressions of the statements.
+func geometry(stmts []ast.Statement) []ast.Expression {
+	var result []ast.Expression
+	for _, stmt := range stmts {
+		if exp := expression(stmt); exp != nil && !isComment(exp) {
+			result = append(result, exp)
+		}
+	}
+	return result
+}
+
+// optimizeMultmatrix composes a multmatrix with a single multmatrix
+// child, and pushes the transform into a single primitive child
+// when possible.
+func optimizeMultmatrix(n *ast.MultmatrixBlockPrimitive) ast.Node {
+	if n.Body == nil {
+		return n
+	}
+	m, ok := matrix(n)
+	if !ok {
+		return n
+	}
+
+	children := geometry(n.Body.Statements)
+	if len(children) != 1 {
+		return n
+	}
+	if inner, ok := children[0].(*ast.MultmatrixBlockPrimitive); ok && inner.Body != nil {
+		if im, ok := matrix(inner); ok {
+			m = m.Mul(im)
+			n.Arguments = []ast.Expression{transform.Literal(m)}
+			n.Body = spliceBody(n.Body, inner.Body)
+			children = geometry(n.Body.Statements)
+			if len(children) != 1 {
+				return n
+			}
+		}
+	}
+
+	if len(n.Body.Statements) == 1 {
+		if prim, ok := transformPrimitive(children[0], m); ok {
+			return prim
+		}
+	}
+	return n
+}
+
+// spliceBody returns the outer body with its (only) inner block
+// replaced by the statements of the inner block's body.
+func spliceBody(outer, inner *ast.BlockStatement) *ast.BlockStatement {
+	var stmts []ast.Statement
+	for _, stmt := range outer.Statements {
+		if exp := expression(stmt); exp != nil && !isComment(exp) {
+			stmts = append(stmts, inner.Statements...)
+			continue
+		}
+		stmts = append(stmts, stmt)
+	}
+	return &ast.BlockStatement{Token: outer.Token, Statements: stmts}
+}
+
+func matrix(n *ast.MultmatrixBlockPrimitive) (transform.Matrix, bool) {
+	m, err := transform.Of(n, nil)
+	return m, err == nil
+}
@@ -0,0 +1,109 @@
+package optimizer
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/gmlewis/go-csg/lexer"
+	"github.com/gmlewis/go-csg/parser"
+	"github.com/gmlewis/go-csg/printer"
+)
+
+func TestOptimize(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{
+			src:  "group() { group() { cube(size = [1, 2, 3], center = false); } }",
+			want: "cube(size = [1, 2, 3], center = false);\n",
+		},
+		{
+			src:  "group(); group() { } union() { sphere(r = 1); cube(); }",
+			want: "sphere(r = 1);\ncube();\n",
+		},
+		{
+			src:  "translate([1, 0, 0]); difference(); rotate([0, 0, 90]) { } cube();",
+			want: "cube();\n",
+		},
+		{
+			src:  "difference() { group(); cube(); group(); }",
+			want: "difference() {\n\tgroup();\n\tcube();\n}\n",
+		},
+		{
+			src:  "intersection() { group(); cube(); union() { sphere(); cube(); } }",
+			want: "intersection() {\n\tgroup();\n\tcube();\n\tunion() {\n\t\tsphere();\n\t\tcube();\n\t}\n}\n",
+		},
+		{
+			src:  "multmatrix([[1, 0, 0, 0], [0, 1, 0, 0], [0, 0, 1, 0], [0, 0, 0, 1]]) { cube(); sphere(); }",
+			want: "cube();\nsphere();\n",
+		},
+		{
+			src:  "difference() { multmatrix([[1, 0, 0, 0], [0, 1, 0, 0], [0, 0, 1, 0], [0, 0, 0, 1]]) { cube(); sphere(); } cube(); }",
+			want: "difference() {\n\tgroup() {\n\t\tcube();\n\t\tsphere();\n\t}\n\tcube();\n}\n",
+		},
+		{
+			src:  "multmatrix([[1, 0, 0, 5], [0, 1, 0, 0], [0, 0, 1, 0], [0, 0, 0, 1]]) { group() { multmatrix([[2, 0, 0, 0], [0, 2, 0, 1], [0, 0, 2, 0], [0, 0, 0, 1]]) { cube(); sphere(); } } }",
+			want: "multmatrix([[2, 0, 0, 5], [0, 2, 0, 1], [0, 0, 2, 0], [0, 0, 0, 1]]) {\n\tcube();\n\tsphere();\n}\n",
+		},
+		{
+			src:  "translate([5, 0, 0]) { rotate([0, 0, 90]) { cube(); sphere(); } }",
+			want: "multmatrix([[0, -1, 0, 5], [1, 0, 0, 0], [0, 0, 1, 0], [0, 0, 0, 1]]) {\n\tcube();\n\tsphere();\n}\n",
+		},
+		{
+			src:  "scale([2, 3, 4]) { cube(size = [1, 2, 3], center = true); }",
+			want: "cube(size = [2, 6, 12], center = true);\n",
+		},
+		{
+			src:  "resize([2, 0, 0]) { group() { cube(); } }",
+			want: "resize([2, 0, 0]) {\n\tcube();\n}\n",
+		},
+		{
+			src:  "multmatrix([[2, 0, 0, 0], [0, 3, 0, 0], [0, 0, 4, 0], [0, 0, 0, 1]]) { cube(size = [1, 2, 3], center = true); }",
+			want: "cube(size = [2, 6, 12], center = true);\n",
+		},
+		{
+			src:  "multmatrix([[1, 0, 0, 1], [0, 1, 0, 0], [0, 0, 1, 0], [0, 0, 0, 1]]) { cube(size = [1, 2, 3], center = true); }",
+			want: "multmatrix([[1, 0, 0, 1], [0, 1, 0, 0], [0, 0, 1, 0], [0, 0, 0, 1]]) {\n\tcube(size = [1, 2, 3], center = true);\n}\n",
+		},
+		{
+			src:  "multmatrix([[2, 0, 0, 0], [0, 2, 0, 0], [0, 0, 2, 0], [0, 0, 0, 1]]) { sphere($fn = 0, $fa = 12, $fs = 2, d = 10); }",
+			want: "sphere($fn = 16, $fa = 12, $fs = 2, r = 10);\n",
+		},
+		{
+			src:  "multmatrix([[0.5, 0, 0, 0], [0, 0.5, 0, 0], [0, 0, 3, 0], [0, 0, 0, 1]]) { cylinder($fn = 8, h = 2, r1 = 1, r2 = 3, center = false); }",
+			want: "cylinder($fn = 8, $fa = 12, $fs = 2, h = 6, r1 = 0.5, r2 = 1.5, center = false);\n",
+		},
+		{
+			src:  "multmatrix([[1, 0, 0, 1], [0, -1, 0, 2], [0, 0, 1, 0], [0, 0, 0, 1]]) { polygon(points = [[0, 0], [1, 0], [0, 1]], paths = undef, convexity = 1); }",
+			want: "polygon(points = [[1, 2], [2, 2], [1, 1]], paths = undef, convexity = 1);\n",
+		},
+		{
+			src:  "multmatrix([[-1, 0, 0, 0], [0, 1, 0, 0], [0, 0, 1, 0], [0, 0, 0, 1]]) { polyhedron(points = [[0, 0, 0], [1, 0, 0], [0, 1, 0], [0, 0, 1]], faces = [[0, 1, 2], [0, 3, 1]], convexity = 1); }",
+			want: "polyhedron(points = [[0, 0, 0], [-1, 0, 0], [0, 1, 0], [0, 0, 1]], faces = [[2, 1, 0], [1, 3, 0]], convexity = 1);\n",
+		},
+		{
+			src:  "color([1, 0, 0, 1]) { group() { // comment\ncube(); } }",
+			want: "color([1, 0, 0, 1]) {\n\t// comment\n\tcube();\n}\n",
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test #%v", i), func(t *testing.T) {
+			p := parser.New(lexer.New(tt.src))
+			program := p.ParseProgram()
+			if errs := p.Errors(); len(errs) != 0 {
+				t.Fatalf("ParseProgram: %v", strings.Join(errs, "\n"))
+			}
+
+			got, err := printer.String(Optimize(program))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Optimize =\n%v\nwant:\n%v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,250 @@
+package optimizer
+
+import (
+	"github.com/gmlewis/go-csg/ast"
+	"github.com/gmlewis/go-csg/params"
+	"github.com/gmlewis/go-csg/token"
+	"github.com/gmlewis/go-csg/transform"
+)
+
+// transformPrimitive returns the primitive with the transform m applied
+// to its parameters, if the primitive can represent the result exactly.
+func transformPrimitive(exp ast.Expression, m transform.Matrix) (ast.Expression, bool) {
+	if m[3] != [4]float64{0, 0, 0, 1} {
+		return nil, false
+	}
+
+	switch n := exp.(type) {
+	case *ast.CubePrimitive:
+		sx, sy, sz, ok := scale3(m)
+		if !ok {
+			return nil, false
+		}
+		size, center, err := params.Cube(n.Arguments)
+		if err != nil {
+			return nil, false
+		}
+		n.Arguments = replaceArgs(n.Arguments, []string{"size", "center"},
+			named("size", vector(sx*size[0], sy*size[1], sz*size[2])),
+			named("center", boolean(center)))
+		return n, true
+
+	case *ast.SpherePrimitive:
+		sx, sy, sz, ok := scale3(m)
+		if !ok || sx != sy || sx != sz {
+			return nil, false
+		}
+		r, fragments, err := params.Sphere(n.Arguments)
+		if err != nil {
+			return nil, false
+		}
+		n.Arguments = roundArgs(n.Arguments, []string{"r"}, fragments, named("r", transform.Number(sx*r)))
+		return n, true
+
+	case *ast.CylinderPrimitive:
+		sx, sy, sz, ok := scale3(m)
+		if !ok || sx != sy {
+			return nil, false
+		}
+		c, err := params.Cylinder(n.Arguments)
+		if err != nil {
+			return nil, false
+		}
+		n.Arguments = roundArgs(n.Arguments, []string{"h", "r1", "r2", "center"}, c.Fragments,
+			named("h", transform.Number(sz*c.H)),
+			named("r1", transform.Number(sx*c.R1)),
+			named("r2", transform.Number(sx*c.R2)),
+			named("center", boolean(c.Center)))
+		return n, true
+
+	case *ast.CirclePrimitive:
+		sx, sy, ok := scale2(m)
+		if !ok || sx != sy {
+			return nil, false
+		}
+		r, fragments, err := params.Circle(n.Arguments)
+		if err != nil {
+			return nil, false
+		}
+		n.Arguments = roundArgs(n.Arguments, []string{"r"}, fragments, named("r", transform.Number(sx*r)))
+		return n, true
+
+	case *ast.SquarePrimitive:
+		sx, sy, ok := scale2(m)
+		if !ok {
+			return nil, false
+		}
+		size, center, err := params.Square(n.Arguments)
+		if err != nil {
+			return nil, false
+		}
+		n.Arguments = replaceArgs(n.Arguments, []string{"size", "center"},
+			named("size", vector(sx*size[0], sy*size[1])),
+			named("center", boolean(center)))
+		return n, true
+
+	case *ast.PolygonPrimitive:
+		if !is2D(m) || det(m) == 0 {
+			return nil, false
+		}
+		points, _, err := params.Polygon(n.Arguments)
+		if err != nil {
+			return nil, false
+		}
+		pts := make([]ast.Expression, 0, len(points))
+		for _, p := range points {
+			pts = append(pts, vector(
+				transform.Snap(m[0][0]*p.X+m[0][1]*p.Y+m[0][3]),
+				transform.Snap(m[1][0]*p.X+m[1][1]*p.Y+m[1][3])))
+		}
+		// paths (and any other arguments) are kept as they are.
+		n.Arguments = replaceArgs(n.Arguments, []string{"points", "paths", "convexity"}, named("points", transform.Array(pts)))
+		return n, true
+
+	case *ast.PolyhedronPrimitive:
+		d := det(m)
+		if d == 0 {
+			return nil, false
+		}
+		points, faces, err := params.Polyhedron(n.Arguments)
+		if err != nil {
+			return nil, false
+		}
+		pts := make([]ast.Expression, 0, len(points))
+		for _, p := range points {
+			var v [3]float64
+			for i := 0; i < 3; i++ {
+				v[i] = transform.Snap(m[i][0]*p[0] + m[i][1]*p[1] + m[i][2]*p[2] + m[i][3])
+			}
+			pts = append(pts, vector(v[:]...))
+		}
+		fs := make([]ast.Expression, 0, len(faces))
+		for _, face := range faces {
+			f := make([]ast.Expression, len(face))
+			for i, v := range face {
+				if d < 0 {
+					// A mirroring transform reverses the winding of the faces.
+					f[len(face)-1-i] = transform.Number(float64(v))
+				} else {
+					f[i] = transform.Number(float64(v))
+				}
+			}
+			fs = append(fs, transform.Array(f))
+		}
+		n.Arguments = replaceArgs(n.Arguments, []string{"points", "faces", "convexity"}, named("points", transform.Array(pts)), named("faces", transform.Array(fs)))
+		return n, true
+	}
+	return nil, false
+}
+
+// scale3 returns the scale factors of m if it is a positive
+// diagonal scale without translation.
+func scale3(m transform.Matrix) (sx, sy, sz float64, ok bool) {
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 4; j++ {
+			if i != j && m[i][j] != 0 {
+				return 0, 0, 0, false
+			}
+		}
+		if m[i][i] <= 0 {
+			return 0, 0, 0, false
+		}
+	}
+	return m[0][0], m[1][1], m[2][2], true
+}
+
+// scale2 returns the XY scale factors of m if it is a positive
+// diagonal scale without translation that leaves Z unchanged.
+func scale2(m transform.Matrix) (sx, sy float64, ok bool) {
+	if !is2D(m) || m[0][1] != 0 || m[1][0] != 0 || m[0][3] != 0 || m[1][3] != 0 ||
+		m[0][0] <= 0 || m[1][1] <= 0 {
+		return 0, 0, false
+	}
+	return m[0][0], m[1][1], true
+}
+
+// is2D reports whether m only transforms the XY plane.
+func is2D(m transform.Matrix) bool {
+	return m[0][2] == 0 && m[1][2] == 0 &&
+		m[2] == [4]float64{0, 0, 1, 0}
+}
+
+// det returns the determinant of the upper-left 3x3 part of m.
+func det(m transform.Matrix) float64 {
+	return m[0][0]*(m[1][1]*m[2][2]-m[1][2]*m[2][1]) -
+		m[0][1]*(m[1][0]*m[2][2]-m[1][2]*m[2][0]) +
+		m[0][2]*(m[1][0]*m[2][1]-m[1][1]*m[2][0])
+}
+
+// roundArgs replaces the arguments of a round primitive, pinning
+// its fragment count with $fn so that the scaled primitive is
+// faceted exactly like the original one. Diameters are replaced
+// by the radii in values.
+func roundArgs(args []ast.Expression, positional []string, fragments int, values ...*ast.NamedArgument) []ast.Expression {
+	a, err := params.Parse(args)
+	if err != nil {
+		return args
+	}
+	fa, _ := a.Float(-1, "$fa", 12)
+	fs, _ := a.Float(-1, "$fs", 2)
+	values = append([]*ast.NamedArgument{
+		named("$fn", transform.Number(float64(fragments))),
+		named("$fa", transform.Number(fa)),
+		named("$fs", transform.Number(fs)),
+	}, values...)
+	values = append(values, named("r", nil), named("d", nil), named("d1", nil), named("d2", nil))
+	return replaceArgs(args, positional, values...)
+}
+
+// replaceArgs returns the given named arguments followed by the
+// remaining arguments of args. Positional arguments are converted to
+// named arguments using the positional names. A value of nil removes
+// the argument.
+func replaceArgs(args []ast.Expression, positional []string, values ...*ast.NamedArgument) []ast.Expression {
+	replaced := map[string]bool{}
+	result := make([]ast.Expression, 0, len(args)+len(values))
+	for _, v := range values {
+		replaced[v.Name.String()] = true
+		if v.Value != nil {
+			result = append(result, v)
+		}
+	}
+	var i int
+	for _, arg := range args {
+		na, ok := arg.(*ast.NamedArgument)
+		if !ok {
+			if i >= len(positional) {
+				continue
+			}
+			na = named(positional[i], arg)
+			i++
+		}
+		if !replaced[na.Name.String()] {
+			result = append(result, na)
+		}
+	}
+	return result
+}
+
+func named(name string, value ast.Expression) *ast.NamedArgument {
+	return &ast.NamedArgument{
+		Token: token.Token{Type: token.ASSIGN, Literal: "="},
+		Name:  &ast.Identifier{Token: token.Token{Type: token.IDENT, Literal: name}, Value: name},
+		Value: value,
+	}
+}
+
+func boolean(v bool) ast.Expression {
+	if v {
+		return &ast.BooleanLiteral{Token: token.Token{Type: token.TRUE, Literal: "true"}, Value: true}
+	}
+	return &ast.BooleanLiteral{Token: token.Token{Type: token.FALSE, Literal: "false"}, Value: false}
+}
+
+func vector(vs ...float64) *ast.ArrayLiteral {
+	elements := make([]ast.Expression, 0, len(vs))
+	for _, v := range vs {
+		elements = append(elements, transform.Number(v))
+	}
+	return transform.Array(elements)
+}
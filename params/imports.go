@@ -0,0 +1,44 @@
+package params
+
+import (
+	"fmt"
+
+	"github.com/gmlewis/go-csg/ast"
+)
+
+// Import returns the filename of an import() call.
+func Import(exps []ast.Expression) (string, error) {
+	a, err := Parse(exps)
+	if err != nil {
+		return "", err
+	}
+	file, err := a.String(0, "file", "")
+	if err != nil {
+		return "", err
+	}
+	if file == "" {
+		return "", fmt.Errorf("import: missing file")
+	}
+	return file, nil
+}
+
+// Imports returns the filenames of the import() calls within node,
+// without duplicates, in the order that they appear.
+func Imports(node ast.Node) ([]string, error) {
+	var result []string
+	var err error
+	seen := map[string]bool{}
+	ast.Inspect(node, func(n ast.Node) bool {
+		ce, ok := n.(*ast.CallExpression)
+		if !ok || err != nil || ce.Function.String() != "import" {
+			return err == nil
+		}
+		var file string
+		if file, err = Import(ce.Arguments); err == nil && !seen[file] {
+			seen[file] = true
+			result = append(result, file)
+		}
+		return false
+	})
+	return result, err
+}
@@ -0,0 +1,212 @@
+// Package params resolves the arguments of CSG primitives
+// to concrete Go values.
+package params
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/gmlewis/go-csg/ast"
+	"github.com/gmlewis/go-csg/evaluator"
+	"github.com/gmlewis/go-csg/object"
+)
+
+// Args represents the evaluated arguments of a CSG primitive.
+type Args struct {
+	Positional []object.Object
+	Named      map[string]object.Object
+}
+
+// Parse evaluates the argument expressions of a CSG primitive.
+func Parse(exps []ast.Expression) (*Args, error) {
+	env := object.NewEnvironment()
+	args := &Args{Named: map[string]object.Object{}}
+	for _, exp := range exps {
+		obj := evaluator.Eval(exp, env)
+		switch obj := obj.(type) {
+		case *object.Error:
+			return nil, fmt.Errorf("unable to evaluate argument %v: %v", exp.String(), obj.Message)
+		case *object.NamedArgument:
+			args.Named[obj.Name] = obj.Value
+		default:
+			args.Positional = append(args.Positional, obj)
+		}
+	}
+	return args, nil
+}
+
+// Get returns the named argument if present, otherwise the
+// positional argument at index i (use i<0 for named-only arguments).
+// It returns nil if the argument is missing or undef.
+func (a *Args) Get(i int, name string) object.Object {
+	if v, ok := a.Named[name]; ok {
+		if _, isNull := v.(*object.Null); isNull {
+			return nil
+		}
+		return v
+	}
+	if i >= 0 && i < len(a.Positional) {
+		if _, isNull := a.Positional[i].(*object.Null); isNull {
+			return nil
+		}
+		return a.Positional[i]
+	}
+	return nil
+}
+
+// Has reports whether the argument is present (and not undef).
+func (a *Args) Has(i int, name string) bool {
+	return a.Get(i, name) != nil
+}
+
+// Float returns the argument as a number, or def if it is missing.
+func (a *Args) Float(i int, name string, def float64) (float64, error) {
+	obj := a.Get(i, name)
+	if obj == nil {
+		return def, nil
+	}
+	v, ok := ToFloat(obj)
+	if !ok {
+		return 0, fmt.Errorf("argument %q: expected number, got %v", name, obj.Inspect())
+	}
+	return v, nil
+}
+
+// Bool returns the argument as a boolean, or def if it is missing.
+func (a *Args) Bool(i int, name string, def bool) (bool, error) {
+	obj := a.Get(i, name)
+	if obj == nil {
+		return def, nil
+	}
+	v, ok := obj.(*object.Boolean)
+	if !ok {
+		return false, fmt.Errorf("argument %q: expected boolean, got %v", name, obj.Inspect())
+	}
+	return v.Value, nil
+}
+
+// String returns the argument as a string, or def if it is missing.
+func (a *Args) String(i int, name string, def string) (string, error) {
+	obj := a.Get(i, name)
+	if obj == nil {
+		return def, nil
+	}
+	v, ok := obj.(*object.String)
+	if !ok {
+		return "", fmt.Errorf("argument %q: expected string, got %v", name, obj.Inspect())
+	}
+	return v.Value, nil
+}
+
+// Vec returns the argument as a vector of n numbers, or def if it
+// is missing. A scalar argument is expanded to all n components.
+func (a *Args) Vec(i int, name string, n int, def []float64) ([]float64, error) {
+	obj := a.Get(i, name)
+	if obj == nil {
+		return def, nil
+	}
+	if v, ok := ToFloat(obj); ok {
+		result := make([]float64, n)
+		for j := range result {
+			result[j] = v
+		}
+		return result, nil
+	}
+	v, ok := ToVec(obj)
+	if !ok || len(v) < n {
+		return nil, fmt.Errorf("argument %q: expected vector of %v numbers, got %v", name, n, obj.Inspect())
+	}
+	return v[:n], nil
+}
+
+// ToFloat converts a number object to a float64.
+func ToFloat(obj object.Object) (float64, bool) {
+	switch v := obj.(type) {
+	case *object.Float:
+		return v.Value, true
+	case *object.Integer:
+		return float64(v.Value), true
+	}
+	return 0, false
+}
+
+// ToArray returns the elements of an array object.
+func ToArray(obj object.Object) ([]object.Object, bool) {
+	array, ok := obj.(*object.Array)
+	if !ok {
+		return nil, false
+	}
+	return array.Elements, true
+}
+
+// ToVec converts an array of numbers to a []float64.
+func ToVec(obj object.Object) ([]float64, bool) {
+	array, ok := obj.(*object.Array)
+	if !ok {
+		return nil, false
+	}
+	result := make([]float64, 0, len(array.Elements))
+	for _, el := range array.Elements {
+		v, ok := ToFloat(el)
+		if !ok {
+			return nil, false
+		}
+		result = append(result, v)
+	}
+	return result, true
+}
+
+// ToMatrix converts an array of 4 arrays of 4 numbers to a 4x4 matrix
+// (as found in multmatrix).
+func ToMatrix(obj object.Object) ([4][4]float64, bool) {
+	var m [4][4]float64
+	array, ok := obj.(*object.Array)
+	if !ok || len(array.Elements) != 4 {
+		return m, false
+	}
+	for i, row := range array.Elements {
+		v, ok := ToVec(row)
+		if !ok || len(v) != 4 {
+			return m, false
+		}
+		copy(m[i][:], v)
+	}
+	return m, true
+}
+
+// gridFine is OpenSCAD's GRID_FINE constant.
+const gridFine = 0.00000095367431640625
+
+// FragmentsFromR returns the number of fragments used by OpenSCAD
+// to approximate a circle of radius r. It mirrors OpenSCAD's
+// get_fragments_from_r function.
+func FragmentsFromR(r, fn, fs, fa float64) int {
+	if r < gridFine || math.IsInf(fn, 0) || math.IsNaN(fn) {
+		return 3
+	}
+	if fn > 0 {
+		if fn >= 3 {
+			return int(fn)
+		}
+		return 3
+	}
+	return int(math.Ceil(math.Max(math.Min(360.0/fa, r*2*math.Pi/fs), 5)))
+}
+
+// Fragments returns the number of fragments for a circle of radius r
+// using the $fn, $fs and $fa special variables found in the arguments.
+func (a *Args) Fragments(r float64) (int, error) {
+	fn, err := a.Float(-1, "$fn", 0)
+	if err != nil {
+		return 0, err
+	}
+	fs, err := a.Float(-1, "$fs", 2)
+	if err != nil {
+		return 0, err
+	}
+	fa, err := a.Float(-1, "$fa", 12)
+	if err != nil {
+		return 0, err
+	}
+	return FragmentsFromR(r, fn, fs, fa), nil
+}
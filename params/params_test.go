@@ -0,0 +1,213 @@
+package params
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gmlewis/go-csg/ast"
+	"github.com/gmlewis/go-csg/lexer"
+	"github.com/gmlewis/go-csg/parser"
+)
+
+func TestFragmentsFromR(t *testing.T) {
+	tests := []struct {
+		r, fn, fs, fa float64
+		want          int
+	}{
+		{r: 10, fn: 0, fs: 2, fa: 12, want: 30},
+		{r: 1, fn: 0, fs: 2, fa: 12, want: 5},
+		{r: 10, fn: 8, fs: 2, fa: 12, want: 8},
+		{r: 10, fn: 2, fs: 2, fa: 12, want: 3},
+		{r: 0, fn: 0, fs: 2, fa: 12, want: 3},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test #%v", i), func(t *testing.T) {
+			if got := FragmentsFromR(tt.r, tt.fn, tt.fs, tt.fa); got != tt.want {
+				t.Errorf("FragmentsFromR = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolygon(t *testing.T) {
+	tests := []struct {
+		src    string
+		points []Point
+		paths  [][]int
+	}{
+		{
+			src:    "polygon(points = [[0, 0], [1, 0], [0, 1]], paths = undef, convexity = 1);",
+			points: []Point{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 0, Y: 1}},
+		},
+		{
+			src:    "polygon(points = [[0, 0], [1, 0], [0, 1]], paths = [[0, 1, 2]], convexity = 1);",
+			points: []Point{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 0, Y: 1}},
+			paths:  [][]int{{0, 1, 2}},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test #%v", i), func(t *testing.T) {
+			points, paths, err := Polygon(parseArgs(t, tt.src))
+			if err != nil {
+				t.Fatalf("Polygon: %v", err)
+			}
+			if !reflect.DeepEqual(points, tt.points) {
+				t.Errorf("points = %+v, want %+v", points, tt.points)
+			}
+			if !reflect.DeepEqual(paths, tt.paths) {
+				t.Errorf("paths = %+v, want %+v", paths, tt.paths)
+			}
+		})
+	}
+}
+
+func TestCylinder(t *testing.T) {
+	tests := []struct {
+		src  string
+		want *CylinderParams
+	}{
+		{
+			src:  "cylinder($fn = 0, $fa = 12, $fs = 2, h = 10, r1 = 1, r2 = 2, center = true);",
+			want: &CylinderParams{H: 10, R1: 1, R2: 2, Center: true, Fragments: 7},
+		},
+		{
+			src:  "cylinder($fn = 6, $fa = 12, $fs = 2, h = 3, r = 4, center = false);",
+			want: &CylinderParams{H: 3, R1: 4, R2: 4, Fragments: 6},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test #%v", i), func(t *testing.T) {
+			got, err := Cylinder(parseArgs(t, tt.src))
+			if err != nil {
+				t.Fatalf("Cylinder: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Cylinder = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLinearExtrude(t *testing.T) {
+	tests := []struct {
+		src  string
+		want *LinearExtrudeParams
+	}{
+		{
+			src:  "linear_extrude() { }",
+			want: &LinearExtrudeParams{Height: 100, Convexity: 1, Slices: 1, Scale: [2]float64{1, 1}, V: [3]float64{0, 0, 1}},
+		},
+		{
+			src:  "linear_extrude(height = 10, center = true, convexity = 10, twist = -100, slices = 20, scale = [1, 0]) { }",
+			want: &LinearExtrudeParams{Height: 10, Center: true, Convexity: 10, Twist: -100, Slices: 20, Scale: [2]float64{1, 0}, V: [3]float64{0, 0, 1}},
+		},
+		{
+			src:  "linear_extrude(height = 100, twist = 90, scale = 2) { }",
+			want: &LinearExtrudeParams{Height: 100, Convexity: 1, Twist: 90, Slices: 7, Scale: [2]float64{2, 2}, V: [3]float64{0, 0, 1}},
+		},
+		{
+			src:  "linear_extrude(v = [3, 0, 4]) { }",
+			want: &LinearExtrudeParams{Height: 5, Convexity: 1, Slices: 1, Scale: [2]float64{1, 1}, V: [3]float64{0.6, 0, 0.8}},
+		},
+		{
+			src:  "linear_extrude(height = 2, v = [0, 0, 4]) { }",
+			want: &LinearExtrudeParams{Height: 2, Convexity: 1, Slices: 1, Scale: [2]float64{1, 1}, V: [3]float64{0, 0, 1}},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test #%v", i), func(t *testing.T) {
+			got, err := LinearExtrude(parseArgs(t, tt.src))
+			if err != nil {
+				t.Fatalf("LinearExtrude: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LinearExtrude = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRotateExtrude(t *testing.T) {
+	tests := []struct {
+		src  string
+		want float64
+	}{
+		{src: "rotate_extrude() { }", want: 360},
+		{src: "rotate_extrude(angle = 90) { }", want: 90},
+		{src: "rotate_extrude(angle = -270) { }", want: -270},
+		{src: "rotate_extrude(angle = -360) { }", want: 360},
+		{src: "rotate_extrude(angle = 400) { }", want: 360},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test #%v", i), func(t *testing.T) {
+			got, err := RotateExtrude(parseArgs(t, tt.src))
+			if err != nil {
+				t.Fatalf("RotateExtrude: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("RotateExtrude = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImports(t *testing.T) {
+	tests := []struct {
+		src  string
+		want []string
+	}{
+		{src: "cube(size = 1);"},
+		{
+			src:  `import(file = "a.stl", layer = "", convexity = 1); union() { import("b.dxf"); import(file = "a.stl"); }`,
+			want: []string{"a.stl", "b.dxf"},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test #%v", i), func(t *testing.T) {
+			le := lexer.New(tt.src)
+			p := parser.New(le)
+			program := p.ParseProgram()
+			if errs := p.Errors(); len(errs) != 0 {
+				t.Fatalf("ParseProgram: %v", strings.Join(errs, "\n"))
+			}
+			got, err := Imports(program)
+			if err != nil {
+				t.Fatalf("Imports: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Imports = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func parseArgs(t *testing.T, src string) []ast.Expression {
+	t.Helper()
+	le := lexer.New(src)
+	p := parser.New(le)
+	program := p.ParseProgram()
+	if errs := p.Errors(); len(errs) != 0 {
+		t.Fatalf("ParseProgram: %v", strings.Join(errs, "\n"))
+	}
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	switch exp := stmt.Expression.(type) {
+	case *ast.PolygonPrimitive:
+		return exp.Arguments
+	case *ast.CylinderPrimitive:
+		return exp.Arguments
+	case *ast.LinearExtrudeBlockPrimitive:
+		return exp.Arguments
+	case *ast.RotateExtrudeBlockPrimitive:
+		return exp.Arguments
+	}
+	t.Fatalf("unexpected expression %T", stmt.Expression)
+	return nil
+}
@@ -0,0 +1,356 @@
+package params
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/gmlewis/go-csg/ast"
+)
+
+// Point represents a 2D point.
+type Point struct {
+	X, Y float64
+}
+
+// Cube returns the size and centering of a cube primitive.
+func Cube(exps []ast.Expression) (size []float64, center bool, err error) {
+	a, err := Parse(exps)
+	if err != nil {
+		return nil, false, err
+	}
+	if size, err = a.Vec(0, "size", 3, []float64{1, 1, 1}); err != nil {
+		return nil, false, err
+	}
+	center, err = a.Bool(1, "center", false)
+	return size, center, err
+}
+
+// Square returns the size and centering of a square primitive.
+func Square(exps []ast.Expression) (size []float64, center bool, err error) {
+	a, err := Parse(exps)
+	if err != nil {
+		return nil, false, err
+	}
+	if size, err = a.Vec(0, "size", 2, []float64{1, 1}); err != nil {
+		return nil, false, err
+	}
+	center, err = a.Bool(1, "center", false)
+	return size, center, err
+}
+
+// radius returns the radius given by rName, or half of dName,
+// or def. Consistent with the irmf backend, an explicit radius
+// overrides a diameter.
+func (a *Args) radius(i int, rName, dName string, def float64) (float64, error) {
+	if a.Has(i, rName) {
+		return a.Float(i, rName, def)
+	}
+	if a.Has(-1, dName) {
+		d, err := a.Float(-1, dName, 2*def)
+		return 0.5 * d, err
+	}
+	return def, nil
+}
+
+// Circle returns the radius and fragment count of a circle primitive.
+func Circle(exps []ast.Expression) (r float64, fragments int, err error) {
+	a, err := Parse(exps)
+	if err != nil {
+		return 0, 0, err
+	}
+	if r, err = a.radius(0, "r", "d", 1); err != nil {
+		return 0, 0, err
+	}
+	fragments, err = a.Fragments(r)
+	return r, fragments, err
+}
+
+// Sphere returns the radius and fragment count of a sphere primitive.
+func Sphere(exps []ast.Expression) (r float64, fragments int, err error) {
+	return Circle(exps)
+}
+
+// CylinderParams represents the resolved parameters of a cylinder.
+type CylinderParams struct {
+	H, R1, R2 float64
+	Center    bool
+	Fragments int
+}
+
+// Cylinder returns the parameters of a cylinder primitive.
+func Cylinder(exps []ast.Expression) (*CylinderParams, error) {
+	a, err := Parse(exps)
+	if err != nil {
+		return nil, err
+	}
+	c := &CylinderParams{}
+	if c.H, err = a.Float(0, "h", 1); err != nil {
+		return nil, err
+	}
+	r, err := a.radius(-1, "r", "d", 1)
+	if err != nil {
+		return nil, err
+	}
+	if c.R1, err = a.radius(1, "r1", "d1", r); err != nil {
+		return nil, err
+	}
+	if c.R2, err = a.radius(2, "r2", "d2", r); err != nil {
+		return nil, err
+	}
+	if c.Center, err = a.Bool(3, "center", false); err != nil {
+		return nil, err
+	}
+	rmax := c.R1
+	if c.R2 > rmax {
+		rmax = c.R2
+	}
+	c.Fragments, err = a.Fragments(rmax)
+	return c, err
+}
+
+// Polygon returns the points and paths of a polygon primitive.
+// paths is nil when it is missing or undef.
+func Polygon(exps []ast.Expression) (points []Point, paths [][]int, err error) {
+	a, err := Parse(exps)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	obj := a.Get(0, "points")
+	if obj == nil {
+		return nil, nil, errors.New("missing polygon points")
+	}
+	pts, ok := ToArray(obj)
+	if !ok {
+		return nil, nil, fmt.Errorf("polygon unexpected points type %T (%+v)", obj, obj)
+	}
+	for _, el := range pts {
+		v, ok := ToVec(el)
+		if !ok {
+			return nil, nil, fmt.Errorf("polygon unexpected point type %T (%+v)", el, el)
+		}
+		if len(v) != 2 {
+			return nil, nil, fmt.Errorf("polygon expected 2 elements per point: %v", el.Inspect())
+		}
+		points = append(points, Point{X: v[0], Y: v[1]})
+	}
+
+	if obj = a.Get(1, "paths"); obj == nil {
+		return points, nil, nil
+	}
+	ps, ok := ToArray(obj)
+	if !ok {
+		return nil, nil, fmt.Errorf("polygon unexpected paths type %T (%+v)", obj, obj)
+	}
+	for _, el := range ps {
+		v, ok := ToVec(el)
+		if !ok {
+			return nil, nil, fmt.Errorf("polygon unexpected path type %T (%+v)", el, el)
+		}
+		var path []int
+		for _, f := range v {
+			idx := int(f)
+			if idx < 0 || idx >= len(points) {
+				return nil, nil, fmt.Errorf("polygon path index %v out of range", idx)
+			}
+			path = append(path, idx)
+		}
+		paths = append(paths, path)
+	}
+
+	return points, paths, nil
+}
+
+// Polyhedron returns the points and faces of a polyhedron primitive.
+// The older "triangles" argument is accepted in place of "faces".
+func Polyhedron(exps []ast.Expression) (points [][3]float64, faces [][]int, err error) {
+	a, err := Parse(exps)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	obj := a.Get(0, "points")
+	if obj == nil {
+		return nil, nil, errors.New("missing polyhedron points")
+	}
+	pts, ok := ToArray(obj)
+	if !ok {
+		return nil, nil, fmt.Errorf("polyhedron unexpected points type %T (%+v)", obj, obj)
+	}
+	for _, el := range pts {
+		v, ok := ToVec(el)
+		if !ok || len(v) != 3 {
+			return nil, nil, fmt.Errorf("polyhedron expected 3 elements per point: %v", el.Inspect())
+		}
+		points = append(points, [3]float64{v[0], v[1], v[2]})
+	}
+
+	if obj = a.Get(1, "faces"); obj == nil {
+		obj = a.Get(-1, "triangles")
+	}
+	fs, ok := ToArray(obj)
+	if !ok {
+		return nil, nil, errors.New("missing polyhedron faces")
+	}
+	for _, el := range fs {
+		v, ok := ToVec(el)
+		if !ok {
+			return nil, nil, fmt.Errorf("polyhedron unexpected face type %T (%+v)", el, el)
+		}
+		var face []int
+		for _, f := range v {
+			idx := int(f)
+			if idx < 0 || idx >= len(points) {
+				return nil, nil, fmt.Errorf("polyhedron face index %v out of range", idx)
+			}
+			face = append(face, idx)
+		}
+		faces = append(faces, face)
+	}
+	return points, faces, nil
+}
+
+// LinearExtrudeParams represents the arguments of a linear_extrude block.
+type LinearExtrudeParams struct {
+	// Height is the length of the extrusion along V.
+	Height float64
+	Center bool
+	// Convexity is only a hint for previews.
+	Convexity int
+	Twist     float64
+	// Slices is the number of layers of a twisted extrusion.
+	Slices int
+	Scale  [2]float64
+	// V is the (unit) direction of the extrusion.
+	V [3]float64
+}
+
+// LinearExtrude returns the arguments of a linear_extrude block.
+// As in OpenSCAD, the height defaults to the length of v (or 100),
+// and the number of slices of a twisted extrusion defaults to the
+// number of fragments for its height and twist.
+func LinearExtrude(exps []ast.Expression) (*LinearExtrudeParams, error) {
+	a, err := Parse(exps)
+	if err != nil {
+		return nil, err
+	}
+	le := &LinearExtrudeParams{V: [3]float64{0, 0, 1}}
+	v, err := a.Vec(-1, "v", 3, nil)
+	if err != nil {
+		return nil, err
+	}
+	height := 100.0
+	if v != nil {
+		height = math.Sqrt(v[0]*v[0] + v[1]*v[1] + v[2]*v[2])
+		if height == 0 {
+			return nil, errors.New(`argument "v": expected a non-zero vector`)
+		}
+		le.V = [3]float64{v[0] / height, v[1] / height, v[2] / height}
+	}
+	if le.Height, err = a.Float(0, "height", height); err != nil {
+		return nil, err
+	}
+	if le.Center, err = a.Bool(1, "center", false); err != nil {
+		return nil, err
+	}
+	convexity, err := a.Float(2, "convexity", 1)
+	if err != nil {
+		return nil, err
+	}
+	le.Convexity = int(convexity)
+	if le.Twist, err = a.Float(3, "twist", 0); err != nil {
+		return nil, err
+	}
+	slices, err := a.Float(4, "slices", 0)
+	if err != nil {
+		return nil, err
+	}
+	le.Slices = int(slices)
+	if le.Slices <= 0 {
+		le.Slices = 1
+		if le.Twist != 0 {
+			fragments, err := a.Fragments(le.Height)
+			if err != nil {
+				return nil, err
+			}
+			le.Slices = int(math.Max(2, math.Abs(float64(fragments)*le.Twist/360)))
+		}
+	}
+	scale, err := a.Vec(-1, "scale", 2, []float64{1, 1})
+	if err != nil {
+		return nil, err
+	}
+	le.Scale = [2]float64{scale[0], scale[1]}
+	return le, nil
+}
+
+// RotateExtrude returns the angle (in degrees) of a rotate_extrude block.
+// Like OpenSCAD, angles outside of (-360, 360] revolve a full turn.
+func RotateExtrude(exps []ast.Expression) (angle float64, err error) {
+	a, err := Parse(exps)
+	if err != nil {
+		return 0, err
+	}
+	angle, err = a.Float(-1, "angle", 360)
+	if err != nil {
+		return 0, err
+	}
+	if angle <= -360 || angle > 360 {
+		angle = 360
+	}
+	return angle, nil
+}
+
+// Color returns the RGBA components (from 0 to 1) of a color block.
+func Color(exps []ast.Expression) ([4]float64, error) {
+	rgba := [4]float64{1, 1, 1, 1}
+	a, err := Parse(exps)
+	if err != nil {
+		return rgba, err
+	}
+
+	obj := a.Get(0, "c")
+	if obj != nil {
+		if v, ok := ToVec(obj); ok && (len(v) == 3 || len(v) == 4) {
+			copy(rgba[:], v)
+		} else if name, err := a.String(0, "c", ""); err == nil {
+			c, ok := namedColors[strings.ToLower(name)]
+			if !ok {
+				return rgba, fmt.Errorf("unknown color %q", name)
+			}
+			rgba = c
+		} else {
+			return rgba, fmt.Errorf("unexpected color %v", obj.Inspect())
+		}
+	}
+
+	if a.Has(1, "alpha") {
+		if rgba[3], err = a.Float(1, "alpha", 1); err != nil {
+			return rgba, err
+		}
+	}
+	return rgba, nil
+}
+
+// namedColors are the most common SVG color names supported by OpenSCAD.
+var namedColors = map[string][4]float64{
+	"black":   {0, 0, 0, 1},
+	"blue":    {0, 0, 1, 1},
+	"brown":   {165.0 / 255, 42.0 / 255, 42.0 / 255, 1},
+	"cyan":    {0, 1, 1, 1},
+	"gold":    {1, 215.0 / 255, 0, 1},
+	"gray":    {128.0 / 255, 128.0 / 255, 128.0 / 255, 1},
+	"green":   {0, 128.0 / 255, 0, 1},
+	"grey":    {128.0 / 255, 128.0 / 255, 128.0 / 255, 1},
+	"lime":    {0, 1, 0, 1},
+	"magenta": {1, 0, 1, 1},
+	"orange":  {1, 165.0 / 255, 0, 1},
+	"pink":    {1, 192.0 / 255, 203.0 / 255, 1},
+	"purple":  {128.0 / 255, 0, 128.0 / 255, 1},
+	"red":     {1, 0, 0, 1},
+	"silver":  {192.0 / 255, 192.0 / 255, 192.0 / 255, 1},
+	"white":   {1, 1, 1, 1},
+	"yellow":  {1, 1, 0, 1},
+}
@@ -0,0 +1,131 @@
+package params
+
+import (
+	"fmt"
+
+	"github.com/gmlewis/go-csg/ast"
+	"github.com/gmlewis/go-csg/object"
+)
+
+// vec3 returns the argument as a vector of up to 3 numbers, padded
+// with pad like OpenSCAD does for the 2D forms of the transforms,
+// or def if it is missing.
+func (a *Args) vec3(i int, name string, pad float64, def [3]float64) ([3]float64, error) {
+	obj := a.Get(i, name)
+	if obj == nil {
+		return def, nil
+	}
+	v, ok := ToVec(obj)
+	if !ok || len(v) == 0 || len(v) > 3 {
+		return def, fmt.Errorf("argument %q: expected vector of 2 or 3 numbers, got %v", name, obj.Inspect())
+	}
+	result := [3]float64{pad, pad, pad}
+	copy(result[:], v)
+	return result, nil
+}
+
+// Translate returns the offset of a translate block.
+func Translate(exps []ast.Expression) ([3]float64, error) {
+	a, err := Parse(exps)
+	if err != nil {
+		return [3]float64{}, err
+	}
+	return a.vec3(0, "v", 0, [3]float64{})
+}
+
+// RotateParams represents the parameters of a rotate block.
+// If V is zero, the rotation is about the X, Y and Z axes (in that
+// order) by the angles in A. Otherwise, it is about the axis V by Angle.
+// All angles are in degrees.
+type RotateParams struct {
+	A     [3]float64
+	Angle float64
+	V     [3]float64
+}
+
+// Rotate returns the parameters of a rotate block.
+func Rotate(exps []ast.Expression) (*RotateParams, error) {
+	a, err := Parse(exps)
+	if err != nil {
+		return nil, err
+	}
+	r := &RotateParams{}
+	obj := a.Get(0, "a")
+	if obj == nil {
+		return r, nil
+	}
+	if _, ok := obj.(*object.Array); ok {
+		r.A, err = a.vec3(0, "a", 0, r.A)
+		return r, err
+	}
+	angle, err := a.Float(0, "a", 0)
+	if err != nil {
+		return nil, err
+	}
+	if r.V, err = a.vec3(1, "v", 0, [3]float64{}); err != nil {
+		return nil, err
+	}
+	if r.V == [3]float64{} {
+		// A single angle without an axis rotates about Z.
+		r.A[2] = angle
+		return r, nil
+	}
+	r.Angle = angle
+	return r, nil
+}
+
+// Scale returns the scale factors of a scale block.
+func Scale(exps []ast.Expression) ([3]float64, error) {
+	a, err := Parse(exps)
+	if err != nil {
+		return [3]float64{}, err
+	}
+	if obj := a.Get(0, "v"); obj != nil {
+		if s, ok := ToFloat(obj); ok {
+			return [3]float64{s, s, s}, nil
+		}
+	}
+	return a.vec3(0, "v", 1, [3]float64{1, 1, 1})
+}
+
+// Mirror returns the normal vector of the plane of a mirror block.
+func Mirror(exps []ast.Expression) ([3]float64, error) {
+	a, err := Parse(exps)
+	if err != nil {
+		return [3]float64{}, err
+	}
+	return a.vec3(0, "v", 0, [3]float64{1, 0, 0})
+}
+
+// Resize returns the new size of a resize block and which of
+// its axes (with a new size of 0) are scaled automatically.
+func Resize(exps []ast.Expression) (newsize [3]float64, auto [3]bool, err error) {
+	a, err := Parse(exps)
+	if err != nil {
+		return newsize, auto, err
+	}
+	if newsize, err = a.vec3(0, "newsize", 0, newsize); err != nil {
+		return newsize, auto, err
+	}
+
+	obj := a.Get(1, "auto")
+	switch v := obj.(type) {
+	case nil:
+	case *object.Boolean:
+		auto = [3]bool{v.Value, v.Value, v.Value}
+	case *object.Array:
+		if len(v.Elements) > 3 {
+			return newsize, auto, fmt.Errorf("argument %q: expected boolean or vector of booleans, got %v", "auto", obj.Inspect())
+		}
+		for i, el := range v.Elements {
+			b, ok := el.(*object.Boolean)
+			if !ok {
+				return newsize, auto, fmt.Errorf("argument %q: expected boolean or vector of booleans, got %v", "auto", obj.Inspect())
+			}
+			auto[i] = b.Value
+		}
+	default:
+		return newsize, auto, fmt.Errorf("argument %q: expected boolean or vector of booleans, got %v", "auto", obj.Inspect())
+	}
+	return newsize, auto, nil
+}
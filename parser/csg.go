@@ -262,6 +262,24 @@ func (p *Parser) parseMinkowskiBlockPrimitive() ast.Expression {
 	return blockPrim
 }
 
+func (p *Parser) parseMirrorBlockPrimitive() ast.Expression {
+	if !p.peekTokenIs(token.LPAREN) {
+		return p.parseKeywordIdentifier()
+	}
+
+	blockPrim := &ast.MirrorBlockPrimitive{Token: p.curToken}
+
+	args, body, ok := p.parseBlockPrimitive()
+	if !ok {
+		return nil
+	}
+
+	blockPrim.Arguments = args
+	blockPrim.Body = body
+
+	return blockPrim
+}
+
 func (p *Parser) parseMultmatrixBlockPrimitive() ast.Expression {
 	blockPrim := &ast.MultmatrixBlockPrimitive{Token: p.curToken}
 
@@ -276,6 +294,20 @@ func (p *Parser) parseMultmatrixBlockPrimitive() ast.Expression {
 	return blockPrim
 }
 
+func (p *Parser) parseOffsetBlockPrimitive() ast.Expression {
+	blockPrim := &ast.OffsetBlockPrimitive{Token: p.curToken}
+
+	args, body, ok := p.parseBlockPrimitive()
+	if !ok {
+		return nil
+	}
+
+	blockPrim.Arguments = args
+	blockPrim.Body = body
+
+	return blockPrim
+}
+
 func (p *Parser) parseProjectionBlockPrimitive() ast.Expression {
 	blockPrim := &ast.ProjectionBlockPrimitive{Token: p.curToken}
 
@@ -290,6 +322,42 @@ func (p *Parser) parseProjectionBlockPrimitive() ast.Expression {
 	return blockPrim
 }
 
+func (p *Parser) parseResizeBlockPrimitive() ast.Expression {
+	if !p.peekTokenIs(token.LPAREN) {
+		return p.parseKeywordIdentifier()
+	}
+
+	blockPrim := &ast.ResizeBlockPrimitive{Token: p.curToken}
+
+	args, body, ok := p.parseBlockPrimitive()
+	if !ok {
+		return nil
+	}
+
+	blockPrim.Arguments = args
+	blockPrim.Body = body
+
+	return blockPrim
+}
+
+func (p *Parser) parseRotateBlockPrimitive() ast.Expression {
+	if !p.peekTokenIs(token.LPAREN) {
+		return p.parseKeywordIdentifier()
+	}
+
+	blockPrim := &ast.RotateBlockPrimitive{Token: p.curToken}
+
+	args, body, ok := p.parseBlockPrimitive()
+	if !ok {
+		return nil
+	}
+
+	blockPrim.Arguments = args
+	blockPrim.Body = body
+
+	return blockPrim
+}
+
 func (p *Parser) parseRotateExtrudeBlockPrimitive() ast.Expression {
 	blockPrim := &ast.RotateExtrudeBlockPrimitive{Token: p.curToken}
 
@@ -304,6 +372,42 @@ func (p *Parser) parseRotateExtrudeBlockPrimitive() ast.Expression {
 	return blockPrim
 }
 
+func (p *Parser) parseScaleBlockPrimitive() ast.Expression {
+	if !p.peekTokenIs(token.LPAREN) {
+		return p.parseKeywordIdentifier()
+	}
+
+	blockPrim := &ast.ScaleBlockPrimitive{Token: p.curToken}
+
+	args, body, ok := p.parseBlockPrimitive()
+	if !ok {
+		return nil
+	}
+
+	blockPrim.Arguments = args
+	blockPrim.Body = body
+
+	return blockPrim
+}
+
+func (p *Parser) parseTranslateBlockPrimitive() ast.Expression {
+	if !p.peekTokenIs(token.LPAREN) {
+		return p.parseKeywordIdentifier()
+	}
+
+	blockPrim := &ast.TranslateBlockPrimitive{Token: p.curToken}
+
+	args, body, ok := p.parseBlockPrimitive()
+	if !ok {
+		return nil
+	}
+
+	blockPrim.Arguments = args
+	blockPrim.Body = body
+
+	return blockPrim
+}
+
 func (p *Parser) parseUnionBlockPrimitive() ast.Expression {
 	blockPrim := &ast.UnionBlockPrimitive{Token: p.curToken}
 
@@ -316,3 +420,28 @@ func (p *Parser) parseUnionBlockPrimitive() ast.Expression {
 
 	return blockPrim
 }
+
+// parseKeywordIdentifier parses the current keyword as an identifier.
+// The transform keywords are also used as argument names (e.g. the
+// scale of linear_extrude), so they are only block primitives when
+// followed by their arguments.
+func (p *Parser) parseKeywordIdentifier() ast.Expression {
+	tok := token.Token{Type: token.IDENT, Literal: p.curToken.Literal}
+	return &ast.Identifier{Token: tok, Value: tok.Literal}
+}
+
+func (p *Parser) parseModifierExpression() ast.Expression {
+	exp := &ast.ModifierExpression{
+		Token:    p.curToken,
+		Modifier: p.curToken.Literal,
+	}
+
+	p.nextToken()
+
+	exp.Right = p.parseExpression(PREFIX)
+	if exp.Right == nil {
+		return nil
+	}
+
+	return exp
+}
@@ -34,9 +34,23 @@ func TestCSGPrimitives(t *testing.T) {
 		{"linear_extrude(height = 0.666667, center = false, convexity = 1, twist = 3, slices = 2, scale = [0.670925, 0.670925], $fn = 0, $fa = 12, $fs = 2) { sphere(); }", "linear_extrude(height = 0.666667, center = false, convexity = 1, twist = 3, slices = 2, scale = [0.670925, 0.670925], $fn = 0, $fa = 12, $fs = 2) { sphere() }"},
 		{"minkowski(convexity = 0) { sphere(); }", "minkowski(convexity = 0) { sphere() }"},
 		{"multmatrix([[0.0193379, 0.999813, 0, 0], [-0.999813, 0.0193379, 0, 0], [0, 0, 1, 0], [0, 0, 0, 1]]) { sphere(); }", "multmatrix([[0.0193379, 0.999813, 0, 0], [(-0.999813), 0.0193379, 0, 0], [0, 0, 1, 0], [0, 0, 0, 1]]) { sphere() }"},
+		{"offset(r = 2, $fn = 0, $fa = 12, $fs = 2) { square(); }", "offset(r = 2, $fn = 0, $fa = 12, $fs = 2) { square() }"},
 		{"projection(cut = false, convexity = 0) { sphere(); }", "projection(cut = false, convexity = 0) { sphere() }"},
 		{"rotate_extrude(convexity = 2, $fn = 100, $fa = 12, $fs = 2) { sphere(); }", "rotate_extrude(convexity = 2, $fn = 100, $fa = 12, $fs = 2) { sphere() }"},
 		{"union() { sphere(); cube(); }", "union() { sphere(); cube() }"},
+
+		// Transforms:
+		{"translate([1, 2, 3]) { sphere(); }", "translate([1, 2, 3]) { sphere() }"},
+		{"rotate(a = 90, v = [1, 0, 0]) { sphere(); }", "rotate(a = 90, v = [1, 0, 0]) { sphere() }"},
+		{"scale(2) { sphere(); }", "scale(2) { sphere() }"},
+		{"mirror([1, 0, 0]) { sphere(); }", "mirror([1, 0, 0]) { sphere() }"},
+		{"resize(newsize = [10, 0, 0], auto = true) { sphere(); }", "resize(newsize = [10, 0, 0], auto = true) { sphere() }"},
+		{"linear_extrude(height = 1, scale = 2) { scale([1, 2]) { square(); } }", "linear_extrude(height = 1, scale = 2) { scale([1, 2]) { square() } }"},
+
+		// Modifiers:
+		{"*cube();", "*cube()"},
+		{"#group() { sphere(); }", "#group() { sphere() }"},
+		{"%multmatrix([[1, 0, 0, 0], [0, 1, 0, 0], [0, 0, 1, 0], [0, 0, 0, 1]]) { sphere(); }", "%multmatrix([[1, 0, 0, 0], [0, 1, 0, 0], [0, 0, 1, 0], [0, 0, 0, 1]]) { sphere() }"},
 	}
 
 	for i, tt := range tests {
@@ -64,9 +78,9 @@ func TestCSG_ParseProgram(t *testing.T) {
 			want: &ast.Program{
 				Statements: []ast.Statement{
 					&ast.ExpressionStatement{
-						Token: token.Token{Type: token.CUBE, Literal: "cube"},
+						Token: token.Token{Type: token.CUBE, Literal: "cube", Pos: token.Position{Line: 1, Column: 1}},
 						Expression: &ast.CubePrimitive{
-							Token: token.Token{Type: token.CUBE, Literal: "cube"},
+							Token: token.Token{Type: token.CUBE, Literal: "cube", Pos: token.Position{Line: 1, Column: 1}},
 						},
 					},
 				},
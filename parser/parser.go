@@ -10,11 +10,23 @@ import (
 	"github.com/gmlewis/go-csg/token"
 )
 
+// Error represents a parse error at a position in the input.
+type Error struct {
+	Pos token.Position
+	Msg string
+}
+
+// Error returns the string representation of the Error.
+func (e *Error) Error() string {
+	return fmt.Sprintf("%v: %v", e.Pos, e.Msg)
+}
+
 // Parser represents our language parser.
 type Parser struct {
 	le *lexer.Lexer
 
-	errors []string
+	errors      []string
+	diagnostics []Error
 
 	curToken  token.Token
 	peekToken token.Token
@@ -66,11 +78,22 @@ func New(le *lexer.Lexer) *Parser {
 	p.registerPrefix(token.INTERSECTION, p.parseIntersectionBlockPrimitive)
 	p.registerPrefix(token.LINEAR_EXTRUDE, p.parseLinearExtrudeBlockPrimitive)
 	p.registerPrefix(token.MINKOWSKI, p.parseMinkowskiBlockPrimitive)
+	p.registerPrefix(token.MIRROR, p.parseMirrorBlockPrimitive)
 	p.registerPrefix(token.MULTMATRIX, p.parseMultmatrixBlockPrimitive)
+	p.registerPrefix(token.OFFSET, p.parseOffsetBlockPrimitive)
 	p.registerPrefix(token.PROJECTION, p.parseProjectionBlockPrimitive)
+	p.registerPrefix(token.RESIZE, p.parseResizeBlockPrimitive)
+	p.registerPrefix(token.ROTATE, p.parseRotateBlockPrimitive)
 	p.registerPrefix(token.ROTATE_EXTRUDE, p.parseRotateExtrudeBlockPrimitive)
+	p.registerPrefix(token.SCALE, p.parseScaleBlockPrimitive)
+	p.registerPrefix(token.TRANSLATE, p.parseTranslateBlockPrimitive)
 	p.registerPrefix(token.UNION, p.parseUnionBlockPrimitive)
 
+	// CSG modifiers
+	p.registerPrefix(token.ASTERISK, p.parseModifierExpression)
+	p.registerPrefix(token.POUND, p.parseModifierExpression)
+	p.registerPrefix(token.PERCENT, p.parseModifierExpression)
+
 	// Infix expressions:
 	p.registerInfix(token.PLUS, p.parseInfixExpression)
 	p.registerInfix(token.MINUS, p.parseInfixExpression)
@@ -95,9 +118,20 @@ func (p *Parser) Errors() []string {
 	return p.errors
 }
 
-func (p *Parser) peekError(t token.T) {
-	msg := fmt.Sprintf("expected next token to be %v, got %v", t, p.peekToken.Type)
+// Diagnostics returns the parse errors with their positions.
+// Their messages are the same as those of Errors.
+func (p *Parser) Diagnostics() []Error {
+	return p.diagnostics
+}
+
+func (p *Parser) errorf(pos token.Position, format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
 	p.errors = append(p.errors, msg)
+	p.diagnostics = append(p.diagnostics, Error{Pos: pos, Msg: msg})
+}
+
+func (p *Parser) peekError(t token.T) {
+	p.errorf(p.peekToken.Pos, "expected next token to be %v, got %v", t, p.peekToken.Type)
 }
 
 func (p *Parser) nextToken() {
@@ -195,8 +229,7 @@ func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
 }
 
 func (p *Parser) noPrefixParseFnError(t token.T) {
-	msg := fmt.Sprintf("no prefix parse function for %v found", t)
-	p.errors = append(p.errors, msg)
+	p.errorf(p.curToken.Pos, "no prefix parse function for %v found", t)
 }
 
 func (p *Parser) parseExpression(precedence int) ast.Expression {
@@ -225,8 +258,7 @@ func (p *Parser) parseExpression(precedence int) ast.Expression {
 func (p *Parser) parseIntegerLiteral() ast.Expression {
 	value, err := strconv.ParseInt(p.curToken.Literal, 0, 64)
 	if err != nil {
-		msg := fmt.Sprintf("could not parse %q as integer", p.curToken.Literal)
-		p.errors = append(p.errors, msg)
+		p.errorf(p.curToken.Pos, "could not parse %q as integer", p.curToken.Literal)
 		return nil
 	}
 
@@ -236,8 +268,7 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 func (p *Parser) parseFloatLiteral() ast.Expression {
 	value, err := strconv.ParseFloat(p.curToken.Literal, 64)
 	if err != nil {
-		msg := fmt.Sprintf("could not parse %q as float", p.curToken.Literal)
-		p.errors = append(p.errors, msg)
+		p.errorf(p.curToken.Pos, "could not parse %q as float", p.curToken.Literal)
 		return nil
 	}
 
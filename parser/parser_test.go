@@ -2,6 +2,7 @@ package parser
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/gmlewis/go-csg/ast"
@@ -752,3 +753,22 @@ func TestCallExpressionExpression(t *testing.T) {
 	testInfixExpression(t, exp.Arguments[1], 2, "*", 3)
 	testInfixExpression(t, exp.Arguments[2], 4, "+", 5)
 }
+
+func TestDiagnostics(t *testing.T) {
+	input := "cube();\nsphere(r = );\n"
+
+	p := New(lexer.New(input))
+	p.ParseProgram()
+
+	var got []string
+	for _, d := range p.Diagnostics() {
+		got = append(got, d.Error())
+	}
+	want := []string{"2:12: no prefix parse function for ) found", "2:13: expected next token to be ), got ;"}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Diagnostics = %q, want %q", got, want)
+	}
+	if len(p.Errors()) != len(p.Diagnostics()) {
+		t.Errorf("Errors = %q, want the messages of the diagnostics", p.Errors())
+	}
+}
@@ -0,0 +1,226 @@
+// Package printer implements printing of CSG AST nodes as canonical,
+// OpenSCAD-loadable CSG text.
+//
+// With the default configuration, printing is lossless:
+// parsing the printed output yields an AST equal to the original
+// (apart from the spelling of number literals).
+package printer
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/gmlewis/go-csg/ast"
+)
+
+// Config controls the output of Fprint.
+type Config struct {
+	// Indent is the string used for each level of indentation.
+	Indent string
+	// Precision is the number of significant digits used for float
+	// literals. A negative value uses the smallest number of digits
+	// necessary to represent the value exactly.
+	Precision int
+}
+
+// DefaultConfig is the configuration used by Fprint and String,
+// matching the layout of OpenSCAD's CSG export.
+var DefaultConfig = &Config{Indent: "\t", Precision: -1}
+
+// Fprint prints the node to w using the default configuration.
+func Fprint(w io.Writer, node ast.Node) error {
+	return DefaultConfig.Fprint(w, node)
+}
+
+// String returns the node printed using the default configuration.
+func String(node ast.Node) (string, error) {
+	var buf bytes.Buffer
+	if err := Fprint(&buf, node); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// Fprint prints the node to w.
+func (c *Config) Fprint(w io.Writer, node ast.Node) error {
+	p := &printer{config: c}
+	switch node := node.(type) {
+	case *ast.Program:
+		p.statements(node.Statements, 0)
+	case *ast.BlockStatement:
+		p.statements(node.Statements, 0)
+	case ast.Statement:
+		p.statement(node, 0)
+	case ast.Expression:
+		p.buf.WriteString(p.expression(node, lowest))
+	default:
+		return fmt.Errorf("unsupported node type %T", node)
+	}
+	if p.err != nil {
+		return p.err
+	}
+	_, err := w.Write(p.buf.Bytes())
+	return err
+}
+
+type printer struct {
+	config *Config
+	buf    bytes.Buffer
+	err    error
+}
+
+func (p *printer) errorf(format string, args ...interface{}) {
+	if p.err == nil {
+		p.err = fmt.Errorf(format, args...)
+	}
+}
+
+func (p *printer) statements(stmts []ast.Statement, depth int) {
+	for _, stmt := range stmts {
+		p.statement(stmt, depth)
+	}
+}
+
+func (p *printer) statement(stmt ast.Statement, depth int) {
+	indent := strings.Repeat(p.config.Indent, depth)
+	switch stmt := stmt.(type) {
+	case *ast.ExpressionStatement:
+		if stmt.Expression == nil {
+			return
+		}
+		p.buf.WriteString(indent)
+		exp := stmt.Expression
+		for {
+			m, ok := exp.(*ast.ModifierExpression)
+			if !ok {
+				break
+			}
+			p.buf.WriteString(m.Modifier)
+			exp = m.Right
+		}
+		if args, body, ok := ast.Parts(exp); ok && body != nil {
+			p.buf.WriteString(p.call(exp.TokenLiteral(), args))
+			p.buf.WriteString(" {\n")
+			p.statements(body.Statements, depth+1)
+			p.buf.WriteString(indent)
+			p.buf.WriteString("}\n")
+			return
+		}
+		p.buf.WriteString(p.expression(exp, lowest))
+		if _, ok := exp.(*ast.LineComment); !ok {
+			p.buf.WriteString(";")
+		}
+		p.buf.WriteString("\n")
+	case *ast.LetStatement:
+		fmt.Fprintf(&p.buf, "%vlet %v = %v;\n", indent, stmt.Name.Value, p.expression(stmt.Value, lowest))
+	case *ast.ReturnStatement:
+		fmt.Fprintf(&p.buf, "%vreturn %v;\n", indent, p.expression(stmt.ReturnValue, lowest))
+	default:
+		p.errorf("unsupported statement type %T", stmt)
+	}
+}
+
+func (p *printer) call(name string, args []ast.Expression) string {
+	return name + "(" + p.list(args) + ")"
+}
+
+func (p *printer) list(exps []ast.Expression) string {
+	parts := make([]string, 0, len(exps))
+	for _, exp := range exps {
+		parts = append(parts, p.expression(exp, lowest))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Operator precedences, mirroring the parser.
+const (
+	lowest = iota + 1
+	equals
+	lessGreater
+	sum
+	product
+	prefix
+	call
+)
+
+var precedences = map[string]int{
+	"==": equals,
+	"!=": equals,
+	"<":  lessGreater,
+	">":  lessGreater,
+	"+":  sum,
+	"-":  sum,
+	"/":  product,
+	"*":  product,
+}
+
+// expression returns the printed expression, parenthesized if its
+// precedence is lower than prec.
+func (p *printer) expression(exp ast.Expression, prec int) string {
+	if args, body, ok := ast.Parts(exp); ok {
+		if body != nil {
+			p.errorf("block primitive %v used within an expression", exp.TokenLiteral())
+		}
+		return p.call(exp.TokenLiteral(), args)
+	}
+
+	switch n := exp.(type) {
+	case *ast.LineComment:
+		return "//" + n.Value
+	case *ast.Identifier:
+		return n.Value
+	case *ast.IntegerLiteral:
+		return strconv.FormatInt(n.Value, 10)
+	case *ast.FloatLiteral:
+		return p.float(n.Value)
+	case *ast.StringLiteral:
+		return `"` + n.Value + `"`
+	case *ast.BooleanLiteral:
+		return strconv.FormatBool(n.Value)
+	case *ast.UndefLiteral:
+		return "undef"
+	case *ast.ArrayLiteral:
+		return "[" + p.list(n.Elements) + "]"
+	case *ast.NamedArgument:
+		return p.expression(n.Name, lowest) + " = " + p.expression(n.Value, lowest)
+	case *ast.PrefixExpression:
+		return paren(n.Operator+p.expression(n.Right, prefix), prefix, prec)
+	case *ast.ModifierExpression:
+		return n.Modifier + p.expression(n.Right, prefix)
+	case *ast.InfixExpression:
+		op := precedences[n.Operator]
+		// Operators are left-associative, so a right operand of
+		// equal precedence must be parenthesized.
+		s := p.expression(n.Left, op) + " " + n.Operator + " " + p.expression(n.Right, op+1)
+		return paren(s, op, prec)
+	case *ast.CallExpression:
+		return p.expression(n.Function, call) + "(" + p.list(n.Arguments) + ")"
+	case *ast.IndexExpression:
+		return p.expression(n.Left, call) + "[" + p.expression(n.Index, lowest) + "]"
+	}
+
+	p.errorf("unsupported expression type %T", exp)
+	return ""
+}
+
+func paren(s string, op, prec int) string {
+	if op < prec {
+		return "(" + s + ")"
+	}
+	return s
+}
+
+// float formats a float literal so that it is lexed as a float
+// (and not as an integer) when parsed again.
+func (p *printer) float(v float64) string {
+	s := strconv.FormatFloat(v, 'g', p.config.Precision, 64)
+	// The lexer does not accept a '+' within a number.
+	s = strings.Replace(s, "e+", "e", 1)
+	if !strings.ContainsAny(s, ".e") {
+		s += ".0"
+	}
+	return s
+}
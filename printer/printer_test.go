@@ -0,0 +1,144 @@
+package printer
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gmlewis/go-csg/ast"
+	"github.com/gmlewis/go-csg/lexer"
+	"github.com/gmlewis/go-csg/parser"
+	"github.com/gmlewis/go-csg/token"
+)
+
+func parse(t *testing.T, src string) *ast.Program {
+	t.Helper()
+	le := lexer.New(src)
+	p := parser.New(le)
+	program := p.ParseProgram()
+	if errs := p.Errors(); len(errs) != 0 {
+		t.Fatalf("ParseProgram: %v", strings.Join(errs, "\n"))
+	}
+	return program
+}
+
+func TestConfig_Fprint(t *testing.T) {
+	tests := []struct {
+		src    string
+		config *Config
+		want   string
+	}{
+		{
+			src:    "group() { cube(size = [1, 2.50, 3e2], center = false); }",
+			config: DefaultConfig,
+			want:   "group() {\n\tcube(size = [1, 2.5, 300.0], center = false);\n}\n",
+		},
+		{
+			src:    "// a comment\nmultmatrix([[1, 0, 0, -0.5], [0, 1, 0, 0], [0, 0, 1, 0], [0, 0, 0, 1]]) {\n// inner\nsphere($fn = 0, $fa = 12, $fs = 2, r = 1e-05);\n}",
+			config: &Config{Indent: "  ", Precision: -1},
+			want:   "// a comment\nmultmatrix([[1, 0, 0, -0.5], [0, 1, 0, 0], [0, 0, 1, 0], [0, 0, 0, 1]]) {\n  // inner\n  sphere($fn = 0, $fa = 12, $fs = 2, r = 1e-05);\n}\n",
+		},
+		{
+			src:    "circle(r = 0.3333333333333333); group(); union() { }",
+			config: &Config{Indent: "\t", Precision: 3},
+			want:   "circle(r = 0.333);\ngroup();\nunion() {\n}\n",
+		},
+		{
+			src:    `text(text = "hi", size = (1 + 2) * 3 - (4 - 5), valign = !true);`,
+			config: DefaultConfig,
+			want:   "text(text = \"hi\", size = (1 + 2) * 3 - (4 - 5), valign = !true);\n",
+		},
+		{
+			src:    "*cube(); %group() { #sphere(); }",
+			config: DefaultConfig,
+			want:   "*cube();\n%group() {\n\t#sphere();\n}\n",
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test #%v", i), func(t *testing.T) {
+			var buf strings.Builder
+			if err := tt.config.Fprint(&buf, parse(t, tt.src)); err != nil {
+				t.Fatalf("Fprint: %v", err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("Fprint =\n%v\nwant:\n%v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestRoundTrip checks that parse(print(x)) == x for all the examples.
+func TestRoundTrip(t *testing.T) {
+	filenames, err := filepath.Glob("../examples/*/*.csg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(filenames) == 0 {
+		t.Fatal("no examples found")
+	}
+
+	for _, filename := range filenames {
+		t.Run(filepath.Base(filename), func(t *testing.T) {
+			buf, err := ioutil.ReadFile(filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := parse(t, string(buf))
+			printed, err := String(want)
+			if err != nil {
+				t.Fatalf("String: %v", err)
+			}
+			got := parse(t, printed)
+			if !equal(reflect.ValueOf(got), reflect.ValueOf(want)) {
+				t.Errorf("parse(print(x)) != x")
+			}
+			if again, err := String(got); err != nil || again != printed {
+				t.Errorf("printing is not idempotent: %v", err)
+			}
+		})
+	}
+}
+
+var tokenType = reflect.TypeOf(token.Token{})
+
+// equal compares two AST values, ignoring tokens (which differ only
+// in the spelling of literals).
+func equal(a, b reflect.Value) bool {
+	if a.Type() != b.Type() {
+		return false
+	}
+	switch a.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if a.IsNil() || b.IsNil() {
+			return a.IsNil() == b.IsNil()
+		}
+		return equal(a.Elem(), b.Elem())
+	case reflect.Struct:
+		if a.Type() == tokenType {
+			return true
+		}
+		for i := 0; i < a.NumField(); i++ {
+			if !equal(a.Field(i), b.Field(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Slice:
+		if a.Len() != b.Len() {
+			return false
+		}
+		for i := 0; i < a.Len(); i++ {
+			if !equal(a.Index(i), b.Index(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Map:
+		return a.Len() == 0 && b.Len() == 0
+	}
+	return reflect.DeepEqual(a.Interface(), b.Interface())
+}
@@ -0,0 +1,128 @@
+package repl
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/gmlewis/go-csg/ast"
+	"github.com/gmlewis/go-csg/evaluator"
+	"github.com/gmlewis/go-csg/irmf"
+	"github.com/gmlewis/go-csg/object"
+)
+
+const help = `Meta-commands:
+  :load FILE  evaluate a CSG file
+  :ast [CSG]  print the parse tree of the CSG (default: the last input)
+  :irmf       print the IRMF shader of the last expression or loaded file
+  :mbb        print the minimum bounding box of the last expression or loaded file
+  :env        list the bindings of the environment
+  :reset      clear the environment and the last input
+  :help       print this help
+  :quit       exit the repl
+`
+
+// command runs a meta-command and reports whether the repl should quit.
+func (r *repl) command(line string) (quit bool) {
+	fields := strings.Fields(line)
+	name, arg := fields[0], strings.TrimSpace(strings.TrimPrefix(line, fields[0]))
+	switch name {
+	case ":help":
+		fmt.Fprint(r.out, help)
+	case ":quit", ":q":
+		return true
+	case ":load":
+		r.load(arg)
+	case ":ast":
+		r.dumpAST(arg)
+	case ":irmf":
+		if shader := r.shader(); shader != nil {
+			fmt.Fprintln(r.out, shader.String())
+		}
+	case ":mbb":
+		if shader := r.shader(); shader != nil {
+			if mbb := shader.MBB; mbb != nil {
+				fmt.Fprintf(r.out, "[%v, %v, %v] to [%v, %v, %v]\n", mbb.XMin, mbb.YMin, mbb.ZMin, mbb.XMax, mbb.YMax, mbb.ZMax)
+			} else {
+				fmt.Fprintln(r.out, "no geometry")
+			}
+		}
+	case ":env":
+		for _, name := range r.env.Names() {
+			obj, _ := r.env.Get(name)
+			fmt.Fprintf(r.out, "%v = %v\n", name, obj.Inspect())
+		}
+	case ":reset":
+		r.env = object.NewEnvironment()
+		r.input, r.last = nil, nil
+	default:
+		fmt.Fprintf(r.out, "unknown command %v (see :help)\n", name)
+	}
+	return false
+}
+
+func (r *repl) load(filename string) {
+	if filename == "" {
+		fmt.Fprintln(r.out, "usage: :load FILE")
+		return
+	}
+	buf, err := ioutil.ReadFile(filename)
+	if err != nil {
+		fmt.Fprintf(r.out, "%v\n", err)
+		return
+	}
+	program, ok := r.parse(string(buf))
+	if !ok {
+		return
+	}
+	r.input, r.last = program, &ast.Program{}
+	for _, stmt := range program.Statements {
+		if _, ok := stmt.(*ast.ExpressionStatement); ok {
+			r.last.Statements = append(r.last.Statements, stmt)
+		}
+	}
+	if obj := evaluator.Eval(program, r.env); obj != nil && obj.Type() == object.ErrorT {
+		fmt.Fprintln(r.out, obj.Inspect())
+		return
+	}
+	fmt.Fprintf(r.out, "loaded %v statements from %v\n", len(program.Statements), filename)
+}
+
+func (r *repl) dumpAST(src string) {
+	program := r.input
+	if src != "" {
+		var ok bool
+		if program, ok = r.parse(src); !ok {
+			return
+		}
+	}
+	if program == nil {
+		fmt.Fprintln(r.out, "no input")
+		return
+	}
+	b, err := json.MarshalIndent(ast.JSON(program), "", "  ")
+	if err != nil {
+		fmt.Fprintf(r.out, "%v\n", err)
+		return
+	}
+	fmt.Fprintln(r.out, string(b))
+}
+
+// shader returns the IRMF shader of the last expression or
+// loaded file, printing its warnings, or nil on error.
+func (r *repl) shader() *irmf.Shader {
+	if r.last == nil {
+		fmt.Fprintln(r.out, "no expression")
+		return nil
+	}
+	shader, err := irmf.New(r.last, false)
+	if err != nil {
+		fmt.Fprintf(r.out, "%v\n", err)
+		return nil
+	}
+	for _, w := range shader.Warnings {
+		fmt.Fprintf(r.out, "warning: %v\n", w)
+	}
+	return shader
+}
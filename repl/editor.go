@@ -0,0 +1,222 @@
+package repl
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// errInterrupt is returned by readLine when the user types ctrl-c.
+var errInterrupt = errors.New("interrupt")
+
+// lineReader reads the lines typed by the user.
+type lineReader interface {
+	readLine(prompt string) (string, error)
+}
+
+// newLineReader returns an editor for a terminal, or else
+// a lineReader that reads plain lines from in.
+func newLineReader(in io.Reader, out io.Writer, h *history) lineReader {
+	if f, ok := in.(*os.File); ok && isTerminal(int(f.Fd())) {
+		fd := int(f.Fd())
+		return &editor{
+			in:      bufio.NewReader(in),
+			out:     out,
+			history: h,
+			raw:     func() (func(), error) { return makeRaw(fd) },
+		}
+	}
+	return &scanner{scanner: bufio.NewScanner(in), out: out}
+}
+
+// scanner represents a lineReader without line editing.
+type scanner struct {
+	scanner *bufio.Scanner
+	out     io.Writer
+}
+
+func (s *scanner) readLine(prompt string) (string, error) {
+	fmt.Fprint(s.out, prompt)
+	if !s.scanner.Scan() {
+		if err := s.scanner.Err(); err != nil {
+			return "", err
+		}
+		return "", io.EOF
+	}
+	return s.scanner.Text(), nil
+}
+
+// editor represents a lineReader that supports the usual
+// line-editing keys, and recalls the history with the up and
+// down arrows.
+type editor struct {
+	in      *bufio.Reader
+	out     io.Writer
+	history *history
+	// raw puts the terminal into raw mode and returns a func
+	// that restores it.
+	raw func() (func(), error)
+
+	prompt string
+	buf    []rune
+	pos    int
+}
+
+// Control keys.
+const (
+	keyCtrlA     = 1
+	keyCtrlB     = 2
+	keyCtrlC     = 3
+	keyCtrlD     = 4
+	keyCtrlE     = 5
+	keyCtrlF     = 6
+	keyBackspace = 8
+	keyCtrlK     = 11
+	keyCtrlL     = 12
+	keyEnter     = 13
+	keyCtrlN     = 14
+	keyCtrlP     = 16
+	keyCtrlU     = 21
+	keyEscape    = 27
+	keyDelete    = 127
+)
+
+func (e *editor) readLine(prompt string) (string, error) {
+	restore, err := e.raw()
+	if err != nil {
+		return "", err
+	}
+	defer restore()
+
+	e.prompt, e.buf, e.pos = prompt, nil, 0
+	index := len(e.history.entries)
+	saved := ""
+	recall := func(i int) {
+		if i < 0 || i > len(e.history.entries) || i == index {
+			return
+		}
+		if index == len(e.history.entries) {
+			saved = string(e.buf)
+		}
+		index = i
+		if i == len(e.history.entries) {
+			e.buf = []rune(saved)
+		} else {
+			e.buf = []rune(e.history.entries[i])
+		}
+		e.pos = len(e.buf)
+	}
+
+	e.refresh()
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			return "", err
+		}
+		switch r {
+		case '\n', keyEnter:
+			fmt.Fprint(e.out, "\r\n")
+			return string(e.buf), nil
+		case keyCtrlC:
+			fmt.Fprint(e.out, "^C\r\n")
+			return "", errInterrupt
+		case keyCtrlD:
+			if len(e.buf) == 0 {
+				fmt.Fprint(e.out, "\r\n")
+				return "", io.EOF
+			}
+			e.delete()
+		case keyCtrlA:
+			e.pos = 0
+		case keyCtrlE:
+			e.pos = len(e.buf)
+		case keyCtrlB:
+			e.move(-1)
+		case keyCtrlF:
+			e.move(1)
+		case keyCtrlK:
+			e.buf = e.buf[:e.pos]
+		case keyCtrlU:
+			e.buf, e.pos = e.buf[e.pos:], 0
+		case keyCtrlL:
+			fmt.Fprint(e.out, "\x1b[H\x1b[2J")
+		case keyCtrlP:
+			recall(index - 1)
+		case keyCtrlN:
+			recall(index + 1)
+		case keyBackspace, keyDelete:
+			if e.pos > 0 {
+				e.pos--
+				e.delete()
+			}
+		case keyEscape:
+			switch e.escape() {
+			case "[A":
+				recall(index - 1)
+			case "[B":
+				recall(index + 1)
+			case "[C":
+				e.move(1)
+			case "[D":
+				e.move(-1)
+			case "[H", "[1~", "OH":
+				e.pos = 0
+			case "[F", "[4~", "OF":
+				e.pos = len(e.buf)
+			case "[3~":
+				e.delete()
+			}
+		default:
+			if unicode.IsPrint(r) {
+				e.buf = append(e.buf[:e.pos], append([]rune{r}, e.buf[e.pos:]...)...)
+				e.pos++
+			}
+		}
+		e.refresh()
+	}
+}
+
+// escape reads the rest of an escape sequence, e.g. "[A" for the up arrow.
+func (e *editor) escape() string {
+	var seq []rune
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			return string(seq)
+		}
+		seq = append(seq, r)
+		if len(seq) > 1 && (r == '~' || unicode.IsLetter(r)) {
+			return string(seq)
+		}
+		if len(seq) == 1 && r != '[' && r != 'O' {
+			return string(seq)
+		}
+	}
+}
+
+func (e *editor) move(delta int) {
+	if pos := e.pos + delta; pos >= 0 && pos <= len(e.buf) {
+		e.pos = pos
+	}
+}
+
+// delete deletes the rune at the cursor.
+func (e *editor) delete() {
+	if e.pos < len(e.buf) {
+		e.buf = append(e.buf[:e.pos], e.buf[e.pos+1:]...)
+	}
+}
+
+// refresh redraws the line and positions the cursor.
+func (e *editor) refresh() {
+	var b strings.Builder
+	b.WriteString("\r" + e.prompt + string(e.buf) + "\x1b[K\r")
+	if n := len([]rune(e.prompt)) + e.pos; n > 0 {
+		fmt.Fprintf(&b, "\x1b[%vC", n)
+	}
+	io.WriteString(e.out, b.String())
+}
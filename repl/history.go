@@ -0,0 +1,59 @@
+package repl
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// history represents the entries entered in the current and,
+// if it has a file, previous sessions.
+type history struct {
+	entries  []string
+	filename string
+}
+
+// load reads the entries of previous sessions from the file,
+// and appends each new entry to it.
+func (h *history) load(filename string) error {
+	h.filename = filename
+	f, err := os.Open(filename)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			h.entries = append(h.entries, line)
+		}
+	}
+	return scanner.Err()
+}
+
+// add adds an entry, where a multi-line entry becomes a single line.
+func (h *history) add(entry string) error {
+	entry = strings.Join(strings.Fields(entry), " ")
+	if entry == "" || (len(h.entries) > 0 && h.entries[len(h.entries)-1] == entry) {
+		return nil
+	}
+	h.entries = append(h.entries, entry)
+	if h.filename == "" {
+		return nil
+	}
+
+	f, err := os.OpenFile(h.filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(f, entry); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
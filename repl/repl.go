@@ -1,48 +1,162 @@
 // Package repl implements a Read, Eval, Print, Loop for our language.
+//
+// An input continues over multiple lines until its brackets are
+// balanced, and lines starting with ":" are meta-commands (see :help).
 package repl
 
 import (
-	"bufio"
 	"fmt"
 	"io"
+	"strings"
 
+	"github.com/gmlewis/go-csg/ast"
 	"github.com/gmlewis/go-csg/evaluator"
 	"github.com/gmlewis/go-csg/lexer"
 	"github.com/gmlewis/go-csg/object"
 	"github.com/gmlewis/go-csg/parser"
+	"github.com/gmlewis/go-csg/token"
 )
 
 // PROMPT is the repl prompt.
 const PROMPT = ">> "
 
+// CONTINUE_PROMPT is the repl prompt for the continuation lines of a
+// multi-line input.
+const CONTINUE_PROMPT = ".. "
+
+// Option represents an option of the repl.
+type Option func(r *repl)
+
+// WithHistory loads the history of previous sessions from the file,
+// and appends each new input to it.
+func WithHistory(filename string) Option {
+	return func(r *repl) {
+		if err := r.history.load(filename); err != nil {
+			fmt.Fprintf(r.out, "unable to load history: %v\n", err)
+		}
+	}
+}
+
+// repl represents the state of a session.
+type repl struct {
+	out     io.Writer
+	lines   lineReader
+	history *history
+	env     *object.Environment
+
+	// input is the last program that parsed (for :ast), and last
+	// holds its last expression (for :irmf and :mbb), or the
+	// expressions of the last loaded file.
+	input *ast.Program
+	last  *ast.Program
+}
+
 // Start starts the repl.
-func Start(in io.Reader, out io.Writer) {
-	scanner := bufio.NewScanner(in)
-	env := object.NewEnvironment()
+func Start(in io.Reader, out io.Writer, opts ...Option) {
+	r := &repl{
+		out:     out,
+		history: &history{},
+		env:     object.NewEnvironment(),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	r.lines = newLineReader(in, out, r.history)
 
 	for {
-		fmt.Printf(PROMPT)
-		if !scanner.Scan() {
+		src, err := r.read()
+		if err == errInterrupt {
+			continue
+		}
+		if err != nil {
+			if err != io.EOF {
+				fmt.Fprintf(out, "%v\n", err)
+			}
 			return
 		}
+		if err := r.history.add(src); err != nil {
+			fmt.Fprintf(out, "unable to save history: %v\n", err)
+		}
 
-		line := scanner.Text()
-		le := lexer.New(line)
-		p := parser.New(le)
+		if strings.HasPrefix(strings.TrimSpace(src), ":") {
+			if quit := r.command(strings.TrimSpace(src)); quit {
+				return
+			}
+			continue
+		}
+		r.eval(src)
+	}
+}
 
-		program := p.ParseProgram()
+// read reads an input, continuing over multiple lines
+// until its brackets are balanced.
+func (r *repl) read() (string, error) {
+	var lines []string
+	prompt := PROMPT
+	for {
+		line, err := r.lines.readLine(prompt)
+		if err == io.EOF && len(lines) > 0 {
+			return strings.Join(lines, "\n"), nil
+		}
+		if err != nil {
+			return "", err
+		}
+		lines = append(lines, line)
+		src := strings.Join(lines, "\n")
+		if strings.HasPrefix(strings.TrimSpace(src), ":") || depth(src) <= 0 {
+			return src, nil
+		}
+		prompt = CONTINUE_PROMPT
+	}
+}
 
-		if len(p.Errors()) != 0 {
-			printParserErrors(out, p.Errors())
-			continue
+// depth returns the number of unclosed brackets in src.
+func depth(src string) int {
+	var n int
+	le := lexer.New(src)
+	for tok := le.NextToken(); tok.Type != token.EOF; tok = le.NextToken() {
+		switch tok.Type {
+		case token.LPAREN, token.LBRACKET, token.LBRACE:
+			n++
+		case token.RPAREN, token.RBRACKET, token.RBRACE:
+			n--
 		}
+	}
+	return n
+}
+
+// parse parses src, printing its errors if it does not parse.
+func (r *repl) parse(src string) (*ast.Program, bool) {
+	le := lexer.New(src)
+	p := parser.New(le)
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		printParserErrors(r.out, p.Errors())
+		return nil, false
+	}
+	return program, true
+}
 
-		evaluated := evaluator.Eval(program, env)
-		if evaluated != nil {
-			io.WriteString(out, evaluated.Inspect())
-			io.WriteString(out, "\n")
+func (r *repl) eval(src string) {
+	program, ok := r.parse(src)
+	if !ok {
+		return
+	}
+	r.input = program
+	for i := len(program.Statements) - 1; i >= 0; i-- {
+		if es, ok := program.Statements[i].(*ast.ExpressionStatement); ok {
+			if _, isComment := es.Expression.(*ast.LineComment); !isComment {
+				r.last = &ast.Program{Statements: []ast.Statement{es}}
+				break
+			}
 		}
 	}
+
+	evaluated := evaluator.Eval(program, r.env)
+	if evaluated != nil {
+		io.WriteString(r.out, evaluated.Inspect())
+		io.WriteString(r.out, "\n")
+	}
 }
 
 func printParserErrors(out io.Writer, errors []string) {
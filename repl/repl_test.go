@@ -0,0 +1,129 @@
+package repl
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStart(t *testing.T) {
+	dir, err := ioutil.TempDir("", "repl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	csgFile := filepath.Join(dir, "a.csg")
+	if err := ioutil.WriteFile(csgFile, []byte("let s = 2;\nsquare(size = [1, 3]);\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{
+			in:   "let a = [\n1,\n2];\na[1] + 3\n",
+			want: ">> .. .. >> 5\n>> ",
+		},
+		{
+			in:   "translate([1, 0, 0]) {\n  cube(size = [1, 2, 3]);\n}\n// done\n:mbb\n",
+			want: ">> .. .. translate([1, 0, 0]) {\n\tcube(size = [1, 2, 3]);\n}\n>> // done\n>> [1, 0, 0] to [2, 2, 3]\n>> ",
+		},
+		{
+			in:   "let x = 1;\nlet y = \"two\";\n:env\n:reset\n:env\n:mbb\n",
+			want: ">> >> >> x = 1\ny = two\n>> >> >> no expression\n>> ",
+		},
+		{
+			in:   fmt.Sprintf(":load %v\n:env\n:mbb\n", csgFile),
+			want: fmt.Sprintf(">> loaded 2 statements from %v\n>> s = 2\n>> warning: top-level 2D objects extend infinitely along Z; use a preview thickness to render them like OpenSCAD.\n[0, 0, 0] to [1, 3, 0]\n>> ", csgFile),
+		},
+		{
+			in:   ":ast\n:ast 1\n:bogus\ncube(size = );\n:quit\n1;\n",
+			want: ">> no input\n>> {\n  \"statements\": [\n    {\n      \"expression\": {\n        \"type\": \"IntegerLiteral\",\n        \"value\": 1\n      },\n      \"type\": \"ExpressionStatement\"\n    }\n  ],\n  \"type\": \"Program\"\n}\n>> unknown command :bogus (see :help)\n>> \tno prefix parse function for ) found\n\texpected next token to be ), got ;\n>> ",
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test #%v", i), func(t *testing.T) {
+			var out bytes.Buffer
+			Start(strings.NewReader(tt.in), &out)
+			if got := out.String(); got != tt.want {
+				t.Errorf("output =\n%q\nwant:\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithHistory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "repl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "history")
+
+	Start(strings.NewReader("let x = 1;\nlet x = 1;\nunion() {\n  cube(size = 1);\n}\n"), ioutil.Discard, WithHistory(filename))
+	Start(strings.NewReader(":env\n"), ioutil.Discard, WithHistory(filename))
+
+	got, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "let x = 1;\nunion() { cube(size = 1); }\n:env\n"; string(got) != want {
+		t.Errorf("history =\n%v\nwant:\n%v", string(got), want)
+	}
+}
+
+func TestEditor(t *testing.T) {
+	tests := []struct {
+		keys string
+		want []string
+	}{
+		{keys: "abc\r", want: []string{"abc"}},
+		{keys: "ac\x1b[Db\x1b[C d\r", want: []string{"abc d"}},
+		{keys: "world\x01hello \x05!\r", want: []string{"hello world!"}},
+		{keys: "abcd\x7f\x7f\x01\x1b[3~\r", want: []string{"b"}},
+		{keys: "one two\x01\x1b[C\x1b[C\x1b[C\x0b\r", want: []string{"one"}},
+		{keys: "junk\x03new\r", want: []string{"interrupt", "new"}},
+		{keys: "\x1b[A\x1b[A\r\x1b[A\x1b[B\x1b[Bdraft\x1b[A\x1b[B\r", want: []string{"first", "draft"}},
+		{keys: "a\x04\r\x04", want: []string{"a", "EOF"}},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test #%v", i), func(t *testing.T) {
+			e := &editor{
+				in:      bufio.NewReader(strings.NewReader(tt.keys)),
+				out:     ioutil.Discard,
+				history: &history{entries: []string{"first", "second"}},
+				raw:     func() (func(), error) { return func() {}, nil },
+			}
+			var got []string
+			for {
+				line, err := e.readLine(PROMPT)
+				if err == errInterrupt {
+					got = append(got, "interrupt")
+					continue
+				}
+				if err == io.EOF {
+					if strings.HasSuffix(tt.keys, "\x04") {
+						got = append(got, "EOF")
+					}
+					break
+				}
+				if err != nil {
+					t.Fatalf("readLine: %v", err)
+				}
+				got = append(got, line)
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("lines = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,17 @@
+//go:build !linux
+// +build !linux
+
+package repl
+
+import (
+	"fmt"
+	"runtime"
+)
+
+func isTerminal(fd int) bool {
+	return false
+}
+
+func makeRaw(fd int) (restore func(), err error) {
+	return nil, fmt.Errorf("line editing is not supported on %v", runtime.GOOS)
+}
@@ -1,2 +1,2 @@
 #!/bin/bash -ex
-go run cmd/csg2irmf/main.go examples/$@*/$@*.csg
+go run ./cmd/csg2irmf -f examples/$@*/$@*.csg
@@ -0,0 +1,109 @@
+// Package slicer evaluates a solid.Model as a stack of voxel layers,
+// suitable for resin (DLP) and multi-material printers.
+//
+// Each layer is an image whose color channels hold the occupancy of
+// up to four materials, matching the vec4 materials output by the
+// IRMF mainModel4 function: red is the first material, green the
+// second, blue the third and alpha the fourth.
+package slicer
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/gmlewis/go-csg/solid"
+)
+
+// MaxMaterials is the number of materials that fit in the RGBA channels.
+const MaxMaterials = 4
+
+// Manifest describes a slice stack.
+type Manifest struct {
+	Units       string     `json:"units"`
+	LayerHeight float64    `json:"layerHeight"`
+	PixelPitch  float64    `json:"pixelPitch"`
+	Width       int        `json:"width"`
+	Height      int        `json:"height"`
+	Min         [3]float64 `json:"min"`
+	Max         [3]float64 `json:"max"`
+	Channels    []string   `json:"channels"`
+	Layers      []Layer    `json:"layers"`
+}
+
+// Layer describes a single layer of the stack.
+type Layer struct {
+	Index    int     `json:"index"`
+	Z        float64 `json:"z"`
+	Filename string  `json:"filename,omitempty"`
+}
+
+// Slicer samples a model over a bounding box.
+type Slicer struct {
+	Manifest *Manifest
+
+	model *solid.Model
+}
+
+// New returns a Slicer that covers the box with layers of the given
+// height and pixels of the given pitch (both in model units).
+func New(model *solid.Model, box solid.Box, layerHeight, pixelPitch float64) (*Slicer, error) {
+	if layerHeight <= 0 || pixelPitch <= 0 {
+		return nil, errors.New("layer height and pixel pitch must be positive")
+	}
+	if box.Empty() {
+		return nil, errors.New("empty bounding box")
+	}
+	if len(model.Materials) > MaxMaterials {
+		return nil, fmt.Errorf("%v materials found; at most %v are supported", len(model.Materials), MaxMaterials)
+	}
+
+	m := &Manifest{
+		Units:       "mm",
+		LayerHeight: layerHeight,
+		PixelPitch:  pixelPitch,
+		Width:       steps(box.Max[0]-box.Min[0], pixelPitch),
+		Height:      steps(box.Max[1]-box.Min[1], pixelPitch),
+		Min:         box.Min,
+		Max:         box.Max,
+	}
+	for _, mat := range model.Materials {
+		m.Channels = append(m.Channels, mat.Name)
+	}
+	n := steps(box.Max[2]-box.Min[2], layerHeight)
+	for i := 0; i < n; i++ {
+		m.Layers = append(m.Layers, Layer{Index: i, Z: box.Min[2] + (float64(i)+0.5)*layerHeight})
+	}
+	return &Slicer{Manifest: m, model: model}, nil
+}
+
+// steps returns the number of samples of the given size needed to cover d.
+func steps(d, size float64) int {
+	n := int(math.Ceil(d/size - 1e-9))
+	if n < 1 {
+		n = 1
+	}
+	return n
+}
+
+// Layer renders layer i. Samples are taken at the pixel centers and
+// the top row of the image is at the maximum Y of the box.
+func (s *Slicer) Layer(i int) *image.NRGBA {
+	m := s.Manifest
+	img := image.NewNRGBA(image.Rect(0, 0, m.Width, m.Height))
+	z := m.Layers[i].Z
+	for row := 0; row < m.Height; row++ {
+		y := m.Max[1] - (float64(row)+0.5)*m.PixelPitch
+		for col := 0; col < m.Width; col++ {
+			x := m.Min[0] + (float64(col)+0.5)*m.PixelPitch
+			var c [MaxMaterials]uint8
+			if mat := s.model.At(solid.Vec3{x, y, z}); mat >= 0 {
+				c[mat] = 255
+			}
+			img.SetNRGBA(col, row, color.NRGBA{R: c[0], G: c[1], B: c[2], A: c[3]})
+		}
+	}
+	return img
+}
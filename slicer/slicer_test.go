@@ -0,0 +1,75 @@
+package slicer
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/gmlewis/go-csg/lexer"
+	"github.com/gmlewis/go-csg/parser"
+	"github.com/gmlewis/go-csg/solid"
+)
+
+func TestSlicer(t *testing.T) {
+	type probe struct {
+		layer, col, row int
+		want            color.NRGBA
+	}
+	tests := []struct {
+		src           string
+		width, height int
+		layers        int
+		probes        []probe
+	}{
+		{
+			src:    "cube(size = [4, 2, 1], center = false);",
+			width:  8,
+			height: 4,
+			layers: 2,
+			probes: []probe{{0, 0, 0, color.NRGBA{R: 255}}, {1, 7, 3, color.NRGBA{R: 255}}},
+		},
+		{
+			src: `color([1, 0, 0, 1]) { cube(size = [2, 2, 2], center = false); }
+color([0, 0, 1, 1]) { multmatrix([[1, 0, 0, 2], [0, 1, 0, 0], [0, 0, 1, 0], [0, 0, 0, 1]]) { cube(size = [2, 2, 1], center = false); } }`,
+			width:  8,
+			height: 4,
+			layers: 4,
+			probes: []probe{
+				{0, 0, 0, color.NRGBA{G: 255}},
+				{0, 7, 0, color.NRGBA{B: 255}},
+				{3, 0, 3, color.NRGBA{G: 255}},
+				{3, 7, 3, color.NRGBA{}},
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test #%v", i), func(t *testing.T) {
+			le := lexer.New(tt.src)
+			p := parser.New(le)
+			program := p.ParseProgram()
+			if errs := p.Errors(); len(errs) != 0 {
+				t.Fatalf("ParseProgram: %v", strings.Join(errs, "\n"))
+			}
+			model, err := solid.New(program)
+			if err != nil {
+				t.Fatalf("solid.New: %v", err)
+			}
+
+			s, err := New(model, model.Bounds, 0.5, 0.5)
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+			m := s.Manifest
+			if m.Width != tt.width || m.Height != tt.height || len(m.Layers) != tt.layers {
+				t.Errorf("size = %vx%vx%v, want %vx%vx%v", m.Width, m.Height, len(m.Layers), tt.width, tt.height, tt.layers)
+			}
+			for _, pr := range tt.probes {
+				if got := s.Layer(pr.layer).NRGBAAt(pr.col, pr.row); got != pr.want {
+					t.Errorf("layer %v (%v,%v) = %+v, want %+v", pr.layer, pr.col, pr.row, got, pr.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,232 @@
+package solid
+
+import (
+	"fmt"
+
+	"github.com/gmlewis/go-csg/ast"
+	"github.com/gmlewis/go-csg/params"
+	"github.com/gmlewis/go-csg/transform"
+)
+
+// union represents the union of its children. Where children
+// overlap, the material of the first child wins.
+type union struct {
+	children []node
+	box      Box
+}
+
+func newUnion(children []node) *union {
+	box := emptyBox
+	for _, c := range children {
+		box = box.union(c.bounds())
+	}
+	return &union{children: children, box: box}
+}
+
+func (u *union) at(p Vec3) int {
+	if !u.box.Contains(p) {
+		return -1
+	}
+	for _, c := range u.children {
+		if m := c.at(p); m >= 0 {
+			return m
+		}
+	}
+	return -1
+}
+
+func (u *union) bounds() Box { return u.box }
+
+// difference represents the first child minus all the others.
+type difference struct {
+	children []node
+}
+
+func (d *difference) at(p Vec3) int {
+	m := d.children[0].at(p)
+	if m < 0 {
+		return -1
+	}
+	for _, c := range d.children[1:] {
+		if c.at(p) >= 0 {
+			return -1
+		}
+	}
+	return m
+}
+
+func (d *difference) bounds() Box { return d.children[0].bounds() }
+
+// intersection represents the intersection of its children,
+// using the material of the first child.
+type intersection struct {
+	children []node
+}
+
+func (in *intersection) at(p Vec3) int {
+	m := in.children[0].at(p)
+	if m < 0 {
+		return -1
+	}
+	for _, c := range in.children[1:] {
+		if c.at(p) < 0 {
+			return -1
+		}
+	}
+	return m
+}
+
+func (in *intersection) bounds() Box {
+	box := in.children[0].bounds()
+	for _, c := range in.children[1:] {
+		box = box.intersect(c.bounds())
+	}
+	return box
+}
+
+// colored assigns its material to all uncolored geometry within it.
+// As in OpenSCAD, an inner color overrides an outer one.
+type colored struct {
+	material int
+	child    node
+}
+
+func (b *builder) color(exp *ast.ColorBlockPrimitive) (node, error) {
+	rgba, err := params.Color(exp.Arguments)
+	if err != nil {
+		return nil, fmt.Errorf("color: %v", err)
+	}
+	material := b.material(rgba)
+	children, err := b.block(exp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &colored{material: material, child: newUnion(children)}, nil
+}
+
+func (c *colored) at(p Vec3) int {
+	m := c.child.at(p)
+	if m == 0 {
+		return c.material
+	}
+	return m
+}
+
+func (c *colored) bounds() Box { return c.child.bounds() }
+
+// affine applies a transform to its children.
+type affine struct {
+	inv   [4][4]float64
+	child node
+	box   Box
+}
+
+// transform applies a multmatrix, translate, rotate, scale, mirror
+// or resize block to its children.
+func (b *builder) transform(exp ast.Expression) (node, error) {
+	m, err := transform.Of(exp, b.bounds)
+	if _, ok := err.(*transform.Warning); err != nil && !ok {
+		return nil, err
+	}
+	inv, ok := invert(m)
+	if !ok {
+		return nil, fmt.Errorf("%v: singular matrix %v", exp.TokenLiteral(), m)
+	}
+
+	children, err := b.block(transform.Body(exp))
+	if err != nil {
+		return nil, err
+	}
+	child := newUnion(children)
+
+	box := emptyBox
+	if cb := child.bounds(); !cb.Empty() {
+		for i := 0; i < 8; i++ {
+			corner := Vec3{cb.Min[0], cb.Min[1], cb.Min[2]}
+			for j := 0; j < 3; j++ {
+				if i&(1<<uint(j)) != 0 {
+					corner[j] = cb.Max[j]
+				}
+			}
+			p := apply(m, corner)
+			box = box.union(Box{Min: p, Max: p})
+		}
+	}
+	return &affine{inv: inv, child: child, box: box}, nil
+}
+
+// bounds returns the bounding box of the children of a block.
+func (b *builder) bounds(body *ast.BlockStatement) (min, max [3]float64, ok bool) {
+	children, err := b.block(body)
+	if err != nil {
+		return min, max, false
+	}
+	box := newUnion(children).bounds()
+	if box.Empty() {
+		return min, max, false
+	}
+	return box.Min, box.Max, true
+}
+
+func (t *affine) at(p Vec3) int {
+	if !t.box.Contains(p) {
+		return -1
+	}
+	return t.child.at(apply(t.inv, p))
+}
+
+func (t *affine) bounds() Box { return t.box }
+
+func apply(m [4][4]float64, v Vec3) Vec3 {
+	return Vec3{
+		m[0][0]*v[0] + m[0][1]*v[1] + m[0][2]*v[2] + m[0][3],
+		m[1][0]*v[0] + m[1][1]*v[1] + m[1][2]*v[2] + m[1][3],
+		m[2][0]*v[0] + m[2][1]*v[1] + m[2][2]*v[2] + m[2][3],
+	}
+}
+
+// invert returns the inverse of m using Gauss-Jordan elimination.
+func invert(m [4][4]float64) ([4][4]float64, bool) {
+	var a [4][8]float64
+	for i := 0; i < 4; i++ {
+		copy(a[i][:4], m[i][:])
+		a[i][4+i] = 1
+	}
+	for col := 0; col < 4; col++ {
+		pivot := col
+		for row := col + 1; row < 4; row++ {
+			if abs(a[row][col]) > abs(a[pivot][col]) {
+				pivot = row
+			}
+		}
+		if abs(a[pivot][col]) < 1e-12 {
+			return m, false
+		}
+		a[col], a[pivot] = a[pivot], a[col]
+		f := a[col][col]
+		for j := range a[col] {
+			a[col][j] /= f
+		}
+		for row := 0; row < 4; row++ {
+			if row == col || a[row][col] == 0 {
+				continue
+			}
+			f := a[row][col]
+			for j := range a[row] {
+				a[row][j] -= f * a[col][j]
+			}
+		}
+	}
+	var result [4][4]float64
+	for i := 0; i < 4; i++ {
+		copy(result[i][:], a[i][4:])
+	}
+	return result, true
+}
+
+func abs(v float64) float64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
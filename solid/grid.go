@@ -0,0 +1,67 @@
+package solid
+
+import (
+	"math"
+	"runtime"
+	"sync"
+)
+
+// Grid represents a regular grid of cells over a box.
+type Grid struct {
+	Box Box
+	// N is the number of cells along each axis.
+	N [3]int
+	// Size is the size of a cell.
+	Size Vec3
+}
+
+// CellSize returns the cell size that divides the box into about n cubic cells.
+func (b Box) CellSize(n int) float64 {
+	return math.Cbrt(math.Max(b.Max[0]-b.Min[0], 1e-9) * math.Max(b.Max[1]-b.Min[1], 1e-9) * math.Max(b.Max[2]-b.Min[2], 1e-9) / float64(n))
+}
+
+// NewGrid returns the grid of cells of about the given size over the box.
+func NewGrid(box Box, size float64) *Grid {
+	g := &Grid{Box: box}
+	for i := 0; i < 3; i++ {
+		extent := box.Max[i] - box.Min[i]
+		g.N[i] = int(math.Max(1, math.Ceil(extent/size)))
+		g.Size[i] = extent / float64(g.N[i])
+	}
+	return g
+}
+
+// Cells returns the number of cells of the grid.
+func (g *Grid) Cells() int {
+	return g.N[0] * g.N[1] * g.N[2]
+}
+
+// Sample calls f with the layer index k and the center of each cell
+// of the grid, sampling the layers concurrently. All the cells of a
+// layer are sampled in order by the same goroutine, so f may add them
+// up per layer without locking; adding the layers in order then gives
+// a result that does not depend on the scheduling.
+func (g *Grid) Sample(f func(k int, p Vec3)) {
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < runtime.NumCPU(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for k := range indices {
+				z := g.Box.Min[2] + (float64(k)+0.5)*g.Size[2]
+				for j := 0; j < g.N[1]; j++ {
+					y := g.Box.Min[1] + (float64(j)+0.5)*g.Size[1]
+					for i := 0; i < g.N[0]; i++ {
+						f(k, Vec3{g.Box.Min[0] + (float64(i)+0.5)*g.Size[0], y, z})
+					}
+				}
+			}
+		}()
+	}
+	for k := 0; k < g.N[2]; k++ {
+		indices <- k
+	}
+	close(indices)
+	wg.Wait()
+}
@@ -0,0 +1,236 @@
+package solid
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/gmlewis/go-csg/ast"
+	"github.com/gmlewis/go-csg/geom2d"
+	"github.com/gmlewis/go-csg/params"
+)
+
+// primitive represents uncolored geometry (the default material).
+type primitive struct {
+	box    Box
+	inside func(p Vec3) bool
+}
+
+func (pr *primitive) at(p Vec3) int {
+	if pr.box.Contains(p) && pr.inside(p) {
+		return 0
+	}
+	return -1
+}
+
+func (pr *primitive) bounds() Box { return pr.box }
+
+func newCube(exps []ast.Expression) (node, error) {
+	size, center, err := params.Cube(exps)
+	if err != nil {
+		return nil, fmt.Errorf("cube: %v", err)
+	}
+	box := Box{Max: Vec3{size[0], size[1], size[2]}}
+	if center {
+		for i := 0; i < 3; i++ {
+			box.Min[i] -= 0.5 * size[i]
+			box.Max[i] -= 0.5 * size[i]
+		}
+	}
+	return &primitive{box: box, inside: func(p Vec3) bool { return true }}, nil
+}
+
+func newSphere(exps []ast.Expression) (node, error) {
+	r, _, err := params.Sphere(exps)
+	if err != nil {
+		return nil, fmt.Errorf("sphere: %v", err)
+	}
+	return &primitive{
+		box:    Box{Min: Vec3{-r, -r, -r}, Max: Vec3{r, r, r}},
+		inside: func(p Vec3) bool { return p[0]*p[0]+p[1]*p[1]+p[2]*p[2] <= r*r },
+	}, nil
+}
+
+func newCylinder(exps []ast.Expression) (node, error) {
+	c, err := params.Cylinder(exps)
+	if err != nil {
+		return nil, fmt.Errorf("cylinder: %v", err)
+	}
+	if c.H <= 0 {
+		return nil, nil
+	}
+	z0 := 0.0
+	if c.Center {
+		z0 = -0.5 * c.H
+	}
+	rmax := math.Max(c.R1, c.R2)
+	return &primitive{
+		box: Box{Min: Vec3{-rmax, -rmax, z0}, Max: Vec3{rmax, rmax, z0 + c.H}},
+		inside: func(p Vec3) bool {
+			r := c.R1 + (c.R2-c.R1)*(p[2]-z0)/c.H
+			return p[0]*p[0]+p[1]*p[1] <= r*r
+		},
+	}, nil
+}
+
+// newPolyhedron returns a polyhedron whose inside is determined by
+// its generalized winding number, which is robust to small defects
+// in the mesh.
+func newPolyhedron(exps []ast.Expression) (node, error) {
+	points, faces, err := params.Polyhedron(exps)
+	if err != nil {
+		return nil, fmt.Errorf("polyhedron: %v", err)
+	}
+	box := emptyBox
+	for _, pt := range points {
+		box = box.union(Box{Min: pt, Max: pt})
+	}
+	var tris [][3]Vec3
+	for _, face := range faces {
+		for i := 1; i+1 < len(face); i++ {
+			// OpenSCAD faces are clockwise when viewed from outside.
+			tris = append(tris, [3]Vec3{points[face[0]], points[face[i+1]], points[face[i]]})
+		}
+	}
+	return &primitive{
+		box: box,
+		inside: func(p Vec3) bool {
+			var sum float64
+			for _, t := range tris {
+				sum += solidAngle(t, p)
+			}
+			return sum > 2*math.Pi
+		},
+	}, nil
+}
+
+// solidAngle returns the signed solid angle subtended by the
+// triangle at p (Van Oosterom and Strackee).
+func solidAngle(t [3]Vec3, p Vec3) float64 {
+	var v [3]Vec3
+	var l [3]float64
+	for i := range t {
+		v[i] = Vec3{t[i][0] - p[0], t[i][1] - p[1], t[i][2] - p[2]}
+		l[i] = math.Sqrt(dot(v[i], v[i]))
+	}
+	num := dot(v[0], cross(v[1], v[2]))
+	den := l[0]*l[1]*l[2] + dot(v[0], v[1])*l[2] + dot(v[0], v[2])*l[1] + dot(v[1], v[2])*l[0]
+	return 2 * math.Atan2(num, den)
+}
+
+func dot(a, b Vec3) float64 { return a[0]*b[0] + a[1]*b[1] + a[2]*b[2] }
+
+func cross(a, b Vec3) Vec3 {
+	return Vec3{a[1]*b[2] - a[2]*b[1], a[2]*b[0] - a[0]*b[2], a[0]*b[1] - a[1]*b[0]}
+}
+
+func newLinearExtrude(exp *ast.LinearExtrudeBlockPrimitive) (node, error) {
+	le, err := params.LinearExtrude(exp.Arguments)
+	if err != nil {
+		return nil, fmt.Errorf("linear_extrude: %v", err)
+	}
+	shape, err := geom2d.EvalNode(exp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("linear_extrude: %v", err)
+	}
+	if len(shape) == 0 || le.Height <= 0 {
+		return nil, nil
+	}
+
+	// The extrusion follows the vector d, starting at o.
+	d := Vec3{le.Height * le.V[0], le.Height * le.V[1], le.Height * le.V[2]}
+	if d[2] <= 0 {
+		return nil, fmt.Errorf("linear_extrude: v must point upward")
+	}
+	var o Vec3
+	if le.Center {
+		o = Vec3{-0.5 * d[0], -0.5 * d[1], -0.5 * d[2]}
+	}
+	min, max := shape.Bounds()
+	box := Box{Min: Vec3{min.X, min.Y, o[2]}, Max: Vec3{max.X, max.Y, o[2] + d[2]}}
+	sx, sy := math.Max(1, le.Scale[0]), math.Max(1, le.Scale[1])
+	if le.Twist != 0 {
+		// Any rotation stays within the circle enclosing the shape.
+		var r float64
+		for _, pt := range []geom2d.Point{min, max, {X: min.X, Y: max.Y}, {X: max.X, Y: min.Y}} {
+			r = math.Max(r, math.Hypot(sx*pt.X, sy*pt.Y))
+		}
+		box.Min[0], box.Min[1], box.Max[0], box.Max[1] = -r, -r, r, r
+	} else {
+		box.Min[0], box.Max[0] = math.Min(sx*min.X, min.X), math.Max(sx*max.X, max.X)
+		box.Min[1], box.Max[1] = math.Min(sy*min.Y, min.Y), math.Max(sy*max.Y, max.Y)
+	}
+	for i := 0; i < 2; i++ {
+		box.Min[i] += math.Min(o[i], o[i]+d[i])
+		box.Max[i] += math.Max(o[i], o[i]+d[i])
+	}
+
+	twist := le.Twist * math.Pi / 180
+	return &primitive{
+		box: box,
+		inside: func(p Vec3) bool {
+			t := (p[2] - o[2]) / d[2]
+			x, y := p[0]-o[0]-t*d[0], p[1]-o[1]-t*d[1]
+			if twist != 0 {
+				// Positive twist rotates clockwise as z increases,
+				// so rotate the query point counterclockwise.
+				s, c := math.Sincos(twist * t)
+				x, y = x*c-y*s, x*s+y*c
+			}
+			scaleX := 1 + (le.Scale[0]-1)*t
+			scaleY := 1 + (le.Scale[1]-1)*t
+			if scaleX <= 0 || scaleY <= 0 {
+				return false
+			}
+			return shape.Contains(geom2d.Point{X: x / scaleX, Y: y / scaleY})
+		},
+	}, nil
+}
+
+func newRotateExtrude(exp *ast.RotateExtrudeBlockPrimitive) (node, error) {
+	angle, err := params.RotateExtrude(exp.Arguments)
+	if err != nil {
+		return nil, fmt.Errorf("rotate_extrude: %v", err)
+	}
+	if angle == 0 {
+		return nil, nil
+	}
+	shape, err := geom2d.EvalNode(exp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("rotate_extrude: %v", err)
+	}
+	if len(shape) == 0 {
+		return nil, nil
+	}
+
+	min, max := shape.Bounds()
+	if min.X < 0 && max.X > 0 {
+		return nil, fmt.Errorf("rotate_extrude: all points must have the same X coordinate sign (range is %.2f -> %.2f)", min.X, max.X)
+	}
+	// A profile at X <= 0 is revolved as its mirror image, starting
+	// half a turn away.
+	sign, start := 1.0, 0.0
+	if max.X <= 0 && min.X < 0 {
+		sign, start = -1, math.Pi
+	}
+	r := math.Max(math.Abs(min.X), math.Abs(max.X))
+	box := Box{Min: Vec3{-r, -r, min.Y}, Max: Vec3{r, r, max.Y}}
+	limit := math.Abs(angle) * math.Pi / 180
+	return &primitive{
+		box: box,
+		inside: func(p Vec3) bool {
+			if angle != 360 {
+				phi := math.Atan2(p[1], p[0]) - start
+				if angle < 0 {
+					phi = -phi
+				}
+				if phi = math.Mod(phi, 2*math.Pi); phi < 0 {
+					phi += 2 * math.Pi
+				}
+				if phi > limit {
+					return false
+				}
+			}
+			return shape.Contains(geom2d.Point{X: sign * math.Hypot(p[0], p[1]), Y: p[2]})
+		},
+	}, nil
+}
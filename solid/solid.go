@@ -0,0 +1,209 @@
+// Package solid evaluates a CSG program as a 3D solid on the CPU.
+// The resulting Model answers point-membership queries, reporting
+// which material (if any) occupies a point in space. Materials are
+// taken from the color() blocks in the program.
+package solid
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/gmlewis/go-csg/ast"
+)
+
+// DefaultMaterial is the name of the material used for geometry
+// outside of any color() block (consistent with the IRMF header).
+const DefaultMaterial = "PLA"
+
+// Vec3 represents a 3D point or vector.
+type Vec3 [3]float64
+
+// Box represents an axis-aligned bounding box.
+type Box struct {
+	Min, Max Vec3
+}
+
+// Material represents a material with a display color.
+type Material struct {
+	Name  string
+	Color [4]float64 // RGBA, from 0 to 1.
+}
+
+// Model represents a solid that can be queried for point membership.
+type Model struct {
+	// Materials lists the materials of the model.
+	// Materials[0] is always the default material.
+	Materials []*Material
+	// Bounds is the bounding box of the model.
+	Bounds Box
+
+	root node
+}
+
+// node represents a solid within the CSG tree.
+type node interface {
+	// at returns the material index at p, or -1 if p is empty.
+	at(p Vec3) int
+	// bounds returns the bounding box of the node (Min > Max when empty).
+	bounds() Box
+}
+
+// New returns a new Model from a CSG ast.Program.
+func New(program *ast.Program) (*Model, error) {
+	b := &builder{
+		materials: []*Material{{Name: DefaultMaterial, Color: [4]float64{1, 1, 1, 1}}},
+		index:     map[[4]float64]int{},
+	}
+	children, err := b.statements(program.Statements)
+	if err != nil {
+		return nil, err
+	}
+	root := newUnion(children)
+	return &Model{Materials: b.materials, Bounds: root.bounds(), root: root}, nil
+}
+
+// At returns the index into Materials of the material at p,
+// or -1 if p is empty.
+func (m *Model) At(p Vec3) int {
+	return m.root.at(p)
+}
+
+// Empty reports whether the box contains no volume.
+func (b Box) Empty() bool {
+	return b.Min[0] > b.Max[0] || b.Min[1] > b.Max[1] || b.Min[2] > b.Max[2]
+}
+
+// Contains reports whether p is within the box.
+func (b Box) Contains(p Vec3) bool {
+	return p[0] >= b.Min[0] && p[0] <= b.Max[0] &&
+		p[1] >= b.Min[1] && p[1] <= b.Max[1] &&
+		p[2] >= b.Min[2] && p[2] <= b.Max[2]
+}
+
+var emptyBox = Box{
+	Min: Vec3{math.Inf(1), math.Inf(1), math.Inf(1)},
+	Max: Vec3{math.Inf(-1), math.Inf(-1), math.Inf(-1)},
+}
+
+func (b Box) union(o Box) Box {
+	for i := 0; i < 3; i++ {
+		b.Min[i] = math.Min(b.Min[i], o.Min[i])
+		b.Max[i] = math.Max(b.Max[i], o.Max[i])
+	}
+	return b
+}
+
+func (b Box) intersect(o Box) Box {
+	for i := 0; i < 3; i++ {
+		b.Min[i] = math.Max(b.Min[i], o.Min[i])
+		b.Max[i] = math.Min(b.Max[i], o.Max[i])
+	}
+	return b
+}
+
+// builder converts the AST into a tree of nodes.
+type builder struct {
+	materials []*Material
+	index     map[[4]float64]int
+}
+
+// material returns the index of the material with the given color,
+// adding it if necessary.
+func (b *builder) material(rgba [4]float64) int {
+	if i, ok := b.index[rgba]; ok {
+		return i
+	}
+	i := len(b.materials)
+	b.index[rgba] = i
+	b.materials = append(b.materials, &Material{
+		Name:  fmt.Sprintf("color_%02X%02X%02X%02X", channel(rgba[0]), channel(rgba[1]), channel(rgba[2]), channel(rgba[3])),
+		Color: rgba,
+	})
+	return i
+}
+
+func channel(v float64) int {
+	return int(math.Round(255 * math.Max(0, math.Min(1, v))))
+}
+
+func (b *builder) statements(stmts []ast.Statement) ([]node, error) {
+	var result []node
+	for _, stmt := range stmts {
+		n, err := b.statement(stmt)
+		if err != nil {
+			return nil, err
+		}
+		if n != nil {
+			result = append(result, n)
+		}
+	}
+	return result, nil
+}
+
+func (b *builder) block(block *ast.BlockStatement) ([]node, error) {
+	if block == nil {
+		return nil, nil
+	}
+	return b.statements(block.Statements)
+}
+
+func (b *builder) statement(stmt ast.Statement) (node, error) {
+	switch stmt := stmt.(type) {
+	case *ast.ExpressionStatement:
+		return b.expression(stmt.Expression)
+	}
+	return nil, fmt.Errorf("unhandled statement type %T (%+v)", stmt, stmt)
+}
+
+func (b *builder) expression(exp ast.Expression) (node, error) {
+	switch exp := exp.(type) {
+	case *ast.LineComment, *ast.GroupPrimitive:
+		return nil, nil
+	case *ast.ModifierExpression:
+		if exp.Disabled() {
+			return nil, nil
+		}
+		return b.expression(exp.Right)
+	case *ast.CubePrimitive:
+		return newCube(exp.Arguments)
+	case *ast.CylinderPrimitive:
+		return newCylinder(exp.Arguments)
+	case *ast.SpherePrimitive:
+		return newSphere(exp.Arguments)
+	case *ast.PolyhedronPrimitive:
+		return newPolyhedron(exp.Arguments)
+	case *ast.ColorBlockPrimitive:
+		return b.color(exp)
+	case *ast.GroupBlockPrimitive:
+		children, err := b.block(exp.Body)
+		return newUnion(children), err
+	case *ast.UnionBlockPrimitive:
+		children, err := b.block(exp.Body)
+		return newUnion(children), err
+	case *ast.DifferenceBlockPrimitive:
+		children, err := b.block(exp.Body)
+		if err != nil || len(children) == 0 {
+			return nil, err
+		}
+		return &difference{children: children}, nil
+	case *ast.IntersectionBlockPrimitive:
+		children, err := b.block(exp.Body)
+		if err != nil || len(children) == 0 {
+			return nil, err
+		}
+		return &intersection{children: children}, nil
+	case *ast.MultmatrixBlockPrimitive, *ast.TranslateBlockPrimitive, *ast.RotateBlockPrimitive,
+		*ast.ScaleBlockPrimitive, *ast.MirrorBlockPrimitive, *ast.ResizeBlockPrimitive:
+		return b.transform(exp)
+	case *ast.LinearExtrudeBlockPrimitive:
+		return newLinearExtrude(exp)
+	case *ast.RotateExtrudeBlockPrimitive:
+		return newRotateExtrude(exp)
+	case *ast.CirclePrimitive, *ast.SquarePrimitive, *ast.PolygonPrimitive,
+		*ast.OffsetBlockPrimitive, *ast.ProjectionBlockPrimitive:
+		return nil, fmt.Errorf("2D node %v is only supported within linear_extrude or rotate_extrude", exp.TokenLiteral())
+	case *ast.CallExpression:
+		return nil, fmt.Errorf("%v is not supported", exp.Function.String())
+	}
+	return nil, fmt.Errorf("%v is not supported", exp.TokenLiteral())
+}
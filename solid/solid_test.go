@@ -0,0 +1,150 @@
+package solid
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/gmlewis/go-csg/lexer"
+	"github.com/gmlewis/go-csg/parser"
+)
+
+func TestModel_At(t *testing.T) {
+	type probe struct {
+		p    Vec3
+		want int
+	}
+	tests := []struct {
+		src       string
+		materials int
+		probes    []probe
+	}{
+		{
+			src:       "cube(size = [10, 10, 10], center = false);",
+			materials: 1,
+			probes:    []probe{{Vec3{5, 5, 5}, 0}, {Vec3{-1, 5, 5}, -1}, {Vec3{5, 5, 11}, -1}},
+		},
+		{
+			src:       "difference() { sphere($fn = 0, $fa = 12, $fs = 2, r = 10); cylinder($fn = 0, $fa = 12, $fs = 2, h = 30, r1 = 2, r2 = 2, center = true); }",
+			materials: 1,
+			probes:    []probe{{Vec3{5, 0, 0}, 0}, {Vec3{1, 0, 0}, -1}, {Vec3{9, 9, 0}, -1}},
+		},
+		{
+			src: `color([1, 0, 0, 1]) {
+	cube(size = [10, 10, 10], center = true);
+	color([0, 0, 1, 1]) {
+		multmatrix([[1, 0, 0, 10], [0, 1, 0, 0], [0, 0, 1, 0], [0, 0, 0, 1]]) {
+			cube(size = [10, 10, 10], center = true);
+		}
+	}
+}
+sphere($fn = 0, $fa = 12, $fs = 2, r = 1);`,
+			materials: 3,
+			probes:    []probe{{Vec3{0, 0, 0}, 1}, {Vec3{12, 0, 0}, 2}, {Vec3{16, 0, 0}, -1}},
+		},
+		{
+			src:       "linear_extrude(height = 10, center = false, convexity = 1, twist = 90, scale = [1, 1]) { square(size = [4, 1], center = true); }",
+			materials: 1,
+			probes:    []probe{{Vec3{1.5, 0, 0.1}, 0}, {Vec3{1.5, 0, 9.9}, -1}, {Vec3{0, 1.5, 9.9}, 0}},
+		},
+		{
+			src:       "rotate_extrude(angle = 90, convexity = 2) { multmatrix([[1, 0, 0, 5], [0, 1, 0, 0], [0, 0, 1, 0], [0, 0, 0, 1]]) { circle($fn = 0, $fa = 12, $fs = 2, r = 1); } }",
+			materials: 1,
+			probes:    []probe{{Vec3{5, 0.1, 0}, 0}, {Vec3{0, 5, 0.5}, 0}, {Vec3{0, -5, 0}, -1}, {Vec3{3, 3, 0}, 0}, {Vec3{0, 0, 0}, -1}},
+		},
+		{
+			src:       "rotate_extrude(angle = -90, convexity = 2) { multmatrix([[1, 0, 0, -5], [0, 1, 0, 0], [0, 0, 1, 0], [0, 0, 0, 1]]) { circle($fn = 0, $fa = 12, $fs = 2, r = 1); } }",
+			materials: 1,
+			probes:    []probe{{Vec3{-5, 0.1, 0}, 0}, {Vec3{0, 5, 0.5}, 0}, {Vec3{0, -5, 0}, -1}, {Vec3{-5, -0.1, 0}, -1}, {Vec3{5, 0, 0}, -1}},
+		},
+		{
+			src:       "mirror([1, 0, 0]) { translate([5, 0, 0]) { scale([1, 1, 2]) { cube(size = [1, 1, 1], center = false); } } }",
+			materials: 1,
+			probes:    []probe{{Vec3{-5.5, 0.5, 1.5}, 0}, {Vec3{5.5, 0.5, 0.5}, -1}, {Vec3{-5.5, 0.5, 2.5}, -1}},
+		},
+		{
+			src:       "polyhedron(points = [[0, 0, 0], [10, 0, 0], [0, 10, 0], [0, 0, 10]], faces = [[0, 1, 2], [0, 3, 1], [0, 2, 3], [1, 3, 2]], convexity = 1);",
+			materials: 1,
+			probes:    []probe{{Vec3{1, 1, 1}, 0}, {Vec3{5, 5, 5}, -1}},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test #%v", i), func(t *testing.T) {
+			le := lexer.New(tt.src)
+			p := parser.New(le)
+			program := p.ParseProgram()
+			if errs := p.Errors(); len(errs) != 0 {
+				t.Fatalf("ParseProgram: %v", strings.Join(errs, "\n"))
+			}
+
+			model, err := New(program)
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+			if got := len(model.Materials); got != tt.materials {
+				t.Errorf("materials = %v, want %v", got, tt.materials)
+			}
+			for _, pr := range tt.probes {
+				if got := model.At(pr.p); got != pr.want {
+					t.Errorf("At(%v) = %v, want %v", pr.p, got, pr.want)
+				}
+			}
+		})
+	}
+}
+
+func TestNew_Errors(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{src: "square(size = [1, 1], center = false);", want: "only supported within linear_extrude"},
+		{src: "multmatrix([[0, 0, 0, 0], [0, 1, 0, 0], [0, 0, 1, 0], [0, 0, 0, 1]]) { cube(); }", want: "singular matrix"},
+		{src: "rotate_extrude() { square(size = [2, 1], center = true); }", want: "same X coordinate sign"},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test #%v", i), func(t *testing.T) {
+			le := lexer.New(tt.src)
+			p := parser.New(le)
+			program := p.ParseProgram()
+			if errs := p.Errors(); len(errs) != 0 {
+				t.Fatalf("ParseProgram: %v", strings.Join(errs, "\n"))
+			}
+
+			_, err := New(program)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestGrid_Sample(t *testing.T) {
+	box := Box{Min: Vec3{0, 0, 0}, Max: Vec3{10, 10, 5}}
+	g := NewGrid(box, 2.5)
+	if g.N != [3]int{4, 4, 2} || g.Size != (Vec3{2.5, 2.5, 2.5}) {
+		t.Fatalf("NewGrid = %+v, want 4x4x2 cells of 2.5", g)
+	}
+
+	counts := make([]int, g.N[2])
+	sums := make([]Vec3, g.N[2])
+	g.Sample(func(k int, p Vec3) {
+		counts[k]++
+		for i := range p {
+			sums[k][i] += p[i]
+		}
+	})
+	for k := range counts {
+		want := Vec3{80, 80, 16 * (1.25 + 2.5*float64(k))}
+		if counts[k] != 16 || sums[k] != want {
+			t.Errorf("layer %v: %v cells with sum %v, want 16 cells with sum %v", k, counts[k], sums[k], want)
+		}
+	}
+
+	if got, want := box.CellSize(32), 2.5; math.Abs(got-want) > 1e-12 {
+		t.Errorf("CellSize = %v, want %v", got, want)
+	}
+}
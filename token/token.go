@@ -1,6 +1,8 @@
 // Package token tokenizes the input text.
 package token
 
+import "fmt"
+
 // T represents a token type.
 type T string
 
@@ -8,6 +10,30 @@ type T string
 type Token struct {
 	Type    T
 	Literal string
+	// Pos is the position of the token in the input (if known).
+	Pos Position
+}
+
+// Position represents the position of a token in the input: its byte
+// offset, and its line and column (in bytes), which both start at 1.
+type Position struct {
+	Offset int
+	Line   int
+	Column int
+}
+
+// IsValid reports whether the position is known. Tokens that are not
+// read from an input (e.g. those of a rewritten AST) have no position.
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+// String returns the string representation of the Position.
+func (p Position) String() string {
+	if !p.IsValid() {
+		return "-"
+	}
+	return fmt.Sprintf("%v:%v", p.Line, p.Column)
 }
 
 // These constants represent the various types of possible tokens.
@@ -96,11 +122,11 @@ const (
 	// SURFACE        = "SURFACE"
 
 	// Transformations
-	// TRANSLATE  = "TRANSLATE"
-	// ROTATE     = "ROTATE"
-	// SCALE      = "SCALE"
-	// RESIZE     = "RESIZE"
-	// MIRROR     = "MIRROR"
+	TRANSLATE  = "TRANSLATE"
+	ROTATE     = "ROTATE"
+	SCALE      = "SCALE"
+	RESIZE     = "RESIZE"
+	MIRROR     = "MIRROR"
 	MULTMATRIX = "MULTMATRIX"
 	COLOR      = "COLOR"
 	OFFSET     = "OFFSET"
@@ -207,11 +233,11 @@ var keywords = map[string]T{
 	// "surface":        SURFACE,
 
 	// Transformations
-	// "translate":  TRANSLATE,
-	// "rotate":     ROTATE,
-	// "scale":      SCALE,
-	// "resize":     RESIZE,
-	// "mirror":     MIRROR,
+	"translate":  TRANSLATE,
+	"rotate":     ROTATE,
+	"scale":      SCALE,
+	"resize":     RESIZE,
+	"mirror":     MIRROR,
 	"multmatrix": MULTMATRIX,
 	"color":      COLOR,
 	"offset":     OFFSET,
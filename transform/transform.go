@@ -0,0 +1,355 @@
+// Package transform computes the matrices of the CSG transforms
+// (multmatrix, translate, rotate, scale, mirror and resize) and
+// lowers them to the equivalent multmatrix blocks, which are the
+// only transforms that the backends need to support.
+package transform
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/gmlewis/go-csg/ast"
+	"github.com/gmlewis/go-csg/params"
+	"github.com/gmlewis/go-csg/token"
+)
+
+// Matrix represents an affine transform as a row-major 4x4 matrix
+// (as found in multmatrix).
+type Matrix [4][4]float64
+
+// Identity is the identity transform.
+var Identity = Matrix{{1, 0, 0, 0}, {0, 1, 0, 0}, {0, 0, 1, 0}, {0, 0, 0, 1}}
+
+// Warning represents a problem that does not prevent computing a
+// transform. Resize, Of and Lower return it along with their result,
+// so that their callers decide how to report it.
+type Warning struct {
+	Msg string
+}
+
+// Error returns the message of the Warning.
+func (w *Warning) Error() string {
+	return w.Msg
+}
+
+// Tolerance is used to absorb floating point noise when composing
+// matrices and transforming points.
+const Tolerance = 1e-12
+
+// Snap rounds v to the nearest integer if it is within Tolerance.
+func Snap(v float64) float64 {
+	if r := math.Round(v); math.Abs(v-r) <= Tolerance {
+		return r
+	}
+	return v
+}
+
+// Mul returns the transform that applies o followed by m.
+// Entries within Tolerance of an integer are rounded to it.
+func (m Matrix) Mul(o Matrix) Matrix {
+	var result Matrix
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			for k := 0; k < 4; k++ {
+				result[i][j] += m[i][k] * o[k][j]
+			}
+			result[i][j] = Snap(result[i][j])
+		}
+	}
+	return result
+}
+
+// IsIdentity reports whether m is the identity (within Tolerance).
+func (m Matrix) IsIdentity() bool {
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			if math.Abs(m[i][j]-Identity[i][j]) > Tolerance {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Apply returns the point p transformed by m.
+func (m Matrix) Apply(p [3]float64) [3]float64 {
+	var result [3]float64
+	for i := 0; i < 3; i++ {
+		result[i] = m[i][0]*p[0] + m[i][1]*p[1] + m[i][2]*p[2] + m[i][3]
+	}
+	return result
+}
+
+// Translate returns the transform of translate(v).
+func Translate(v [3]float64) Matrix {
+	m := Identity
+	m[0][3], m[1][3], m[2][3] = v[0], v[1], v[2]
+	return m
+}
+
+// Scale returns the transform of scale(v).
+func Scale(v [3]float64) Matrix {
+	m := Identity
+	m[0][0], m[1][1], m[2][2] = v[0], v[1], v[2]
+	return m
+}
+
+// Rotate returns the transform of a rotate block.
+func Rotate(r *params.RotateParams) Matrix {
+	if r.V != [3]float64{} {
+		return rotateAxis(r.Angle, r.V)
+	}
+	x, y, z := r.A[0], r.A[1], r.A[2]
+	rx := Matrix{{1, 0, 0, 0}, {0, cosDegrees(x), -sinDegrees(x), 0}, {0, sinDegrees(x), cosDegrees(x), 0}, {0, 0, 0, 1}}
+	ry := Matrix{{cosDegrees(y), 0, sinDegrees(y), 0}, {0, 1, 0, 0}, {-sinDegrees(y), 0, cosDegrees(y), 0}, {0, 0, 0, 1}}
+	rz := Matrix{{cosDegrees(z), -sinDegrees(z), 0, 0}, {sinDegrees(z), cosDegrees(z), 0, 0}, {0, 0, 1, 0}, {0, 0, 0, 1}}
+	return rz.Mul(ry).Mul(rx)
+}
+
+// rotateAxis returns the rotation by angle degrees about the axis v.
+func rotateAxis(angle float64, v [3]float64) Matrix {
+	n := math.Sqrt(v[0]*v[0] + v[1]*v[1] + v[2]*v[2])
+	x, y, z := v[0]/n, v[1]/n, v[2]/n
+	c, s := cosDegrees(angle), sinDegrees(angle)
+	t := 1 - c
+	return Matrix{
+		{t*x*x + c, t*x*y - s*z, t*x*z + s*y, 0},
+		{t*x*y + s*z, t*y*y + c, t*y*z - s*x, 0},
+		{t*x*z - s*y, t*y*z + s*x, t*z*z + c, 0},
+		{0, 0, 0, 1},
+	}
+}
+
+// Mirror returns the transform of mirror(v), which reflects about
+// the plane through the origin with the normal v.
+func Mirror(v [3]float64) Matrix {
+	n2 := v[0]*v[0] + v[1]*v[1] + v[2]*v[2]
+	if n2 == 0 {
+		return Identity
+	}
+	m := Identity
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			m[i][j] -= 2 * v[i] * v[j] / n2
+		}
+	}
+	return m
+}
+
+// Resize returns the transform of resize(newsize, auto) applied
+// to children whose bounding box has the given size. Like OpenSCAD,
+// it scales about the origin, and an automatic axis uses the scale
+// of the axis with the largest new size. The Z axis of 2D children
+// (without any thickness) is left unchanged. Resizing an axis along
+// which the children have no size returns a *Warning along with the
+// transform, which leaves that axis unchanged.
+func Resize(newsize [3]float64, auto [3]bool, size [3]float64) (Matrix, error) {
+	dims := 3
+	if size[2] == 0 {
+		dims = 2
+	}
+	scale := [3]float64{1, 1, 1}
+	var flat []string
+	largest := 0
+	for i := 0; i < dims; i++ {
+		if newsize[i] == 0 {
+			continue
+		}
+		if size[i] == 0 {
+			flat = append(flat, axes[i])
+		} else {
+			scale[i] = newsize[i] / size[i]
+		}
+		if newsize[i] > newsize[largest] {
+			largest = i
+		}
+	}
+	autoscale := 1.0
+	if newsize[largest] != 0 && size[largest] != 0 {
+		autoscale = newsize[largest] / size[largest]
+	}
+	for i := 0; i < dims; i++ {
+		if auto[i] && newsize[i] == 0 {
+			scale[i] = autoscale
+		}
+	}
+	if len(flat) > 0 {
+		return Scale(scale), &Warning{Msg: fmt.Sprintf("resize: the children have no size along %v, so resizing them there has no effect", strings.Join(flat, ", "))}
+	}
+	return Scale(scale), nil
+}
+
+var axes = [3]string{"x", "y", "z"}
+
+// sinDegrees returns the sine of x degrees, which is exact for
+// multiples of 30 degrees (like OpenSCAD's sin_degrees).
+func sinDegrees(x float64) float64 {
+	x = math.Mod(x, 360)
+	if x < 0 {
+		x += 360
+	}
+	negate := x >= 180
+	if negate {
+		x -= 180
+	}
+	if x > 90 {
+		x = 180 - x
+	}
+	switch x {
+	case 30:
+		x = 0.5
+	case 90:
+		x = 1
+	default:
+		x = math.Sin(x * math.Pi / 180)
+	}
+	if negate {
+		return -x
+	}
+	return x
+}
+
+// cosDegrees returns the cosine of x degrees, which is exact for
+// multiples of 30 degrees (like OpenSCAD's cos_degrees).
+func cosDegrees(x float64) float64 {
+	return sinDegrees(x + 90)
+}
+
+// Bounds returns the minimum and maximum corners of the bounding box
+// of the children of a block, or false if they are unknown.
+type Bounds func(body *ast.BlockStatement) (min, max [3]float64, ok bool)
+
+// Of returns the matrix of a multmatrix, translate, rotate, scale,
+// mirror or resize block. The bounds are only used by resize.
+// A *Warning error comes with a valid matrix.
+func Of(exp ast.Expression, bounds Bounds) (Matrix, error) {
+	switch n := exp.(type) {
+	case *ast.MultmatrixBlockPrimitive:
+		a, err := params.Parse(n.Arguments)
+		if err != nil {
+			return Identity, fmt.Errorf("multmatrix: %v", err)
+		}
+		obj := a.Get(0, "m")
+		if obj == nil {
+			return Identity, fmt.Errorf("multmatrix: missing matrix")
+		}
+		m, ok := params.ToMatrix(obj)
+		if !ok {
+			return Identity, fmt.Errorf("multmatrix: unable to parse matrix %v", obj.Inspect())
+		}
+		return Matrix(m), nil
+	case *ast.TranslateBlockPrimitive:
+		v, err := params.Translate(n.Arguments)
+		if err != nil {
+			return Identity, fmt.Errorf("translate: %v", err)
+		}
+		return Translate(v), nil
+	case *ast.RotateBlockPrimitive:
+		r, err := params.Rotate(n.Arguments)
+		if err != nil {
+			return Identity, fmt.Errorf("rotate: %v", err)
+		}
+		return Rotate(r), nil
+	case *ast.ScaleBlockPrimitive:
+		v, err := params.Scale(n.Arguments)
+		if err != nil {
+			return Identity, fmt.Errorf("scale: %v", err)
+		}
+		return Scale(v), nil
+	case *ast.MirrorBlockPrimitive:
+		v, err := params.Mirror(n.Arguments)
+		if err != nil {
+			return Identity, fmt.Errorf("mirror: %v", err)
+		}
+		return Mirror(v), nil
+	case *ast.ResizeBlockPrimitive:
+		newsize, auto, err := params.Resize(n.Arguments)
+		if err != nil {
+			return Identity, fmt.Errorf("resize: %v", err)
+		}
+		if bounds == nil {
+			return Identity, fmt.Errorf("resize: unknown bounding box of its children")
+		}
+		min, max, ok := bounds(n.Body)
+		if !ok {
+			return Identity, fmt.Errorf("resize: unknown bounding box of its children")
+		}
+		return Resize(newsize, auto, [3]float64{max[0] - min[0], max[1] - min[1], max[2] - min[2]})
+	}
+	return Identity, fmt.Errorf("%v is not a transform", exp.TokenLiteral())
+}
+
+// Body returns the body of a transform block (see Of).
+func Body(exp ast.Expression) *ast.BlockStatement {
+	switch n := exp.(type) {
+	case *ast.MultmatrixBlockPrimitive:
+		return n.Body
+	case *ast.TranslateBlockPrimitive:
+		return n.Body
+	case *ast.RotateBlockPrimitive:
+		return n.Body
+	case *ast.ScaleBlockPrimitive:
+		return n.Body
+	case *ast.MirrorBlockPrimitive:
+		return n.Body
+	case *ast.ResizeBlockPrimitive:
+		return n.Body
+	}
+	return nil
+}
+
+// Lower returns the multmatrix block that is equivalent to a
+// transform block (see Of), with the same body. Like Of, it may
+// return a *Warning along with the block.
+func Lower(exp ast.Expression, bounds Bounds) (*ast.MultmatrixBlockPrimitive, error) {
+	if n, ok := exp.(*ast.MultmatrixBlockPrimitive); ok {
+		return n, nil
+	}
+	m, err := Of(exp, bounds)
+	if _, ok := err.(*Warning); err != nil && !ok {
+		return nil, err
+	}
+	return &ast.MultmatrixBlockPrimitive{
+		Token:     token.Token{Type: token.MULTMATRIX, Literal: "multmatrix"},
+		Arguments: []ast.Expression{Literal(m)},
+		Body:      Body(exp),
+	}, err
+}
+
+// Literal returns the matrix as an array literal.
+func Literal(m Matrix) *ast.ArrayLiteral {
+	rows := make([]ast.Expression, 0, 4)
+	for _, row := range m {
+		elements := make([]ast.Expression, 0, 4)
+		for _, v := range row {
+			elements = append(elements, Number(v))
+		}
+		rows = append(rows, Array(elements))
+	}
+	return Array(rows)
+}
+
+// Number returns a literal for v, using an integer literal
+// when v is integral.
+func Number(v float64) ast.Expression {
+	if v < 0 {
+		return &ast.PrefixExpression{
+			Token:    token.Token{Type: token.MINUS, Literal: "-"},
+			Operator: "-",
+			Right:    Number(-v),
+		}
+	}
+	if v == math.Trunc(v) && v < 1e15 {
+		return &ast.IntegerLiteral{Token: token.Token{Type: token.INT, Literal: strconv.FormatInt(int64(v), 10)}, Value: int64(v)}
+	}
+	// The lexer does not accept a '+' within a number.
+	literal := strings.Replace(strconv.FormatFloat(v, 'g', -1, 64), "e+", "e", 1)
+	return &ast.FloatLiteral{Token: token.Token{Type: token.FLOAT, Literal: literal}, Value: v}
+}
+
+// Array returns an array literal of the elements.
+func Array(elements []ast.Expression) *ast.ArrayLiteral {
+	return &ast.ArrayLiteral{Token: token.Token{Type: token.LBRACKET, Literal: "["}, Elements: elements}
+}
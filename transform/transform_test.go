@@ -0,0 +1,175 @@
+package transform
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/gmlewis/go-csg/ast"
+	"github.com/gmlewis/go-csg/lexer"
+	"github.com/gmlewis/go-csg/parser"
+)
+
+func parse(t *testing.T, src string) ast.Expression {
+	t.Helper()
+	le := lexer.New(src)
+	p := parser.New(le)
+	program := p.ParseProgram()
+	if errs := p.Errors(); len(errs) != 0 {
+		t.Fatalf("ParseProgram: %v", strings.Join(errs, "\n"))
+	}
+	return program.Statements[0].(*ast.ExpressionStatement).Expression
+}
+
+func TestOf(t *testing.T) {
+	// bounds returns the bounds of a 1x2 square at X=-1,
+	// or of cube([1, 2, 3]).
+	bounds := func(flat bool) Bounds {
+		return func(body *ast.BlockStatement) (min, max [3]float64, ok bool) {
+			if flat {
+				return [3]float64{-1, 0, 0}, [3]float64{0, 2, 0}, true
+			}
+			return [3]float64{0, 0, 0}, [3]float64{1, 2, 3}, true
+		}
+	}
+
+	tests := []struct {
+		src  string
+		flat bool
+		want Matrix
+	}{
+		{
+			src:  "multmatrix([[1, 0, 0, 1], [0, 1, 0, 2], [0, 0, 1, 3], [0, 0, 0, 1]]) { cube(); }",
+			want: Matrix{{1, 0, 0, 1}, {0, 1, 0, 2}, {0, 0, 1, 3}, {0, 0, 0, 1}},
+		},
+		{
+			src:  "translate([1, 2, 3]) { cube(); }",
+			want: Matrix{{1, 0, 0, 1}, {0, 1, 0, 2}, {0, 0, 1, 3}, {0, 0, 0, 1}},
+		},
+		{
+			src:  "translate(v = [1, 2]) { square(); }",
+			want: Matrix{{1, 0, 0, 1}, {0, 1, 0, 2}, {0, 0, 1, 0}, {0, 0, 0, 1}},
+		},
+		{
+			src:  "rotate(90) { cube(); }",
+			want: Matrix{{0, -1, 0, 0}, {1, 0, 0, 0}, {0, 0, 1, 0}, {0, 0, 0, 1}},
+		},
+		{
+			src:  "rotate([90, 0, 90]) { cube(); }",
+			want: Matrix{{0, 0, 1, 0}, {1, 0, 0, 0}, {0, 1, 0, 0}, {0, 0, 0, 1}},
+		},
+		{
+			src:  "rotate(a = 180, v = [1, 1, 0]) { cube(); }",
+			want: Matrix{{0, 1, 0, 0}, {1, 0, 0, 0}, {0, 0, -1, 0}, {0, 0, 0, 1}},
+		},
+		{
+			src:  "rotate(a = 30) { cube(); }",
+			want: Matrix{{math.Sqrt(3) / 2, -0.5, 0, 0}, {0.5, math.Sqrt(3) / 2, 0, 0}, {0, 0, 1, 0}, {0, 0, 0, 1}},
+		},
+		{
+			src:  "scale(2) { cube(); }",
+			want: Matrix{{2, 0, 0, 0}, {0, 2, 0, 0}, {0, 0, 2, 0}, {0, 0, 0, 1}},
+		},
+		{
+			src:  "scale([2, 3]) { square(); }",
+			want: Matrix{{2, 0, 0, 0}, {0, 3, 0, 0}, {0, 0, 1, 0}, {0, 0, 0, 1}},
+		},
+		{
+			src:  "mirror([1, 0, 0]) { cube(); }",
+			want: Matrix{{-1, 0, 0, 0}, {0, 1, 0, 0}, {0, 0, 1, 0}, {0, 0, 0, 1}},
+		},
+		{
+			src:  "mirror([1, 1, 0]) { cube(); }",
+			want: Matrix{{0, -1, 0, 0}, {-1, 0, 0, 0}, {0, 0, 1, 0}, {0, 0, 0, 1}},
+		},
+		{
+			src:  "resize([2, 0, 6]) { cube([1, 2, 3]); }",
+			want: Matrix{{2, 0, 0, 0}, {0, 1, 0, 0}, {0, 0, 2, 0}, {0, 0, 0, 1}},
+		},
+		{
+			src:  "resize(newsize = [0, 8, 0], auto = [true, false, true]) { cube([1, 2, 3]); }",
+			want: Matrix{{4, 0, 0, 0}, {0, 4, 0, 0}, {0, 0, 4, 0}, {0, 0, 0, 1}},
+		},
+		{
+			src:  "resize([3, 0], auto = true) { square([1, 2]); }",
+			flat: true,
+			want: Matrix{{3, 0, 0, 0}, {0, 3, 0, 0}, {0, 0, 1, 0}, {0, 0, 0, 1}},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test #%v", i), func(t *testing.T) {
+			got, err := Of(parse(t, tt.src), bounds(tt.flat))
+			if err != nil {
+				t.Fatalf("Of: %v", err)
+			}
+			for r := range got {
+				for c := range got[r] {
+					if math.Abs(got[r][c]-tt.want[r][c]) > 1e-12 {
+						t.Fatalf("Of = %v, want %v", got, tt.want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestLower(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{
+			src:  "translate([1, -2.5, 0]) { cube(); }",
+			want: "multmatrix([[1, 0, 0, 1], [0, 1, 0, (-2.5)], [0, 0, 1, 0], [0, 0, 0, 1]]) { cube() }",
+		},
+		{
+			src:  "mirror([0, 1, 0]) { cube(); sphere(); }",
+			want: "multmatrix([[1, 0, 0, 0], [0, (-1), 0, 0], [0, 0, 1, 0], [0, 0, 0, 1]]) { cube(); sphere() }",
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test #%v", i), func(t *testing.T) {
+			got, err := Lower(parse(t, tt.src), nil)
+			if err != nil {
+				t.Fatalf("Lower: %v", err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("Lower = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResize_Warning(t *testing.T) {
+	got, err := Resize([3]float64{2, 2, 6}, [3]bool{}, [3]float64{0, 1, 3})
+	if _, ok := err.(*Warning); !ok || !strings.Contains(err.Error(), "no size along x") {
+		t.Errorf("Resize err = %v, want a warning about x", err)
+	}
+	if want := Scale([3]float64{1, 2, 2}); got != want {
+		t.Errorf("Resize = %v, want %v", got, want)
+	}
+}
+
+func TestOf_Errors(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{src: "resize([1, 1, 1]) { cube(); }", want: "unknown bounding box"},
+		{src: "translate(1) { cube(); }", want: "expected vector"},
+		{src: "resize(auto = 1) { cube(); }", want: "expected boolean"},
+		{src: "union() { cube(); }", want: "not a transform"},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test #%v", i), func(t *testing.T) {
+			_, err := Of(parse(t, tt.src), nil)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}